@@ -21,13 +21,13 @@ export const Sidebar: React.FC<SidebarProps> = ({ role, ref, isMenuOpen }) => {
       path: ROUTES.HOME,
       label: 'Главная',
       icon: '🏠',
-      roles: ['engineer', 'manager', 'observer'],
+      roles: ['engineer', 'manager', 'observer', 'contractor'],
     },
     {
       path: ROUTES.DEFECTS,
       label: 'Все дефекты',
       icon: '🐛',
-      roles: ['engineer', 'manager', 'observer'],
+      roles: ['engineer', 'manager', 'observer', 'contractor'],
     },
     {
       path: ROUTES.DEFECTS_CREATE,
@@ -51,7 +51,7 @@ export const Sidebar: React.FC<SidebarProps> = ({ role, ref, isMenuOpen }) => {
       path: ROUTES.PROFILE,
       label: 'Мой профиль',
       icon: '👤',
-      roles: ['engineer', 'manager', 'observer'],
+      roles: ['engineer', 'manager', 'observer', 'contractor'],
     },
   ]
 
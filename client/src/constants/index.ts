@@ -4,6 +4,7 @@ export const ROLES = {
   engineer: 'engineer',
   manager: 'manager',
   observer: 'observer',
+  contractor: 'contractor',
 } as const
 
 export const DEFECT_STATUSES = {
@@ -65,6 +66,7 @@ export const ROLE_PERMISSIONS = {
     'comments:create',
   ],
   observer: ['defects:view', 'reports:view'],
+  contractor: ['defects:view', 'defects:edit_own', 'comments:create'],
 } as const
 
 export const STORAGE_KEYS = {
@@ -66,7 +66,11 @@ export const DefectDetailsPage: React.FC = () => {
     if (!defect) return
 
     try {
-      await defectService.updateDefectStatus(defect.id, newStatus as any)
+      await defectService.updateDefectStatus(
+        defect.id,
+        newStatus as any,
+        defect.version
+      )
       await loadDefectData() // Перезагружаем данные
     } catch (err) {
       setError(err instanceof Error ? err.message : 'Ошибка обновления статуса')
@@ -90,7 +90,7 @@ export const EditDefectPage: React.FC = () => {
       // Преобразуем project_id в число
       updateData.project_id = parseInt(updateData.project_id)
 
-      await defectService.updateDefect(defect.id, updateData)
+      await defectService.updateDefect(defect.id, updateData, defect.version)
       navigate(`/defects/${defect.id}`)
     } catch (err) {
       setError(err instanceof Error ? err.message : 'Ошибка обновления дефекта')
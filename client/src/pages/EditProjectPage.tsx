@@ -74,7 +74,7 @@ export const EditProjectPage: React.FC = () => {
         updateData.manager_id = parseInt(updateData.manager_id)
       }
 
-      await projectService.updateProject(project.id, updateData)
+      await projectService.updateProject(project.id, updateData, project.version)
       navigate(`/projects/${project.id}`)
     } catch (err) {
       setError(err instanceof Error ? err.message : 'Ошибка обновления проекта')
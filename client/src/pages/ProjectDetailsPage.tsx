@@ -45,7 +45,12 @@ export const ProjectDetailsPage: React.FC = () => {
 
   const handleStatusChange = async (defectId: number, newStatus: string) => {
     try {
-      await defectService.updateDefectStatus(defectId, newStatus as any)
+      const defect = defects.find((d) => d.id === defectId)
+      await defectService.updateDefectStatus(
+        defectId,
+        newStatus as any,
+        defect?.version ?? 0
+      )
       await loadProjectData() // Перезагружаем данные
     } catch (err) {
       setError(err instanceof Error ? err.message : 'Ошибка обновления статуса')
@@ -0,0 +1,160 @@
+import { api, handleApiResponse, handleApiError } from './api'
+import type {
+  Contractor,
+  ContractorMember,
+  ContractorRanking,
+  ProjectContractor,
+  ApiResponse,
+} from '../types'
+
+export interface ContractorData {
+  name: string
+  tax_id?: string
+  contact_email?: string
+  contact_phone?: string
+}
+
+export const contractorService = {
+  async getContractors(search?: string): Promise<{ contractors: Contractor[] }> {
+    try {
+      const response = await api.get<
+        ApiResponse<{ contractors: Contractor[] }>
+      >('/api/contractors', { params: { search } })
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async getContractor(id: number): Promise<{ contractor: Contractor }> {
+    try {
+      const response = await api.get<ApiResponse<{ contractor: Contractor }>>(
+        `/api/contractors/${id}`
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async createContractor(
+    data: ContractorData
+  ): Promise<{ contractor: Contractor }> {
+    try {
+      const response = await api.post<ApiResponse<{ contractor: Contractor }>>(
+        '/api/contractors',
+        data
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async updateContractor(
+    id: number,
+    data: ContractorData
+  ): Promise<{ contractor: Contractor }> {
+    try {
+      const response = await api.put<ApiResponse<{ contractor: Contractor }>>(
+        `/api/contractors/${id}`,
+        data
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async deleteContractor(id: number): Promise<{ message: string }> {
+    try {
+      const response = await api.delete<ApiResponse<{ message: string }>>(
+        `/api/contractors/${id}`
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async addMember(
+    id: number,
+    userId: number
+  ): Promise<{ member: ContractorMember }> {
+    try {
+      const response = await api.post<
+        ApiResponse<{ member: ContractorMember }>
+      >(`/api/contractors/${id}/members`, { user_id: userId })
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async removeMember(id: number, userId: number): Promise<{ message: string }> {
+    try {
+      const response = await api.delete<ApiResponse<{ message: string }>>(
+        `/api/contractors/${id}/members/${userId}`
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async getProjectContractors(
+    projectId: number
+  ): Promise<{ contractors: ProjectContractor[] }> {
+    try {
+      const response = await api.get<
+        ApiResponse<{ contractors: ProjectContractor[] }>
+      >(`/api/projects/${projectId}/contractors`)
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async setProjectContractor(
+    projectId: number,
+    contractorId: number,
+    data: { contract_number?: string; category_ids?: number[] }
+  ): Promise<{ contractor: ProjectContractor }> {
+    try {
+      const response = await api.put<
+        ApiResponse<{ contractor: ProjectContractor }>
+      >(`/api/projects/${projectId}/contractors/${contractorId}`, data)
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async removeProjectContractor(
+    projectId: number,
+    contractorId: number
+  ): Promise<{ message: string }> {
+    try {
+      const response = await api.delete<ApiResponse<{ message: string }>>(
+        `/api/projects/${projectId}/contractors/${contractorId}`
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async getRanking(params?: {
+    project_id?: number
+    sort_by?: 'open' | 'overdue'
+  }): Promise<{ ranking: ContractorRanking[] }> {
+    try {
+      const response = await api.get<
+        ApiResponse<{ ranking: ContractorRanking[] }>
+      >('/api/contractors/ranking', { params })
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+}
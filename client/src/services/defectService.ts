@@ -5,9 +5,37 @@ import type {
   CreateDefectData,
   UpdateDefectData,
   DefectStatus,
+  DefectWatcher,
+  WorkLog,
+  WorkLogData,
+  WorkLogTotals,
+  BackCharge,
+  BackChargeData,
+  BackChargeDecision,
+  AcceptanceStatus,
+  AcceptanceData,
+  DefectAcceptance,
   ApiResponse,
 } from '../types'
 
+interface AcceptanceResponse {
+  acceptance_status?: AcceptanceStatus
+  accepted_by?: number
+  accepted_at?: string
+  reopen_count: number
+  acceptances: DefectAcceptance[]
+}
+
+interface AcceptanceStepResponse {
+  defect: Defect
+  acceptance: DefectAcceptance
+}
+
+interface WorkLogsResponse {
+  work_logs: WorkLog[]
+  totals: WorkLogTotals
+}
+
 // Создаем интерфейс для ответа сервера
 interface DefectsResponse {
   defects: Defect[]
@@ -61,12 +89,14 @@ export const defectService = {
 
   async updateDefect(
     id: number,
-    defectData: UpdateDefectData
+    defectData: UpdateDefectData,
+    version: number
   ): Promise<{ defect: Defect }> {
     try {
       const response = await api.put<ApiResponse<{ defect: Defect }>>(
         `/api/defects/${id}`,
-        defectData
+        defectData,
+        { headers: { 'If-Match': `"${version}"` } }
       )
       return handleApiResponse(response)
     } catch (error) {
@@ -76,12 +106,14 @@ export const defectService = {
 
   async updateDefectStatus(
     id: number,
-    status: DefectStatus
+    status: DefectStatus,
+    version: number
   ): Promise<{ defect: Defect }> {
     try {
       const response = await api.patch<ApiResponse<{ defect: Defect }>>(
         `/api/defects/${id}/status`,
-        { status }
+        { status },
+        { headers: { 'If-Match': `"${version}"` } }
       )
       return handleApiResponse(response)
     } catch (error) {
@@ -124,4 +156,175 @@ export const defectService = {
       throw new Error(handleApiError(error))
     }
   },
+
+  async getDefectWatchers(
+    defectId: number
+  ): Promise<{ watchers: DefectWatcher[]; watching: boolean }> {
+    try {
+      const response = await api.get<
+        ApiResponse<{ watchers: DefectWatcher[]; watching: boolean }>
+      >(`/api/defects/${defectId}/watchers`)
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async watchDefect(defectId: number): Promise<{ watching: boolean }> {
+    try {
+      const response = await api.post<ApiResponse<{ watching: boolean }>>(
+        `/api/defects/${defectId}/watch`
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async unwatchDefect(defectId: number): Promise<{ watching: boolean }> {
+    try {
+      const response = await api.delete<ApiResponse<{ watching: boolean }>>(
+        `/api/defects/${defectId}/watch`
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async getWorkLogs(defectId: number): Promise<WorkLogsResponse> {
+    try {
+      const response = await api.get<ApiResponse<WorkLogsResponse>>(
+        `/api/defects/${defectId}/worklogs`
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async logTime(
+    defectId: number,
+    data: WorkLogData
+  ): Promise<WorkLogsResponse> {
+    try {
+      const response = await api.post<ApiResponse<WorkLogsResponse>>(
+        `/api/defects/${defectId}/worklogs`,
+        data
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async updateWorkLog(
+    defectId: number,
+    logId: number,
+    data: WorkLogData
+  ): Promise<WorkLogsResponse> {
+    try {
+      const response = await api.put<ApiResponse<WorkLogsResponse>>(
+        `/api/defects/${defectId}/worklogs/${logId}`,
+        data
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async deleteWorkLog(
+    defectId: number,
+    logId: number
+  ): Promise<WorkLogsResponse> {
+    try {
+      const response = await api.delete<ApiResponse<WorkLogsResponse>>(
+        `/api/defects/${defectId}/worklogs/${logId}`
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async getBackCharges(defectId: number): Promise<{ back_charges: BackCharge[] }> {
+    try {
+      const response = await api.get<ApiResponse<{ back_charges: BackCharge[] }>>(
+        `/api/defects/${defectId}/back-charges`
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async createBackCharge(
+    defectId: number,
+    data: BackChargeData
+  ): Promise<{ back_charge: BackCharge }> {
+    try {
+      const response = await api.post<ApiResponse<{ back_charge: BackCharge }>>(
+        `/api/defects/${defectId}/back-charges`,
+        data
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async decideBackCharge(
+    chargeId: number,
+    decision: BackChargeDecision
+  ): Promise<{ back_charge: BackCharge }> {
+    try {
+      const response = await api.patch<ApiResponse<{ back_charge: BackCharge }>>(
+        `/api/back-charges/${chargeId}/status`,
+        decision
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async deleteBackCharge(chargeId: number): Promise<{ message: string }> {
+    try {
+      const response = await api.delete<ApiResponse<{ message: string }>>(
+        `/api/back-charges/${chargeId}`
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async getAcceptance(defectId: number): Promise<AcceptanceResponse> {
+    try {
+      const response = await api.get<ApiResponse<AcceptanceResponse>>(
+        `/api/defects/${defectId}/acceptance`
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  // action: request - предъявить исправление, accept/reject - решение надзора
+  async submitAcceptance(
+    defectId: number,
+    action: 'request' | 'accept' | 'reject',
+    data: AcceptanceData = {}
+  ): Promise<AcceptanceStepResponse> {
+    try {
+      const response = await api.post<ApiResponse<AcceptanceStepResponse>>(
+        `/api/defects/${defectId}/acceptance/${action}`,
+        data
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
 }
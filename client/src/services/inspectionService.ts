@@ -0,0 +1,171 @@
+import { api, handleApiResponse, handleApiError } from './api'
+import type {
+  Defect,
+  InspectionItem,
+  InspectionResult,
+  InspectionRound,
+  InspectionRoundData,
+  InspectionSchedule,
+  InspectionScheduleData,
+  InspectionStatus,
+  InspectionSummary,
+  ApiResponse,
+} from '../types'
+
+interface InspectionRoundResponse {
+  inspection_round: InspectionRound
+  defects: Defect[]
+}
+
+export const inspectionService = {
+  async getSchedules(
+    projectId: number
+  ): Promise<{ inspection_schedules: InspectionSchedule[] }> {
+    try {
+      const response = await api.get<
+        ApiResponse<{ inspection_schedules: InspectionSchedule[] }>
+      >(`/api/projects/${projectId}/inspection-schedules`)
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async createSchedule(
+    projectId: number,
+    data: InspectionScheduleData
+  ): Promise<{ inspection_schedule: InspectionSchedule }> {
+    try {
+      const response = await api.post<
+        ApiResponse<{ inspection_schedule: InspectionSchedule }>
+      >(`/api/projects/${projectId}/inspection-schedules`, data)
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async updateSchedule(
+    id: number,
+    data: InspectionScheduleData
+  ): Promise<{ inspection_schedule: InspectionSchedule }> {
+    try {
+      const response = await api.put<
+        ApiResponse<{ inspection_schedule: InspectionSchedule }>
+      >(`/api/inspection-schedules/${id}`, data)
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async deleteSchedule(id: number): Promise<{ message: string }> {
+    try {
+      const response = await api.delete<ApiResponse<{ message: string }>>(
+        `/api/inspection-schedules/${id}`
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async getRounds(
+    projectId: number,
+    params?: { status?: InspectionStatus; from?: string; to?: string }
+  ): Promise<{ inspection_rounds: InspectionRound[] }> {
+    try {
+      const response = await api.get<
+        ApiResponse<{ inspection_rounds: InspectionRound[] }>
+      >(`/api/projects/${projectId}/inspection-rounds`, { params })
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async createRound(
+    projectId: number,
+    data: InspectionRoundData
+  ): Promise<InspectionRoundResponse> {
+    try {
+      const response = await api.post<ApiResponse<InspectionRoundResponse>>(
+        `/api/projects/${projectId}/inspection-rounds`,
+        data
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async getRound(id: number): Promise<InspectionRoundResponse> {
+    try {
+      const response = await api.get<ApiResponse<InspectionRoundResponse>>(
+        `/api/inspection-rounds/${id}`
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async updateRoundStatus(
+    id: number,
+    status: Exclude<InspectionStatus, 'planned'>,
+    summary?: string
+  ): Promise<InspectionRoundResponse> {
+    try {
+      const response = await api.patch<ApiResponse<InspectionRoundResponse>>(
+        `/api/inspection-rounds/${id}/status`,
+        { status, summary }
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async updateItem(
+    roundId: number,
+    itemId: number,
+    result: InspectionResult,
+    note?: string
+  ): Promise<{ item: InspectionItem }> {
+    try {
+      const response = await api.put<ApiResponse<{ item: InspectionItem }>>(
+        `/api/inspection-rounds/${roundId}/items/${itemId}`,
+        { result, note }
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async deleteRound(id: number): Promise<{ message: string }> {
+    try {
+      const response = await api.delete<ApiResponse<{ message: string }>>(
+        `/api/inspection-rounds/${id}`
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async getSummary(
+    projectId: number,
+    params?: { from?: string; to?: string }
+  ): Promise<InspectionSummary> {
+    try {
+      const response = await api.get<ApiResponse<InspectionSummary>>(
+        `/api/projects/${projectId}/inspection-summary`,
+        { params }
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+}
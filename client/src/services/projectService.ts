@@ -2,7 +2,14 @@ import { api, handleApiResponse, handleApiError } from './api'
 import type {
   Project,
   ProjectFilters,
+  ProjectPhase,
   CreateProjectData,
+  CloneProjectData,
+  ProjectCloneResult,
+  ProjectRollup,
+  ProjectMember,
+  ProjectWatcher,
+  EngineerWorkload,
   ApiResponse,
 } from '../types'
 
@@ -32,11 +39,13 @@ export const projectService = {
     }
   },
 
-  async getProjectById(id: number): Promise<{ project: Project }> {
+  async getProjectById(
+    id: number
+  ): Promise<{ project: Project; ancestors: Project[] }> {
     try {
-      const response = await api.get<ApiResponse<{ project: Project }>>(
-        `/api/projects/${id}`
-      )
+      const response = await api.get<
+        ApiResponse<{ project: Project; ancestors: Project[] }>
+      >(`/api/projects/${id}`)
       return handleApiResponse(response)
     } catch (error) {
       throw new Error(handleApiError(error))
@@ -59,12 +68,59 @@ export const projectService = {
 
   async updateProject(
     id: number,
-    projectData: Partial<CreateProjectData>
+    projectData: Partial<CreateProjectData>,
+    version: number
   ): Promise<{ project: Project }> {
     try {
       const response = await api.put<ApiResponse<{ project: Project }>>(
         `/api/projects/${id}`,
-        projectData
+        projectData,
+        { headers: { 'If-Match': `"${version}"` } }
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async updateProjectPhase(
+    id: number,
+    phase: ProjectPhase,
+    version: number
+  ): Promise<{ project: Project }> {
+    try {
+      const response = await api.patch<ApiResponse<{ project: Project }>>(
+        `/api/projects/${id}/phase`,
+        { phase },
+        { headers: { 'If-Match': `"${version}"` } }
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async moveProject(
+    id: number,
+    parentId: number | null,
+    version: number
+  ): Promise<{ project: Project }> {
+    try {
+      const response = await api.patch<ApiResponse<{ project: Project }>>(
+        `/api/projects/${id}/parent`,
+        { parent_id: parentId },
+        { headers: { 'If-Match': `"${version}"` } }
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async getProjectRollup(id: number): Promise<{ rollup: ProjectRollup }> {
+    try {
+      const response = await api.get<ApiResponse<{ rollup: ProjectRollup }>>(
+        `/api/projects/${id}/rollup`
       )
       return handleApiResponse(response)
     } catch (error) {
@@ -72,6 +128,34 @@ export const projectService = {
     }
   },
 
+  async saveAsTemplate(
+    id: number,
+    data: { name: string; description?: string }
+  ): Promise<{ project: Project; cloned: ProjectCloneResult }> {
+    try {
+      const response = await api.post<
+        ApiResponse<{ project: Project; cloned: ProjectCloneResult }>
+      >(`/api/projects/${id}/template`, data)
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async cloneProject(
+    id: number,
+    data: CloneProjectData
+  ): Promise<{ project: Project; cloned: ProjectCloneResult }> {
+    try {
+      const response = await api.post<
+        ApiResponse<{ project: Project; cloned: ProjectCloneResult }>
+      >(`/api/projects/${id}/clone`, data)
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
   async deleteProject(id: number): Promise<{ message: string }> {
     try {
       const response = await api.delete<ApiResponse<{ message: string }>>(
@@ -96,4 +180,96 @@ export const projectService = {
       throw new Error(handleApiError(error))
     }
   },
+
+  async getProjectMembers(
+    id: number
+  ): Promise<{ members: ProjectMember[] }> {
+    try {
+      const response = await api.get<
+        ApiResponse<{ members: ProjectMember[] }>
+      >(`/api/projects/${id}/members`)
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async addProjectMember(
+    id: number,
+    userId: number
+  ): Promise<{ member: ProjectMember }> {
+    try {
+      const response = await api.post<ApiResponse<{ member: ProjectMember }>>(
+        `/api/projects/${id}/members`,
+        { user_id: userId }
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async removeProjectMember(
+    id: number,
+    userId: number
+  ): Promise<{ message: string }> {
+    try {
+      const response = await api.delete<ApiResponse<{ message: string }>>(
+        `/api/projects/${id}/members/${userId}`
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async getWorkload(
+    projectId?: number
+  ): Promise<{ workload: EngineerWorkload[] }> {
+    try {
+      const response = await api.get<
+        ApiResponse<{ workload: EngineerWorkload[] }>
+      >('/api/workload', {
+        params: projectId ? { project_id: projectId } : undefined,
+      })
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async getProjectWatchers(
+    id: number
+  ): Promise<{ watchers: ProjectWatcher[]; watching: boolean }> {
+    try {
+      const response = await api.get<
+        ApiResponse<{ watchers: ProjectWatcher[]; watching: boolean }>
+      >(`/api/projects/${id}/watchers`)
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async watchProject(id: number): Promise<{ watching: boolean }> {
+    try {
+      const response = await api.post<ApiResponse<{ watching: boolean }>>(
+        `/api/projects/${id}/watch`
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async unwatchProject(id: number): Promise<{ watching: boolean }> {
+    try {
+      const response = await api.delete<ApiResponse<{ watching: boolean }>>(
+        `/api/projects/${id}/watch`
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
 }
@@ -1,5 +1,15 @@
 import { api, handleApiResponse, handleApiError } from './api'
-import type { DefectFilters, ApiResponse } from '../types'
+import type {
+  DefectFilters,
+  ContractorRanking,
+  TimeReportGroup,
+  TimeReportRow,
+  TimeReportFilters,
+  WorkLogTotals,
+  CostReportGroup,
+  CostRollup,
+  ApiResponse,
+} from '../types'
 
 export interface DefectsReport {
   total_defects: number
@@ -9,6 +19,27 @@ export interface DefectsReport {
   avg_resolution_time: number
 }
 
+export interface ContractorsReport {
+  contractors: ContractorRanking[]
+  totals: {
+    open_defects: number
+    overdue_defects: number
+  }
+}
+
+export interface TimeReport {
+  group_by: TimeReportGroup
+  rows: TimeReportRow[]
+  totals: WorkLogTotals
+}
+
+// Итоги считаются отдельно по каждой валюте
+export interface CostReport {
+  group_by: CostReportGroup
+  rows: CostRollup[]
+  totals: CostRollup[]
+}
+
 export interface ProjectReport {
   project_id: number
   project_name: string
@@ -73,4 +104,61 @@ export const reportService = {
       throw new Error(handleApiError(error))
     }
   },
+
+  async getContractorsReport(params?: {
+    project_id?: number
+    sort_by?: 'open' | 'overdue'
+  }): Promise<{ report: ContractorsReport }> {
+    try {
+      const response = await api.get<
+        ApiResponse<{ report: ContractorsReport }>
+      >('/api/reports/contractors', { params })
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async getTimeReport(
+    filters?: TimeReportFilters
+  ): Promise<{ report: TimeReport }> {
+    try {
+      const response = await api.get<ApiResponse<{ report: TimeReport }>>(
+        '/api/reports/time',
+        {
+          params: filters,
+        }
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async exportTimeReportToCSV(filters?: TimeReportFilters): Promise<Blob> {
+    try {
+      const response = await api.get('/api/reports/time', {
+        params: { ...filters, format: 'csv' },
+        responseType: 'blob',
+      })
+      return response.data
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async getCostsReport(params?: {
+    group_by?: CostReportGroup
+    project_id?: number
+  }): Promise<{ report: CostReport }> {
+    try {
+      const response = await api.get<ApiResponse<{ report: CostReport }>>(
+        '/api/reports/costs',
+        { params }
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
 }
@@ -0,0 +1,45 @@
+import { api, handleApiResponse, handleApiError } from './api'
+import type { Defect, Project, TrashItem, ApiResponse } from '../types'
+
+interface TrashResponse {
+  items: TrashItem[]
+  retention_days: number
+}
+
+export const trashService = {
+  async getTrash(params?: {
+    type?: TrashItem['type']
+    project_id?: number
+  }): Promise<TrashResponse> {
+    try {
+      const response = await api.get<ApiResponse<TrashResponse>>('/api/trash', {
+        params,
+      })
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async restoreDefect(id: number): Promise<{ defect: Defect }> {
+    try {
+      const response = await api.post<ApiResponse<{ defect: Defect }>>(
+        `/api/trash/defects/${id}/restore`
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+
+  async restoreProject(id: number): Promise<{ project: Project }> {
+    try {
+      const response = await api.post<ApiResponse<{ project: Project }>>(
+        `/api/trash/projects/${id}/restore`
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
+}
@@ -102,4 +102,19 @@ export const userService = {
       throw new Error(handleApiError(error))
     }
   },
+
+  async setUserActive(
+    userId: number,
+    isActive: boolean
+  ): Promise<{ user: User }> {
+    try {
+      const response = await api.patch<ApiResponse<{ user: User }>>(
+        `/api/users/${userId}/active`,
+        { is_active: isActive }
+      )
+      return handleApiResponse(response)
+    } catch (error) {
+      throw new Error(handleApiError(error))
+    }
+  },
 }
@@ -5,21 +5,371 @@ export interface User {
   full_name: string
   role_id: number
   role_name: UserRole
+  is_active?: boolean
   created_at?: string
   updated_at?: string
 }
 
+export type ProjectPhase =
+  | 'planning'
+  | 'construction'
+  | 'handover'
+  | 'warranty'
+  | 'archived'
+
 export interface Project {
   id: number
   name: string
   description: string
   manager_id: number
+  phase: ProjectPhase
+  level: ProjectLevel
+  parent_id?: number
+  path: string
+  start_date?: string
+  end_date?: string
+  is_template: boolean
+  require_checklist_for_review?: boolean
+  assignment_mode: AssignmentMode
+  open_defects?: number
+  overdue_defects?: number
+  critical_defects?: number
+  version: number
   created_at: string
   updated_at: string
   manager: User
   defects?: Defect[]
 }
 
+export type ProjectLevel = 'portfolio' | 'program' | 'project'
+
+export type AssignmentMode =
+  | 'category'
+  | 'round_robin'
+  | 'least_loaded'
+  | 'manual'
+
+export interface ProjectMember {
+  id: number
+  project_id: number
+  user_id: number
+  inherited: boolean
+}
+
+export interface EngineerWorkload {
+  user_id: number
+  email: string
+  full_name: string
+  open_defects: number
+  overdue_defects: number
+  critical_defects: number
+}
+
+export interface ProjectStats {
+  open_defects: number
+  overdue_defects: number
+  critical_defects: number
+}
+
+export interface ProjectRollup {
+  id: number
+  key: string
+  name: string
+  level: ProjectLevel
+  phase: ProjectPhase
+  parent_id?: number
+  own: ProjectStats
+  total: ProjectStats
+  children: ProjectRollup[]
+}
+
+export interface CloneProjectData {
+  name: string
+  description?: string
+  key?: string
+  start_date?: string
+  end_date?: string
+  include_open_defects?: boolean
+}
+
+export interface ProjectCloneResult {
+  locations: number
+  categories: number
+  category_assignees: number
+  labels: number
+  custom_fields: number
+  sla_policies: number
+  calendar: boolean
+  escalation_rules: number
+  defects: number
+}
+
+export interface Contractor {
+  id: number
+  name: string
+  tax_id?: string
+  contact_email?: string
+  contact_phone?: string
+  members?: ContractorMember[]
+  created_at: string
+  updated_at: string
+}
+
+export interface ContractorMember {
+  id: number
+  contractor_id: number
+  user_id: number
+}
+
+export interface ProjectContractor {
+  id: number
+  project_id: number
+  contractor_id: number
+  contract_number?: string
+  scope: Array<{ id: number; name: string; level: string; path: string }>
+  contractor?: Contractor
+}
+
+export interface ContractorRanking {
+  contractor_id: number
+  name: string
+  open_defects: number
+  overdue_defects: number
+  closed_defects: number
+}
+
+export type WatchReason = 'manual' | 'author' | 'assignee' | 'commenter'
+
+export interface DefectWatcher {
+  id: number
+  defect_id: number
+  user_id: number
+  reason: WatchReason
+  created_at: string
+}
+
+export interface ProjectWatcher {
+  id: number
+  project_id: number
+  user_id: number
+  created_at: string
+}
+
+export interface WorkLog {
+  id: number
+  defect_id: number
+  user_id: number
+  contractor_id?: number
+  date: string
+  minutes: number
+  note?: string
+  hourly_rate?: number
+  created_at: string
+  updated_at: string
+}
+
+export interface WorkLogData {
+  date: string
+  minutes: number
+  note?: string
+  hourly_rate?: number
+}
+
+export interface WorkLogTotals {
+  entries: number
+  minutes: number
+  hours: number
+  cost: number
+}
+
+export type TimeReportGroup = 'project' | 'contractor' | 'engineer'
+
+export interface TimeReportRow extends WorkLogTotals {
+  id: number | null
+  name?: string
+}
+
+export interface TimeReportFilters {
+  group_by?: TimeReportGroup
+  project_id?: number
+  contractor_id?: number
+  user_id?: number
+  from?: string
+  to?: string
+}
+
+export type AcceptanceStatus = 'requested' | 'accepted' | 'rejected'
+
+// Шаг приемки: запрос, приемка или отказ надзора; photo_ids - вложения дефекта
+export interface DefectAcceptance {
+  id: number
+  defect_id: number
+  decision: AcceptanceStatus
+  user_id: number
+  reason?: string
+  photo_ids?: number[]
+  created_at: string
+}
+
+export interface AcceptanceData {
+  reason?: string
+  photo_ids?: number[]
+}
+
+export type BackChargeStatus = 'pending' | 'approved' | 'rejected'
+
+export interface BackCharge {
+  id: number
+  defect_id: number
+  contractor_id: number
+  amount: number
+  currency: string
+  reason: string
+  status: BackChargeStatus
+  created_by: number
+  decided_by?: number
+  decided_at?: string
+  decision_note?: string
+  contractor?: Contractor
+  created_at: string
+  updated_at: string
+}
+
+export interface BackChargeData {
+  contractor_id?: number
+  amount?: number
+  currency?: string
+  reason: string
+}
+
+export interface BackChargeDecision {
+  status: 'approved' | 'rejected'
+  note?: string
+}
+
+export type CostReportGroup = 'project' | 'contractor'
+
+export interface CostRollup {
+  id: number
+  name: string
+  currency: string
+  defects: number
+  estimated_cost: number
+  actual_cost: number
+  back_charge_pending: number
+  back_charge_approved: number
+  back_charge_rejected: number
+}
+
+export type InspectionRecurrence = 'daily' | 'weekly' | 'monthly'
+
+export type InspectionStatus =
+  | 'planned'
+  | 'in_progress'
+  | 'completed'
+  | 'cancelled'
+
+export type InspectionResult = 'pending' | 'pass' | 'fail' | 'n_a'
+
+// Правило регулярных обходов; location_id - зона обхода
+export interface InspectionSchedule {
+  id: number
+  project_id: number
+  name: string
+  location_id?: number
+  inspector_ids: number[]
+  checklist: string[]
+  recurrence: InspectionRecurrence
+  interval: number
+  next_date: string
+  end_date?: string
+  enabled: boolean
+  created_at: string
+  updated_at: string
+}
+
+export interface InspectionScheduleData {
+  name: string
+  location_id?: number
+  inspector_ids: number[]
+  checklist?: string[]
+  recurrence: InspectionRecurrence
+  interval?: number
+  start_date: string
+  end_date?: string
+  enabled?: boolean
+}
+
+export interface InspectionItem {
+  id: number
+  round_id: number
+  title: string
+  position: number
+  result: InspectionResult
+  note?: string
+  checked_by?: number
+  checked_at?: string
+}
+
+export interface InspectionRound {
+  id: number
+  project_id: number
+  schedule_id?: number
+  title: string
+  location_id?: number
+  location?: { id: number; name: string; type: string }
+  scheduled_date: string
+  status: InspectionStatus
+  inspector_ids: number[]
+  started_at?: string
+  completed_at?: string
+  completed_by?: number
+  summary?: string
+  items?: InspectionItem[]
+  created_at: string
+  updated_at: string
+}
+
+export interface InspectionRoundData {
+  title: string
+  location_id?: number
+  scheduled_date: string
+  inspector_ids: number[]
+  checklist?: string[]
+}
+
+export interface InspectionSummaryRow {
+  id: number
+  title: string
+  scheduled_date: string
+  status: InspectionStatus
+  items_failed: number
+  defects_found: number
+  defects_closed: number
+}
+
+export interface InspectionSummary {
+  rounds: InspectionSummaryRow[]
+  totals: {
+    rounds: Partial<Record<InspectionStatus, number>>
+    items_failed: number
+    defects_found: number
+    defects_closed: number
+  }
+}
+
+export interface TrashItem {
+  type: 'defect' | 'project'
+  id: number
+  key: string
+  title: string
+  project_id: number
+  deleted_at: string
+  deleted_by?: number
+  deleted_by_name?: string
+  purge_at: string
+}
+
 export interface Defect {
   id: number
   title: string
@@ -30,6 +380,16 @@ export interface Defect {
   project_id: number
   author_id: number
   assignee_id?: number
+  contractor_id?: number
+  inspection_round_id?: number
+  estimated_cost?: number
+  actual_cost?: number
+  currency?: string
+  acceptance_status?: AcceptanceStatus
+  accepted_by?: number
+  accepted_at?: string
+  reopen_count: number
+  version: number
   created_at: string
   updated_at?: string
   project: Project
@@ -90,6 +450,11 @@ export interface CreateDefectData {
   deadline?: string
   project_id: number
   assignee_id?: number
+  contractor_id?: number
+  estimated_cost?: number
+  actual_cost?: number
+  currency?: string
+  inspection_round_id?: number
 }
 
 export interface UpdateDefectData {
@@ -99,6 +464,10 @@ export interface UpdateDefectData {
   priority?: DefectPriority
   deadline?: string
   assignee_id?: number
+  contractor_id?: number
+  estimated_cost?: number
+  actual_cost?: number
+  currency?: string
 }
 
 // Типы для фильтров и пагинации
@@ -112,13 +481,35 @@ export interface DefectFilters extends PaginationParams {
   status?: DefectStatus
   priority?: DefectPriority
   assignee_id?: number
+  contractor_id?: number
   search?: string // Добавляем поиск
   sort_by?: string
   order?: 'asc' | 'desc'
 }
 
 export interface ProjectFilters extends PaginationParams {
+  search?: string
   manager_id?: number
+  phase?: ProjectPhase
+  include_archived?: boolean
+  template?: boolean
+  parent_id?: number | 'root'
+  level?: ProjectLevel
+  start_from?: string
+  start_to?: string
+  end_from?: string
+  end_to?: string
+  sort_by?:
+    | 'name'
+    | 'key'
+    | 'phase'
+    | 'created_at'
+    | 'start_date'
+    | 'end_date'
+    | 'open_defects'
+    | 'overdue_defects'
+    | 'critical_defects'
+  order?: 'asc' | 'desc'
 }
 
 // Response types
@@ -144,7 +535,7 @@ export interface PaginatedResponse<T = unknown> {
 }
 
 // Union types
-export type UserRole = 'engineer' | 'manager' | 'observer'
+export type UserRole = 'engineer' | 'manager' | 'observer' | 'contractor'
 export type DefectStatus =
   | 'new'
   | 'in_progress'
@@ -166,12 +557,18 @@ export interface CreateProjectData {
   name: string
   description: string
   manager_id: number
+  start_date?: string
+  end_date?: string
+  level?: ProjectLevel
+  parent_id?: number
+  assignment_mode?: AssignmentMode
 }
 
 export interface UpdateProjectData {
   name?: string
   description?: string
   manager_id?: number
+  assignment_mode?: AssignmentMode
 }
 
 // Дополняем типы для форм
@@ -19,7 +19,8 @@ func main() {
     r.Use(func(c *gin.Context) {
         c.Header("Access-Control-Allow-Origin", "http://localhost:5173")
         c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, PATCH, OPTIONS")
-        c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
+        c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, If-Match")
+        c.Header("Access-Control-Expose-Headers", "ETag")
         c.Header("Access-Control-Allow-Credentials", "true")
         
         if c.Request.Method == "OPTIONS" {
@@ -81,17 +82,161 @@ func main() {
             projects.POST("", proxyHandler.ProjectDefectProxy())
             projects.PUT("/:id", proxyHandler.ProjectDefectProxy())
             projects.DELETE("/:id", proxyHandler.ProjectDefectProxy())
+            projects.PATCH("/:id/phase", proxyHandler.ProjectDefectProxy())
+            projects.POST("/:id/template", proxyHandler.ProjectDefectProxy())
+            projects.POST("/:id/clone", proxyHandler.ProjectDefectProxy())
+            projects.PATCH("/:id/parent", proxyHandler.ProjectDefectProxy())
+            projects.GET("/:id/rollup", proxyHandler.ProjectDefectProxy())
+            projects.GET("/:id/members", proxyHandler.ProjectDefectProxy())
+            projects.POST("/:id/members", proxyHandler.ProjectDefectProxy())
+            projects.DELETE("/:id/members/:user_id", proxyHandler.ProjectDefectProxy())
+            projects.GET("/:id/watchers", proxyHandler.ProjectDefectProxy())
+            projects.POST("/:id/watch", proxyHandler.ProjectDefectProxy())
+            projects.DELETE("/:id/watch", proxyHandler.ProjectDefectProxy())
+            projects.GET("/:id/defects", proxyHandler.ProjectDefectProxy())
+            projects.GET("/:id/locations", proxyHandler.ProjectDefectProxy())
+            projects.POST("/:id/locations", proxyHandler.ProjectDefectProxy())
+            projects.GET("/:id/category-assignees", proxyHandler.ProjectDefectProxy())
+            projects.PUT("/:id/category-assignees/:category_id", proxyHandler.ProjectDefectProxy())
+            projects.DELETE("/:id/category-assignees/:category_id", proxyHandler.ProjectDefectProxy())
+            projects.GET("/:id/contractors", proxyHandler.ProjectDefectProxy())
+            projects.PUT("/:id/contractors/:contractor_id", proxyHandler.ProjectDefectProxy())
+            projects.DELETE("/:id/contractors/:contractor_id", proxyHandler.ProjectDefectProxy())
+            projects.GET("/:id/labels", proxyHandler.ProjectDefectProxy())
+            projects.POST("/:id/labels", proxyHandler.ProjectDefectProxy())
+            projects.GET("/:id/custom-fields", proxyHandler.ProjectDefectProxy())
+            projects.POST("/:id/custom-fields", proxyHandler.ProjectDefectProxy())
+            projects.GET("/:id/sla-policies", proxyHandler.ProjectDefectProxy())
+            projects.POST("/:id/sla-policies", proxyHandler.ProjectDefectProxy())
+            projects.GET("/:id/calendar", proxyHandler.ProjectDefectProxy())
+            projects.PUT("/:id/calendar", proxyHandler.ProjectDefectProxy())
+            projects.POST("/:id/calendar/holidays", proxyHandler.ProjectDefectProxy())
+            projects.DELETE("/:id/calendar/holidays/:holiday_id", proxyHandler.ProjectDefectProxy())
+            projects.GET("/:id/escalation-rules", proxyHandler.ProjectDefectProxy())
+            projects.POST("/:id/escalation-rules", proxyHandler.ProjectDefectProxy())
+            projects.GET("/:id/inspection-schedules", proxyHandler.ProjectDefectProxy())
+            projects.POST("/:id/inspection-schedules", proxyHandler.ProjectDefectProxy())
+            projects.GET("/:id/inspection-rounds", proxyHandler.ProjectDefectProxy())
+            projects.POST("/:id/inspection-rounds", proxyHandler.ProjectDefectProxy())
+            projects.GET("/:id/inspection-summary", proxyHandler.ProjectDefectProxy())
+        }
+        
+        escalationRules := api.Group("/escalation-rules")
+        {
+            escalationRules.PUT("/:id", proxyHandler.ProjectDefectProxy())
+            escalationRules.DELETE("/:id", proxyHandler.ProjectDefectProxy())
+        }
+        api.GET("/defect-events", proxyHandler.ProjectDefectProxy())
+        api.GET("/workload", proxyHandler.ProjectDefectProxy())
+        
+        inspectionSchedules := api.Group("/inspection-schedules")
+        {
+            inspectionSchedules.PUT("/:id", proxyHandler.ProjectDefectProxy())
+            inspectionSchedules.DELETE("/:id", proxyHandler.ProjectDefectProxy())
+        }
+        
+        inspectionRounds := api.Group("/inspection-rounds")
+        {
+            inspectionRounds.GET("/:id", proxyHandler.ProjectDefectProxy())
+            inspectionRounds.DELETE("/:id", proxyHandler.ProjectDefectProxy())
+            inspectionRounds.PATCH("/:id/status", proxyHandler.ProjectDefectProxy())
+            inspectionRounds.PUT("/:id/items/:item_id", proxyHandler.ProjectDefectProxy())
+        }
+        
+        trash := api.Group("/trash")
+        {
+            trash.GET("", proxyHandler.ProjectDefectProxy())
+            trash.POST("/defects/:id/restore", proxyHandler.ProjectDefectProxy())
+            trash.POST("/projects/:id/restore", proxyHandler.ProjectDefectProxy())
+        }
+        
+        slaPolicies := api.Group("/sla-policies")
+        {
+            slaPolicies.PUT("/:id", proxyHandler.ProjectDefectProxy())
+            slaPolicies.DELETE("/:id", proxyHandler.ProjectDefectProxy())
+        }
+        
+        customFields := api.Group("/custom-fields")
+        {
+            customFields.PUT("/:id", proxyHandler.ProjectDefectProxy())
+            customFields.DELETE("/:id", proxyHandler.ProjectDefectProxy())
+        }
+        
+        labels := api.Group("/labels")
+        {
+            labels.PUT("/:id", proxyHandler.ProjectDefectProxy())
+            labels.DELETE("/:id", proxyHandler.ProjectDefectProxy())
+        }
+        
+        categories := api.Group("/categories")
+        {
+            categories.GET("", proxyHandler.ProjectDefectProxy())
+            categories.POST("", proxyHandler.ProjectDefectProxy())
+            categories.PUT("/:id", proxyHandler.ProjectDefectProxy())
+            categories.DELETE("/:id", proxyHandler.ProjectDefectProxy())
+        }
+        
+        contractors := api.Group("/contractors")
+        {
+            contractors.GET("", proxyHandler.ProjectDefectProxy())
+            contractors.GET("/ranking", proxyHandler.ProjectDefectProxy())
+            contractors.GET("/:id", proxyHandler.ProjectDefectProxy())
+            contractors.POST("", proxyHandler.ProjectDefectProxy())
+            contractors.PUT("/:id", proxyHandler.ProjectDefectProxy())
+            contractors.DELETE("/:id", proxyHandler.ProjectDefectProxy())
+            contractors.POST("/:id/members", proxyHandler.ProjectDefectProxy())
+            contractors.DELETE("/:id/members/:user_id", proxyHandler.ProjectDefectProxy())
+        }
+        
+        backCharges := api.Group("/back-charges")
+        {
+            backCharges.PATCH("/:id/status", proxyHandler.ProjectDefectProxy())
+            backCharges.DELETE("/:id", proxyHandler.ProjectDefectProxy())
+        }
+        
+        locations := api.Group("/locations")
+        {
+            locations.PUT("/:id", proxyHandler.ProjectDefectProxy())
+            locations.DELETE("/:id", proxyHandler.ProjectDefectProxy())
         }
         
         defects := api.Group("/defects")
         {
             defects.GET("", proxyHandler.ProjectDefectProxy())
             defects.GET("/my", proxyHandler.ProjectDefectProxy())
+            defects.GET("/by-location", proxyHandler.ProjectDefectProxy())
+            defects.GET("/by-category", proxyHandler.ProjectDefectProxy())
+            defects.GET("/duplicates", proxyHandler.ProjectDefectProxy())
             defects.GET("/:id", proxyHandler.ProjectDefectProxy())
             defects.POST("", proxyHandler.ProjectDefectProxy())
             defects.PUT("/:id", proxyHandler.ProjectDefectProxy())
             defects.PATCH("/:id/status", proxyHandler.ProjectDefectProxy())
+            defects.GET("/:id/history", proxyHandler.ProjectDefectProxy())
+            defects.POST("/:id/history/:change_set_id/revert", proxyHandler.ProjectDefectProxy())
             defects.DELETE("/:id", proxyHandler.ProjectDefectProxy())
+            defects.POST("/:id/labels", proxyHandler.ProjectDefectProxy())
+            defects.DELETE("/:id/labels/:label_id", proxyHandler.ProjectDefectProxy())
+            defects.GET("/:id/relations", proxyHandler.ProjectDefectProxy())
+            defects.POST("/:id/relations", proxyHandler.ProjectDefectProxy())
+            defects.DELETE("/:id/relations/:relation_id", proxyHandler.ProjectDefectProxy())
+            defects.GET("/:id/checklist", proxyHandler.ProjectDefectProxy())
+            defects.POST("/:id/checklist", proxyHandler.ProjectDefectProxy())
+            defects.PUT("/:id/checklist/order", proxyHandler.ProjectDefectProxy())
+            defects.PUT("/:id/checklist/:item_id", proxyHandler.ProjectDefectProxy())
+            defects.DELETE("/:id/checklist/:item_id", proxyHandler.ProjectDefectProxy())
+            defects.GET("/:id/acceptance", proxyHandler.ProjectDefectProxy())
+            defects.POST("/:id/acceptance/request", proxyHandler.ProjectDefectProxy())
+            defects.POST("/:id/acceptance/accept", proxyHandler.ProjectDefectProxy())
+            defects.POST("/:id/acceptance/reject", proxyHandler.ProjectDefectProxy())
+            defects.GET("/:id/back-charges", proxyHandler.ProjectDefectProxy())
+            defects.POST("/:id/back-charges", proxyHandler.ProjectDefectProxy())
+            defects.GET("/:id/worklogs", proxyHandler.ProjectDefectProxy())
+            defects.POST("/:id/worklogs", proxyHandler.ProjectDefectProxy())
+            defects.PUT("/:id/worklogs/:log_id", proxyHandler.ProjectDefectProxy())
+            defects.DELETE("/:id/worklogs/:log_id", proxyHandler.ProjectDefectProxy())
+            defects.GET("/:id/watchers", proxyHandler.ProjectDefectProxy())
+            defects.POST("/:id/watch", proxyHandler.ProjectDefectProxy())
+            defects.DELETE("/:id/watch", proxyHandler.ProjectDefectProxy())
         }
         
         // Комментарии
@@ -112,6 +257,17 @@ func main() {
             attachments.DELETE("/:id", proxyHandler.ContentProxy())
         }
         
+        // Чертежи этажей и метки дефектов на них
+        floorPlans := api.Group("/floor-plans")
+        {
+            floorPlans.POST("/project/:project_id", proxyHandler.ContentProxy())
+            floorPlans.GET("/project/:project_id", proxyHandler.ContentProxy())
+            floorPlans.GET("/:id", proxyHandler.ContentProxy())
+            floorPlans.GET("/:id/file", proxyHandler.ContentProxy())
+            floorPlans.GET("/:id/pins", proxyHandler.ProjectDefectProxy())
+            floorPlans.DELETE("/:id", proxyHandler.ContentProxy())
+        }
+        
         // Отчеты
         reports := api.Group("/reports")
         {
@@ -119,6 +275,9 @@ func main() {
             reports.GET("/project/:project_id", proxyHandler.ContentProxy())
             reports.GET("/defects/export", proxyHandler.ContentProxy())
             reports.GET("/user-activity", proxyHandler.ContentProxy())
+            reports.GET("/contractors", proxyHandler.ContentProxy())
+            reports.GET("/time", proxyHandler.ContentProxy())
+            reports.GET("/costs", proxyHandler.ContentProxy())
         }
 
         users := api.Group("/users")
@@ -128,6 +287,7 @@ func main() {
             users.GET("", proxyHandler.AuthProxy())
             users.GET("/:id", proxyHandler.AuthProxy())
             users.PUT("/:id", proxyHandler.AuthProxy())
+            users.PATCH("/:id/active", proxyHandler.AuthProxy())
         }
     }
     
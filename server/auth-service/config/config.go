@@ -14,6 +14,7 @@ type Config struct {
     DBName     string
     JWTSecret  string
     ServicePort string
+    ServiceToken string
     Env        string
 }
 
@@ -26,6 +27,7 @@ func Load() *Config {
         DBName:     getEnv("DB_NAME", "auth_db"),
         JWTSecret:  getEnv("JWT_SECRET", "development-secret-key"),
         ServicePort: getEnv("AUTH_SERVICE_PORT", "8081"),
+        ServiceToken: getEnv("SERVICE_TOKEN", "development-service-token"),
         Env:        getEnv("ENV", "development"),
     }
     
@@ -91,6 +91,7 @@ func seedData(db *gorm.DB) error {
         {RoleName: "engineer"},
         {RoleName: "manager"},
         {RoleName: "observer"},
+        {RoleName: "contractor"},
     }
     
     for _, role := range roles {
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"auth-service/models"
+	"net/http"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
@@ -77,6 +78,11 @@ func (h *AuthHandler) Login(c *gin.Context) {
         return
     }
     
+    if !user.Active {
+        h.error(c, http.StatusForbidden, "Account is deactivated")
+        return
+    }
+    
     token, err := h.generateJWT(user)
     if err != nil {
         h.internalError(c, "Failed to generate token")
@@ -27,7 +27,7 @@ func (h *UserHandler) GetEngineers(c *gin.Context) {
     if err := h.DB.
         Preload("Role").
         Joins("JOIN roles ON users.role_id = roles.id").
-        Where("roles.role_name = ?", "engineer").
+        Where("roles.role_name = ? AND users.active = ?", "engineer", true).
         Find(&engineers).Error; err != nil {
         h.internalError(c, "Failed to fetch engineers")
         return
@@ -162,4 +162,47 @@ func (h *UserHandler) GetUserByID(c *gin.Context) {
     h.success(c, gin.H{
         "user": user.ToResponse(),
     }, "User retrieved successfully")
-}
\ No newline at end of file
+}
+
+// SetUserActive - отключение и повторное включение учетной записи менеджером
+func (h *UserHandler) SetUserActive(c *gin.Context) {
+    userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+    if err != nil {
+        h.badRequest(c, "Invalid user ID")
+        return
+    }
+    
+    var req models.UserActiveRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+    
+    currentUser, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return
+    }
+    if currentUser.Role.RoleName != "manager" {
+        h.error(c, http.StatusForbidden, "Only managers can activate or deactivate users")
+        return
+    }
+    if currentUser.ID == uint(userID) && !*req.IsActive {
+        h.badRequest(c, "You cannot deactivate your own account")
+        return
+    }
+    
+    var user models.User
+    if err := h.DB.Preload("Role").First(&user, userID).Error; err != nil {
+        h.notFound(c, "User not found")
+        return
+    }
+    
+    if err := h.DB.Model(&user).Update("active", *req.IsActive).Error; err != nil {
+        h.internalError(c, "Failed to update user")
+        return
+    }
+    
+    h.success(c, gin.H{
+        "user": user.ToResponse(),
+    }, "User status updated successfully")
+}
@@ -39,7 +39,7 @@ func main() {
     
     // Protected routes
     api := r.Group("/api")
-    api.Use(middleware.JWTMiddleware(cfg.JWTSecret))
+    api.Use(middleware.JWTMiddleware(cfg.JWTSecret, db))
     {
         // Текущий пользователь
         api.GET("/me", authHandler.GetCurrentUser)
@@ -52,9 +52,17 @@ func main() {
             users.GET("", userHandler.GetAllUsers)
             users.GET("/:id", userHandler.GetUserByID)
             users.PUT("/:id", userHandler.UpdateUserData)
+            users.PATCH("/:id/active", userHandler.SetUserActive)
         }
     }
     
+    // Межсервисные маршруты: планировщик project-defect-service проверяет исполнителей
+    internal := r.Group("/internal")
+    internal.Use(middleware.ServiceTokenMiddleware(cfg.ServiceToken))
+    {
+        internal.GET("/users/:id", userHandler.GetUserByID)
+    }
+    
     // Health check
     r.GET("/health", func(c *gin.Context) {
         c.JSON(200, gin.H{
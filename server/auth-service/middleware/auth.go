@@ -4,11 +4,16 @@ import (
 	"net/http"
 	"strings"
 
+	"auth-service/models"
+
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-func JWTMiddleware(jwtSecret string) gin.HandlerFunc {
+// JWTMiddleware проверяет токен и учетную запись: отключенный пользователь теряет
+// доступ сразу, не дожидаясь истечения ранее выданного токена
+func JWTMiddleware(jwtSecret string, db *gorm.DB) gin.HandlerFunc {
     return func(c *gin.Context) {
         authHeader := c.GetHeader("Authorization")
         if authHeader == "" {
@@ -56,7 +61,18 @@ func JWTMiddleware(jwtSecret string) gin.HandlerFunc {
             return
         }
         
-        c.Set("user_id", uint(claims["user_id"].(float64)))
+        userID := uint(claims["user_id"].(float64))
+        var user models.User
+        if err := db.Select("id", "active").First(&user, userID).Error; err != nil || !user.Active {
+            c.JSON(http.StatusUnauthorized, gin.H{
+                "success": false,
+                "error":   "User account is deactivated",
+            })
+            c.Abort()
+            return
+        }
+        
+        c.Set("user_id", userID)
         c.Set("user_role", claims["role"])
         c.Set("user_email", claims["email"])
         
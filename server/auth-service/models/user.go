@@ -26,6 +26,9 @@ type User struct {
     PasswordHash string `gorm:"not null" json:"-"`
     FullName     string `gorm:"not null" json:"full_name"`
     RoleID       uint   `gorm:"not null" json:"role_id"`
+    // Active - учетная запись не отключена; отключенные не входят в систему
+    // и не могут быть назначены исполнителями
+    Active       bool   `gorm:"not null;default:true" json:"is_active"`
     Role         Role   `gorm:"foreignKey:RoleID" json:"role,omitempty"`
 }
 
@@ -41,6 +44,10 @@ type UserUpdateRequest struct {
     FullName string `json:"full_name" binding:"required"`
 }
 
+type UserActiveRequest struct {
+    IsActive *bool `json:"is_active" binding:"required"`
+}
+
 type UserLoginRequest struct {
     Email    string `json:"email" binding:"required,email"`
     Password string `json:"password" binding:"required"`
@@ -52,6 +59,7 @@ type UserResponse struct {
     FullName  string `json:"full_name"`
     RoleID    uint   `json:"role_id"`
     RoleName  string `json:"role_name"`
+    IsActive  bool   `json:"is_active"`
     CreatedAt string `json:"created_at,omitempty"`
     UpdatedAt string `json:"updated_at,omitempty"`
 }
@@ -82,6 +90,7 @@ func (u *User) ToResponse() UserResponse {
         FullName:  u.FullName,
         RoleID:    u.RoleID,
         RoleName:  roleName,
+        IsActive:  u.Active,
         CreatedAt: u.CreatedAt.Format("2006-01-02T15:04:05Z"),
         UpdatedAt: u.UpdatedAt.Format("2006-01-02T15:04:05Z"),
     }
@@ -17,6 +17,8 @@ type Config struct {
     AuthServiceURL       string
     ProjectDefectServiceURL string
     UploadPath           string
+    // Общий токен для внутренних запросов других сервисов
+    ServiceToken         string
     Env                  string
 }
 
@@ -32,6 +34,7 @@ func Load() *Config {
         AuthServiceURL:       getEnv("AUTH_SERVICE_URL", "http://auth-service:8081"),
         ProjectDefectServiceURL: getEnv("PROJECT_DEFECT_SERVICE_URL", "http://project-defect-service:8082"),
         UploadPath:           getEnv("UPLOAD_PATH", "./uploads"),
+        ServiceToken:         getEnv("SERVICE_TOKEN", "development-service-token"),
         Env:                  getEnv("ENV", "development"),
     }
     
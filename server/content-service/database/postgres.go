@@ -70,6 +70,7 @@ func autoMigrate(db *gorm.DB) error {
     models := []interface{}{
         &models.Comment{},
         &models.Attachment{},
+        &models.FloorPlan{},
     }
     
     for _, model := range models {
@@ -19,10 +19,10 @@ type AttachmentHandler struct {
     FileStorage *storage.FileStorage
 }
 
-func NewAttachmentHandler(db *gorm.DB, jwtSecret, authServiceURL, projectDefectServiceURL, uploadPath string) *AttachmentHandler {
+func NewAttachmentHandler(db *gorm.DB, jwtSecret, authServiceURL, projectDefectServiceURL, serviceToken, uploadPath string) *AttachmentHandler {
     fileStorage := storage.NewFileStorage(uploadPath)
     return &AttachmentHandler{
-        Handler:    *NewHandler(db, jwtSecret, authServiceURL, projectDefectServiceURL),
+        Handler:    *NewHandler(db, jwtSecret, authServiceURL, projectDefectServiceURL, serviceToken),
         UploadPath: uploadPath,
         FileStorage: fileStorage,
     }
@@ -51,6 +51,10 @@ func (h *AttachmentHandler) UploadAttachment(c *gin.Context) {
         return
     }
     
+    if !h.checkDefectWritable(c, uint(defectID)) {
+        return
+    }
+    
     // Генерируем уникальное имя файла
     ext := filepath.Ext(header.Filename)
     filename := strconv.FormatInt(time.Now().UnixNano(), 10) + ext
@@ -85,7 +89,15 @@ func (h *AttachmentHandler) UploadAttachment(c *gin.Context) {
 }
 
 func (h *AttachmentHandler) GetAttachments(c *gin.Context) {
-    defectID := c.Param("defect_id")
+    defectID, err := strconv.ParseUint(c.Param("defect_id"), 10, 32)
+    if err != nil {
+        h.badRequest(c, "Invalid defect ID")
+        return
+    }
+    
+    if !h.checkDefectReadable(c, uint(defectID)) {
+        return
+    }
     
     var attachments []models.Attachment
     if err := h.DB.
@@ -109,6 +121,10 @@ func (h *AttachmentHandler) DownloadAttachment(c *gin.Context) {
         return
     }
     
+    if !h.checkDefectReadable(c, attachment.DefectID) {
+        return
+    }
+    
     filePath := filepath.Join(h.UploadPath, attachment.Filepath)
     
     // Проверяем существование файла
@@ -144,6 +160,10 @@ func (h *AttachmentHandler) DeleteAttachment(c *gin.Context) {
         return
     }
     
+    if !h.checkDefectWritable(c, attachment.DefectID) {
+        return
+    }
+    
     filePath := filepath.Join(h.UploadPath, attachment.Filepath)
     
     // Удаляем файл
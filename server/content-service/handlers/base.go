@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
+	"github.com/go-resty/resty/v2"
 	"gorm.io/gorm"
 )
 
@@ -16,16 +18,24 @@ type Handler struct {
     JWTSecret    string
     AuthServiceURL string
     ProjectDefectServiceURL string
+    // Общий токен для внутренних маршрутов project-defect-service
+    ServiceToken string
+    // HTTP-клиент для запросов в другие сервисы
+    Client       *resty.Client
 }
 
-func NewHandler(db *gorm.DB, jwtSecret, authServiceURL, projectDefectServiceURL string) *Handler {
+func NewHandler(db *gorm.DB, jwtSecret, authServiceURL, projectDefectServiceURL, serviceToken string) *Handler {
     validate := validator.New()
+    client := resty.New()
+    client.SetTimeout(30 * time.Second)
     return &Handler{
         DB:            db,
         Validate:      validate,
         JWTSecret:     jwtSecret,
         AuthServiceURL: authServiceURL,
         ProjectDefectServiceURL: projectDefectServiceURL,
+        ServiceToken:  serviceToken,
+        Client:        client,
     }
 }
 
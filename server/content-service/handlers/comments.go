@@ -13,14 +13,22 @@ type CommentHandler struct {
     Handler
 }
 
-func NewCommentHandler(db *gorm.DB, jwtSecret, authServiceURL, projectDefectServiceURL string) *CommentHandler {
+func NewCommentHandler(db *gorm.DB, jwtSecret, authServiceURL, projectDefectServiceURL, serviceToken string) *CommentHandler {
     return &CommentHandler{
-        Handler: *NewHandler(db, jwtSecret, authServiceURL, projectDefectServiceURL),
+        Handler: *NewHandler(db, jwtSecret, authServiceURL, projectDefectServiceURL, serviceToken),
     }
 }
 
 func (h *CommentHandler) GetComments(c *gin.Context) {
-    defectID := c.Param("defect_id")
+    defectID, err := strconv.ParseUint(c.Param("defect_id"), 10, 32)
+    if err != nil {
+        h.badRequest(c, "Invalid defect ID")
+        return
+    }
+    
+    if !h.checkDefectReadable(c, uint(defectID)) {
+        return
+    }
     
     var comments []models.Comment
     
@@ -78,7 +86,9 @@ func (h *CommentHandler) CreateComment(c *gin.Context) {
         return
     }
     
-    // TODO: Проверить существование дефекта через project-defect-service
+    if !h.checkDefectWritable(c, req.DefectID) {
+        return
+    }
     
     comment := models.Comment{
         Text:     req.Text,
@@ -91,6 +101,9 @@ func (h *CommentHandler) CreateComment(c *gin.Context) {
         return
     }
     
+    // Автор комментария следит за дальнейшим обсуждением
+    h.watchDefect(comment.DefectID, userID, "commenter")
+    
     h.success(c, gin.H{
         "comment": comment,
     }, "Comment created successfully")
@@ -117,6 +130,10 @@ func (h *CommentHandler) UpdateComment(c *gin.Context) {
         return
     }
     
+    if !h.checkDefectWritable(c, comment.DefectID) {
+        return
+    }
+    
     var req struct {
         Text string `json:"text" binding:"required"`
     }
@@ -158,6 +175,10 @@ func (h *CommentHandler) DeleteComment(c *gin.Context) {
         return
     }
     
+    if !h.checkDefectWritable(c, comment.DefectID) {
+        return
+    }
+    
     if err := h.DB.Delete(&comment).Error; err != nil {
         h.internalError(c, "Failed to delete comment")
         return
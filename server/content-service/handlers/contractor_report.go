@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"content-service/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetContractorsReport - рейтинг подрядчиков по открытым и просроченным дефектам.
+// Параметры project_id и sort_by=open|overdue передаются project-defect-service
+func (h *ReportHandler) GetContractorsReport(c *gin.Context) {
+    var result struct {
+        Data struct {
+            Ranking []models.ContractorRanking `json:"ranking"`
+        } `json:"data"`
+        Error string `json:"error"`
+    }
+
+    params := map[string]string{}
+    for _, name := range []string{"project_id", "sort_by"} {
+        if value := c.Query(name); value != "" {
+            params[name] = value
+        }
+    }
+
+    resp, err := h.Client.R().
+        SetHeader("X-Service-Token", h.ServiceToken).
+        SetQueryParams(params).
+        SetResult(&result).
+        SetError(&result).
+        Get(h.ProjectDefectServiceURL + "/internal/reports/contractors")
+    if err != nil {
+        h.internalError(c, "Failed to fetch contractor ranking: "+err.Error())
+        return
+    }
+    if resp.StatusCode() == http.StatusBadRequest {
+        h.badRequest(c, result.Error)
+        return
+    }
+    if resp.StatusCode() != http.StatusOK {
+        h.internalError(c, "Project-defect-service returned status: "+strconv.Itoa(resp.StatusCode()))
+        return
+    }
+
+    var totals struct {
+        OpenDefects    int64 `json:"open_defects"`
+        OverdueDefects int64 `json:"overdue_defects"`
+    }
+    for _, row := range result.Data.Ranking {
+        totals.OpenDefects += row.OpenDefects
+        totals.OverdueDefects += row.OverdueDefects
+    }
+
+    h.success(c, gin.H{
+        "report": gin.H{
+            "contractors": result.Data.Ranking,
+            "totals":      totals,
+        },
+    }, "Contractor report generated successfully")
+}
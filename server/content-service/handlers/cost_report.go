@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"content-service/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCostsReport - стоимость устранения дефектов и обратные начисления по проектам
+// или подрядчикам (group_by). Суммы в разных валютах не складываются: итоги
+// считаются отдельно по каждой валюте. format=csv выгружает отчет файлом
+func (h *ReportHandler) GetCostsReport(c *gin.Context) {
+    _, userRole, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return
+    }
+    // Начисления другим организациям подрядчикам не показываются
+    if userRole == "contractor" {
+        h.error(c, http.StatusForbidden, "Contractors cannot view cost reports")
+        return
+    }
+
+    var result struct {
+        Data struct {
+            GroupBy string              `json:"group_by"`
+            Rollup  []models.CostRollup `json:"rollup"`
+        } `json:"data"`
+        Error string `json:"error"`
+    }
+
+    params := map[string]string{}
+    for _, name := range []string{"group_by", "project_id"} {
+        if value := c.Query(name); value != "" {
+            params[name] = value
+        }
+    }
+
+    resp, err := h.Client.R().
+        SetHeader("X-Service-Token", h.ServiceToken).
+        SetQueryParams(params).
+        SetResult(&result).
+        SetError(&result).
+        Get(h.ProjectDefectServiceURL + "/internal/reports/costs")
+    if err != nil {
+        h.internalError(c, "Failed to fetch cost report: "+err.Error())
+        return
+    }
+    if resp.StatusCode() == http.StatusBadRequest || resp.StatusCode() == http.StatusNotFound {
+        h.error(c, resp.StatusCode(), result.Error)
+        return
+    }
+    if resp.StatusCode() != http.StatusOK {
+        h.internalError(c, "Project-defect-service returned status: "+strconv.Itoa(resp.StatusCode()))
+        return
+    }
+
+    rows := result.Data.Rollup
+    byCurrency := map[string]*models.CostRollup{}
+    for _, row := range rows {
+        total, ok := byCurrency[row.Currency]
+        if !ok {
+            total = &models.CostRollup{Name: "total", Currency: row.Currency}
+            byCurrency[row.Currency] = total
+        }
+        total.Defects += row.Defects
+        total.EstimatedCost += row.EstimatedCost
+        total.ActualCost += row.ActualCost
+        total.BackChargePending += row.BackChargePending
+        total.BackChargeApproved += row.BackChargeApproved
+        total.BackChargeRejected += row.BackChargeRejected
+    }
+    totals := make([]models.CostRollup, 0, len(byCurrency))
+    for _, total := range byCurrency {
+        for _, value := range []*float64{
+            &total.EstimatedCost, &total.ActualCost,
+            &total.BackChargePending, &total.BackChargeApproved, &total.BackChargeRejected,
+        } {
+            *value = math.Round(*value*100) / 100
+        }
+        totals = append(totals, *total)
+    }
+    sort.Slice(totals, func(i, j int) bool { return totals[i].Currency < totals[j].Currency })
+
+    if c.Query("format") == "csv" {
+        c.Writer.Header().Set("Content-Type", "text/csv")
+        c.Writer.Header().Set("Content-Disposition", "attachment;filename=cost_report.csv")
+
+        writer := csv.NewWriter(c.Writer)
+        defer writer.Flush()
+
+        writer.Write([]string{"Group", "ID", "Name", "Currency", "Defects", "Estimated", "Actual",
+            "Back-charge pending", "Back-charge approved", "Back-charge rejected"})
+        write := func(group, id string, row models.CostRollup) {
+            writer.Write([]string{
+                group,
+                id,
+                row.Name,
+                row.Currency,
+                strconv.FormatInt(row.Defects, 10),
+                strconv.FormatFloat(row.EstimatedCost, 'f', 2, 64),
+                strconv.FormatFloat(row.ActualCost, 'f', 2, 64),
+                strconv.FormatFloat(row.BackChargePending, 'f', 2, 64),
+                strconv.FormatFloat(row.BackChargeApproved, 'f', 2, 64),
+                strconv.FormatFloat(row.BackChargeRejected, 'f', 2, 64),
+            })
+        }
+        for _, row := range rows {
+            write(result.Data.GroupBy, strconv.FormatUint(uint64(row.ID), 10), row)
+        }
+        for _, total := range totals {
+            write("total", "", total)
+        }
+        return
+    }
+
+    h.success(c, gin.H{
+        "report": gin.H{
+            "group_by": result.Data.GroupBy,
+            "rows":     rows,
+            "totals":   totals,
+        },
+    }, "Cost report generated successfully")
+}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"content-service/models"
+	"content-service/storage"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Форматы чертежей, которые клиент умеет отображать под метками
+var floorPlanMimeTypes = map[string]bool{
+    "image/png":       true,
+    "image/jpeg":      true,
+    "application/pdf": true,
+}
+
+type FloorPlanHandler struct {
+    Handler
+    UploadPath  string
+    FileStorage *storage.FileStorage
+}
+
+func NewFloorPlanHandler(db *gorm.DB, jwtSecret, authServiceURL, projectDefectServiceURL, serviceToken, uploadPath string) *FloorPlanHandler {
+    return &FloorPlanHandler{
+        Handler:     *NewHandler(db, jwtSecret, authServiceURL, projectDefectServiceURL, serviceToken),
+        UploadPath:  uploadPath,
+        FileStorage: storage.NewFileStorage(uploadPath),
+    }
+}
+
+// UploadFloorPlan - загрузка чертежа этажа (multipart: file, name, location_id, page)
+func (h *FloorPlanHandler) UploadFloorPlan(c *gin.Context) {
+    projectID, err := strconv.ParseUint(c.Param("project_id"), 10, 32)
+    if err != nil {
+        h.badRequest(c, "Invalid project ID")
+        return
+    }
+
+    userID, _, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return
+    }
+
+    if !h.checkProjectWritable(c, uint(projectID)) {
+        return
+    }
+
+    file, header, err := c.Request.FormFile("file")
+    if err != nil {
+        h.badRequest(c, "File is required")
+        return
+    }
+    defer file.Close()
+
+    // Определяем тип по содержимому, а не по заголовку клиента
+    head := make([]byte, 512)
+    n, _ := io.ReadFull(file, head)
+    mimeType := http.DetectContentType(head[:n])
+    if !floorPlanMimeTypes[mimeType] {
+        h.badRequest(c, "Floor plan must be a PNG, JPEG or PDF file")
+        return
+    }
+
+    pageNumber := 1
+    if page := c.PostForm("page"); page != "" {
+        pageNumber, err = strconv.Atoi(page)
+        if err != nil || pageNumber < 1 {
+            h.badRequest(c, "Invalid page number")
+            return
+        }
+    }
+    if mimeType != "application/pdf" && pageNumber != 1 {
+        h.badRequest(c, "Page number is only supported for PDF files")
+        return
+    }
+
+    var locationID *uint
+    if location := c.PostForm("location_id"); location != "" {
+        id, err := strconv.ParseUint(location, 10, 32)
+        if err != nil {
+            h.badRequest(c, "Invalid location ID")
+            return
+        }
+        value := uint(id)
+        locationID = &value
+        if status, err := h.checkPlanLocation(c, uint(projectID), value); err != nil {
+            h.error(c, status, err.Error())
+            return
+        }
+    }
+
+    name := c.PostForm("name")
+    if name == "" {
+        name = header.Filename
+    }
+
+    filename := "plan_" + strconv.FormatInt(time.Now().UnixNano(), 10) + filepath.Ext(header.Filename)
+    filePath, err := h.FileStorage.SaveFile(filename, io.MultiReader(bytes.NewReader(head[:n]), file))
+    if err != nil {
+        h.internalError(c, "Failed to save file")
+        return
+    }
+
+    plan := models.FloorPlan{
+        ProjectID:  uint(projectID),
+        LocationID: locationID,
+        Name:       name,
+        PageNumber: pageNumber,
+        Filename:   header.Filename,
+        Filepath:   filename,
+        FileSize:   header.Size,
+        MimeType:   mimeType,
+        UploadedBy: userID,
+    }
+
+    if err := h.DB.Create(&plan).Error; err != nil {
+        h.FileStorage.DeleteFile(filePath)
+        h.internalError(c, "Failed to save floor plan info")
+        return
+    }
+
+    h.success(c, gin.H{
+        "plan": plan,
+    }, "Floor plan uploaded successfully")
+}
+
+// checkPlanLocation проверяет, что место плана - этаж этого проекта. Места этажей
+// запрашиваются в project-defect-service от имени текущего пользователя
+func (h *FloorPlanHandler) checkPlanLocation(c *gin.Context, projectID, locationID uint) (int, error) {
+    var result struct {
+        Data struct {
+            Locations []struct {
+                ID uint `json:"id"`
+            } `json:"locations"`
+        } `json:"data"`
+    }
+
+    resp, err := h.Client.R().
+        SetHeader("Authorization", c.GetHeader("Authorization")).
+        SetQueryParam("type", "floor").
+        SetResult(&result).
+        Get(fmt.Sprintf("%s/api/projects/%d/locations", h.ProjectDefectServiceURL, projectID))
+    if err != nil {
+        return http.StatusInternalServerError, fmt.Errorf("failed to fetch locations from project-defect-service: %w", err)
+    }
+    if resp.StatusCode() != http.StatusOK {
+        return http.StatusInternalServerError, fmt.Errorf("project-defect-service returned status: %d", resp.StatusCode())
+    }
+
+    for _, location := range result.Data.Locations {
+        if location.ID == locationID {
+            return http.StatusOK, nil
+        }
+    }
+    return http.StatusBadRequest, fmt.Errorf("location must be a floor of this project")
+}
+
+// GetFloorPlans - чертежи проекта, опционально только для одного этажа
+func (h *FloorPlanHandler) GetFloorPlans(c *gin.Context) {
+    query := h.DB.Where("project_id = ?", c.Param("project_id"))
+    if locationID := c.Query("location_id"); locationID != "" {
+        query = query.Where("location_id = ?", locationID)
+    }
+
+    var plans []models.FloorPlan
+    if err := query.Order("name, page_number").Find(&plans).Error; err != nil {
+        h.internalError(c, "Failed to fetch floor plans")
+        return
+    }
+
+    h.success(c, gin.H{
+        "plans": plans,
+    }, "Floor plans retrieved successfully")
+}
+
+func (h *FloorPlanHandler) GetFloorPlan(c *gin.Context) {
+    var plan models.FloorPlan
+    if err := h.DB.First(&plan, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Floor plan not found")
+        return
+    }
+
+    h.success(c, gin.H{
+        "plan": plan,
+    }, "Floor plan retrieved successfully")
+}
+
+// GetFloorPlanFile - файл чертежа для отображения в браузере
+func (h *FloorPlanHandler) GetFloorPlanFile(c *gin.Context) {
+    var plan models.FloorPlan
+    if err := h.DB.First(&plan, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Floor plan not found")
+        return
+    }
+
+    filePath := filepath.Join(h.UploadPath, plan.Filepath)
+    if !h.FileStorage.FileExists(filePath) {
+        h.notFound(c, "File not found")
+        return
+    }
+
+    c.Header("Content-Disposition", "inline; filename="+plan.Filename)
+    c.Header("Content-Type", plan.MimeType)
+    c.File(filePath)
+}
+
+func (h *FloorPlanHandler) DeleteFloorPlan(c *gin.Context) {
+    var plan models.FloorPlan
+    if err := h.DB.First(&plan, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Floor plan not found")
+        return
+    }
+
+    userID, userRole, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return
+    }
+
+    // Проверяем права (только автор загрузки или менеджер)
+    if plan.UploadedBy != userID && userRole != "manager" {
+        h.error(c, http.StatusForbidden, "You can only delete your own floor plans")
+        return
+    }
+
+    if !h.checkProjectWritable(c, plan.ProjectID) {
+        return
+    }
+
+    if err := h.DB.Delete(&plan).Error; err != nil {
+        h.internalError(c, "Failed to delete floor plan")
+        return
+    }
+
+    // Удаляем файл
+    h.FileStorage.DeleteFile(filepath.Join(h.UploadPath, plan.Filepath))
+
+    h.success(c, nil, "Floor plan deleted successfully")
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// projectState - этап проекта из project-defect-service
+type projectState struct {
+    ProjectID uint   `json:"project_id"`
+    Phase     string `json:"phase"`
+    ReadOnly  bool   `json:"read_only"`
+}
+
+// fetchProjectState запрашивает этап проекта по внутреннему маршруту project-defect-service
+func (h *Handler) fetchProjectState(path string) (*projectState, int, error) {
+    var result struct {
+        Data projectState `json:"data"`
+    }
+
+    resp, err := h.Client.R().
+        SetHeader("X-Service-Token", h.ServiceToken).
+        SetResult(&result).
+        Get(h.ProjectDefectServiceURL + path)
+    if err != nil {
+        return nil, 0, fmt.Errorf("failed to reach project-defect-service: %w", err)
+    }
+    if resp.StatusCode() != http.StatusOK {
+        return nil, resp.StatusCode(), fmt.Errorf("project-defect-service returned status: %d", resp.StatusCode())
+    }
+    return &result.Data, http.StatusOK, nil
+}
+
+// checkWritable - архивные проекты и их дефекты доступны только для чтения
+func (h *Handler) checkWritable(c *gin.Context, path, notFoundMessage string) bool {
+    state, status, err := h.fetchProjectState(path)
+    if status == http.StatusNotFound {
+        h.notFound(c, notFoundMessage)
+        return false
+    }
+    if err != nil {
+        h.internalError(c, "Failed to check project state: "+err.Error())
+        return false
+    }
+    if state.ReadOnly {
+        h.error(c, http.StatusConflict, "Project is archived and read-only")
+        return false
+    }
+    return true
+}
+
+// defectStatePath - внутренний маршрут состояния дефекта от имени текущего пользователя:
+// сотруднику подрядчика project-defect-service не находит дефекты чужих организаций
+func (h *Handler) defectStatePath(c *gin.Context, defectID uint) string {
+    userID, userRole, _ := h.GetUserFromContext(c)
+    query := url.Values{}
+    query.Set("user_id", strconv.FormatUint(uint64(userID), 10))
+    query.Set("user_role", userRole)
+    return fmt.Sprintf("/internal/defects/%d/state?%s", defectID, query.Encode())
+}
+
+// checkDefectWritable - дефект существует, доступен пользователю, и его проект не в архиве
+func (h *Handler) checkDefectWritable(c *gin.Context, defectID uint) bool {
+    return h.checkWritable(c, h.defectStatePath(c, defectID), "Defect not found")
+}
+
+// checkDefectReadable - дефект доступен пользователю. Ограничены только сотрудники
+// подрядчиков, поэтому для остальных project-defect-service не запрашивается
+func (h *Handler) checkDefectReadable(c *gin.Context, defectID uint) bool {
+    _, userRole, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return false
+    }
+    if userRole != "contractor" {
+        return true
+    }
+
+    _, status, err := h.fetchProjectState(h.defectStatePath(c, defectID))
+    if status == http.StatusNotFound {
+        h.notFound(c, "Defect not found")
+        return false
+    }
+    if err != nil {
+        h.internalError(c, "Failed to check defect access: "+err.Error())
+        return false
+    }
+    return true
+}
+
+// checkProjectWritable - проект существует и не в архиве
+func (h *Handler) checkProjectWritable(c *gin.Context, projectID uint) bool {
+    return h.checkWritable(c, fmt.Sprintf("/internal/projects/%d/state", projectID), "Project not found")
+}
+
+// watchDefect подписывает пользователя на дефект в project-defect-service.
+// Подписка вторична: ошибка только пишется в лог и не мешает основной операции
+func (h *Handler) watchDefect(defectID, userID uint, reason string) {
+    resp, err := h.Client.R().
+        SetHeader("X-Service-Token", h.ServiceToken).
+        SetBody(map[string]interface{}{"user_id": userID, "reason": reason}).
+        Post(fmt.Sprintf("%s/internal/defects/%d/watchers", h.ProjectDefectServiceURL, defectID))
+    if err != nil {
+        log.Printf("Failed to subscribe user %d to defect %d: %v", userID, defectID, err)
+        return
+    }
+    if resp.StatusCode() != http.StatusOK {
+        log.Printf("Failed to subscribe user %d to defect %d: project-defect-service returned status %d", userID, defectID, resp.StatusCode())
+    }
+}
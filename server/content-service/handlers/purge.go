@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"log"
+	"path/filepath"
+
+	"content-service/models"
+	"content-service/storage"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PurgeHandler окончательно удаляет данные дефектов и проектов,
+// которые project-defect-service удаляет из корзины
+type PurgeHandler struct {
+    Handler
+    UploadPath  string
+    FileStorage *storage.FileStorage
+}
+
+func NewPurgeHandler(db *gorm.DB, jwtSecret, authServiceURL, projectDefectServiceURL, serviceToken, uploadPath string) *PurgeHandler {
+    return &PurgeHandler{
+        Handler:     *NewHandler(db, jwtSecret, authServiceURL, projectDefectServiceURL, serviceToken),
+        UploadPath:  uploadPath,
+        FileStorage: storage.NewFileStorage(uploadPath),
+    }
+}
+
+// PurgeDefectContent - комментарии и вложения дефекта вместе с файлами
+func (h *PurgeHandler) PurgeDefectContent(c *gin.Context) {
+    defectID := c.Param("defect_id")
+    
+    var attachments []models.Attachment
+    if err := h.DB.Unscoped().Where("defect_id = ?", defectID).Find(&attachments).Error; err != nil {
+        h.internalError(c, "Failed to fetch attachments")
+        return
+    }
+    
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Unscoped().Where("defect_id = ?", defectID).Delete(&models.Comment{}).Error; err != nil {
+            return err
+        }
+        return tx.Unscoped().Where("defect_id = ?", defectID).Delete(&models.Attachment{}).Error
+    })
+    if err != nil {
+        h.internalError(c, "Failed to purge defect content")
+        return
+    }
+    
+    // Файлы удаляются после записей: потерянный файл безопаснее ссылки на несуществующий
+    for _, attachment := range attachments {
+        h.deleteFile(attachment.Filepath)
+    }
+    
+    h.success(c, gin.H{
+        "attachments": len(attachments),
+    }, "Defect content purged successfully")
+}
+
+// PurgeProjectContent - чертежи этажей проекта вместе с файлами
+func (h *PurgeHandler) PurgeProjectContent(c *gin.Context) {
+    projectID := c.Param("project_id")
+    
+    var plans []models.FloorPlan
+    if err := h.DB.Unscoped().Where("project_id = ?", projectID).Find(&plans).Error; err != nil {
+        h.internalError(c, "Failed to fetch floor plans")
+        return
+    }
+    
+    if err := h.DB.Unscoped().Where("project_id = ?", projectID).Delete(&models.FloorPlan{}).Error; err != nil {
+        h.internalError(c, "Failed to purge project content")
+        return
+    }
+    
+    for _, plan := range plans {
+        h.deleteFile(plan.Filepath)
+    }
+    
+    h.success(c, gin.H{
+        "floor_plans": len(plans),
+    }, "Project content purged successfully")
+}
+
+func (h *PurgeHandler) deleteFile(path string) {
+    fullPath := filepath.Join(h.UploadPath, path)
+    if !h.FileStorage.FileExists(fullPath) {
+        return
+    }
+    if err := h.FileStorage.DeleteFile(fullPath); err != nil {
+        log.Printf("Failed to delete file %s: %v", fullPath, err)
+    }
+}
@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"encoding/csv"
+	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -10,22 +12,16 @@ import (
 	"content-service/models"
 
 	"github.com/gin-gonic/gin"
-	"github.com/go-resty/resty/v2"
 	"gorm.io/gorm"
 )
 
 type ReportHandler struct {
     Handler
-    Client *resty.Client
 }
 
-func NewReportHandler(db *gorm.DB, jwtSecret, authServiceURL, projectDefectServiceURL string) *ReportHandler {
-    client := resty.New()
-    client.SetTimeout(30 * time.Second)
-    
+func NewReportHandler(db *gorm.DB, jwtSecret, authServiceURL, projectDefectServiceURL, serviceToken string) *ReportHandler {
     return &ReportHandler{
-        Handler: *NewHandler(db, jwtSecret, authServiceURL, projectDefectServiceURL),
-        Client:  client,
+        Handler: *NewHandler(db, jwtSecret, authServiceURL, projectDefectServiceURL, serviceToken),
     }
 }
 
@@ -68,11 +64,15 @@ func (h *ReportHandler) GetDefectsReport(c *gin.Context) {
         AvgResolutionTime float64                       `json:"avg_resolution_time"`
         StatusStats       map[string]int64              `json:"status_stats"`
         PriorityStats     map[string]int64              `json:"priority_stats"`
+        CategoryStats     map[string]int64              `json:"category_stats"`
     }
     
     report.TotalDefects = int64(len(defects))
     report.StatusStats = make(map[string]int64)
     report.PriorityStats = make(map[string]int64)
+    report.CategoryStats = make(map[string]int64)
+    
+    categoryCounts := make(map[string]int64)
     
     now := time.Now()
     var totalResolutionTime time.Duration
@@ -92,6 +92,13 @@ func (h *ReportHandler) GetDefectsReport(c *gin.Context) {
             report.PriorityStats[priority]++
         }
         
+        // Группировка по категориям; ID заменяются подписями после обхода
+        if categoryID, ok := defect["category_id"].(float64); ok && categoryID > 0 {
+            categoryCounts[strconv.FormatFloat(categoryID, 'f', 0, 64)]++
+        } else {
+            report.CategoryStats["none"]++
+        }
+        
         // Просроченные дефекты (упрощенная логика)
         if status != "closed" && status != "cancelled" {
             if deadlineStr, ok := defect["deadline"].(string); ok && deadlineStr != "" {
@@ -119,6 +126,20 @@ func (h *ReportHandler) GetDefectsReport(c *gin.Context) {
         }
     }
     
+    // Категории подписываются путем в классификаторе project-defect-service
+    labels, err := h.fetchCategoryLabels(categoryCounts)
+    if err != nil {
+        h.internalError(c, err.Error())
+        return
+    }
+    for categoryID, count := range categoryCounts {
+        label, ok := labels[categoryID]
+        if !ok {
+            label = "#" + categoryID
+        }
+        report.CategoryStats[label] += count
+    }
+    
     // Преобразуем мапы в слайсы для ответа
     for status, count := range report.StatusStats {
         report.DefectsByStatus = append(report.DefectsByStatus, models.DefectsByStatus{
@@ -144,6 +165,35 @@ func (h *ReportHandler) GetDefectsReport(c *gin.Context) {
     }, "Defects report generated successfully")
 }
 
+// fetchCategoryLabels запрашивает подписи категорий (ID → "Вид работ / Категория")
+func (h *ReportHandler) fetchCategoryLabels(counts map[string]int64) (map[string]string, error) {
+    if len(counts) == 0 {
+        return nil, nil
+    }
+    ids := make([]string, 0, len(counts))
+    for id := range counts {
+        ids = append(ids, id)
+    }
+    
+    var result struct {
+        Data struct {
+            Labels map[string]string `json:"labels"`
+        } `json:"data"`
+    }
+    resp, err := h.Client.R().
+        SetHeader("X-Service-Token", h.ServiceToken).
+        SetQueryParam("ids", strings.Join(ids, ",")).
+        SetResult(&result).
+        Get(h.ProjectDefectServiceURL + "/internal/categories/labels")
+    if err != nil {
+        return nil, fmt.Errorf("failed to fetch category labels: %w", err)
+    }
+    if resp.StatusCode() != http.StatusOK {
+        return nil, fmt.Errorf("project-defect-service returned status: %d", resp.StatusCode())
+    }
+    return result.Data.Labels, nil
+}
+
 // GetProjectReport - отчет по конкретному проекту
 func (h *ReportHandler) GetProjectReport(c *gin.Context) {
     projectIDStr := c.Param("project_id")
@@ -256,11 +306,15 @@ func (h *ReportHandler) ExportDefectsCSV(c *gin.Context) {
     writer := csv.NewWriter(c.Writer)
     defer writer.Flush()
     
+    // Дополнительные поля проектов выгружаются отдельными колонками
+    customFieldKeys := collectCustomFieldKeys(defects)
+    
     // Заголовки CSV
     headers := []string{
         "ID", "Title", "Description", "Status", "Priority", 
         "Project ID", "Author ID", "Assignee ID", "Created At",
     }
+    headers = append(headers, customFieldKeys...)
     writer.Write(headers)
     
     // Данные
@@ -291,10 +345,51 @@ func (h *ReportHandler) ExportDefectsCSV(c *gin.Context) {
             assigneeID,
             createdAt,
         }
+        
+        customFields, _ := defect["custom_fields"].(map[string]interface{})
+        for _, key := range customFieldKeys {
+            record = append(record, formatCustomFieldValue(customFields[key]))
+        }
         writer.Write(record)
     }
 }
 
+// collectCustomFieldKeys - отсортированные ключи дополнительных полей всех выгружаемых дефектов
+func collectCustomFieldKeys(defects []map[string]interface{}) []string {
+    seen := make(map[string]bool)
+    var keys []string
+    for _, defect := range defects {
+        customFields, _ := defect["custom_fields"].(map[string]interface{})
+        for key := range customFields {
+            if !seen[key] {
+                seen[key] = true
+                keys = append(keys, key)
+            }
+        }
+    }
+    sort.Strings(keys)
+    return keys
+}
+
+func formatCustomFieldValue(value interface{}) string {
+    switch v := value.(type) {
+    case nil:
+        return ""
+    case string:
+        return v
+    case float64:
+        return strconv.FormatFloat(v, 'f', -1, 64)
+    case []interface{}:
+        items := make([]string, 0, len(v))
+        for _, item := range v {
+            items = append(items, formatCustomFieldValue(item))
+        }
+        return strings.Join(items, "; ")
+    default:
+        return fmt.Sprint(v)
+    }
+}
+
 // GetUserActivityReport - отчет по активности пользователей
 func (h *ReportHandler) GetUserActivityReport(c *gin.Context) {
     // Для этого отчета используем локальные данные комментариев
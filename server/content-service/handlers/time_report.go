@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"content-service/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTimeReport - затраты времени и стоимость работ по проектам, подрядчикам
+// или инженерам (group_by) для сверки счетов подрядчиков. Фильтры project_id,
+// contractor_id, user_id, from и to передаются project-defect-service;
+// format=csv выгружает отчет файлом
+func (h *ReportHandler) GetTimeReport(c *gin.Context) {
+    _, userRole, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return
+    }
+    // Ставки и стоимость работ других организаций подрядчикам не показываются
+    if userRole == "contractor" {
+        h.error(c, http.StatusForbidden, "Contractors cannot view time reports")
+        return
+    }
+
+    var result struct {
+        Data struct {
+            GroupBy string                 `json:"group_by"`
+            Rows    []models.TimeReportRow `json:"rows"`
+        } `json:"data"`
+        Error string `json:"error"`
+    }
+
+    params := map[string]string{}
+    for _, name := range []string{"group_by", "project_id", "contractor_id", "user_id", "from", "to"} {
+        if value := c.Query(name); value != "" {
+            params[name] = value
+        }
+    }
+
+    resp, err := h.Client.R().
+        SetHeader("X-Service-Token", h.ServiceToken).
+        SetQueryParams(params).
+        SetResult(&result).
+        SetError(&result).
+        Get(h.ProjectDefectServiceURL + "/internal/reports/time")
+    if err != nil {
+        h.internalError(c, "Failed to fetch time report: "+err.Error())
+        return
+    }
+    if resp.StatusCode() == http.StatusBadRequest || resp.StatusCode() == http.StatusNotFound {
+        h.error(c, resp.StatusCode(), result.Error)
+        return
+    }
+    if resp.StatusCode() != http.StatusOK {
+        h.internalError(c, "Project-defect-service returned status: "+strconv.Itoa(resp.StatusCode()))
+        return
+    }
+
+    rows := result.Data.Rows
+    if result.Data.GroupBy == "engineer" {
+        if err := h.fillUserNames(c, rows); err != nil {
+            h.internalError(c, "Failed to fetch users: "+err.Error())
+            return
+        }
+    }
+
+    var totals struct {
+        Entries int64   `json:"entries"`
+        Minutes int64   `json:"minutes"`
+        Hours   float64 `json:"hours"`
+        Cost    float64 `json:"cost"`
+    }
+    for _, row := range rows {
+        totals.Entries += row.Entries
+        totals.Minutes += row.Minutes
+        totals.Cost += row.Cost
+    }
+    totals.Hours = math.Round(float64(totals.Minutes)/60*100) / 100
+    totals.Cost = math.Round(totals.Cost*100) / 100
+
+    if c.Query("format") == "csv" {
+        c.Writer.Header().Set("Content-Type", "text/csv")
+        c.Writer.Header().Set("Content-Disposition", "attachment;filename=time_report.csv")
+
+        writer := csv.NewWriter(c.Writer)
+        defer writer.Flush()
+
+        writer.Write([]string{"Group", "ID", "Name", "Entries", "Hours", "Cost"})
+        for _, row := range rows {
+            id := ""
+            if row.ID != nil {
+                id = strconv.FormatUint(uint64(*row.ID), 10)
+            }
+            writer.Write([]string{
+                result.Data.GroupBy,
+                id,
+                row.Name,
+                strconv.FormatInt(row.Entries, 10),
+                strconv.FormatFloat(row.Hours, 'f', 2, 64),
+                strconv.FormatFloat(row.Cost, 'f', 2, 64),
+            })
+        }
+        writer.Write([]string{
+            "total", "", "",
+            strconv.FormatInt(totals.Entries, 10),
+            strconv.FormatFloat(totals.Hours, 'f', 2, 64),
+            strconv.FormatFloat(totals.Cost, 'f', 2, 64),
+        })
+        return
+    }
+
+    h.success(c, gin.H{
+        "report": gin.H{
+            "group_by": result.Data.GroupBy,
+            "rows":     rows,
+            "totals":   totals,
+        },
+    }, "Time report generated successfully")
+}
+
+// fillUserNames подставляет имена инженеров из auth-service от имени текущего пользователя
+func (h *Handler) fillUserNames(c *gin.Context, rows []models.TimeReportRow) error {
+    var result struct {
+        Data struct {
+            Users []struct {
+                ID       uint   `json:"id"`
+                FullName string `json:"full_name"`
+            } `json:"users"`
+        } `json:"data"`
+    }
+
+    resp, err := h.Client.R().
+        SetHeader("Authorization", c.GetHeader("Authorization")).
+        SetResult(&result).
+        Get(h.AuthServiceURL + "/api/users")
+    if err != nil {
+        return fmt.Errorf("failed to reach auth-service: %w", err)
+    }
+    if resp.StatusCode() != http.StatusOK {
+        return fmt.Errorf("auth-service returned status: %d", resp.StatusCode())
+    }
+
+    names := make(map[uint]string, len(result.Data.Users))
+    for _, user := range result.Data.Users {
+        names[user.ID] = user.FullName
+    }
+    for i := range rows {
+        if rows[i].ID != nil {
+            rows[i].Name = names[*rows[i].ID]
+        }
+    }
+    return nil
+}
@@ -28,9 +28,11 @@ func main() {
     r := gin.Default()
     
     
-    commentHandler := handlers.NewCommentHandler(db, cfg.JWTSecret, cfg.AuthServiceURL, cfg.ProjectDefectServiceURL)
-    attachmentHandler := handlers.NewAttachmentHandler(db, cfg.JWTSecret, cfg.AuthServiceURL, cfg.ProjectDefectServiceURL, cfg.UploadPath)
-    reportHandler := handlers.NewReportHandler(db, cfg.JWTSecret, cfg.AuthServiceURL, cfg.ProjectDefectServiceURL)
+    commentHandler := handlers.NewCommentHandler(db, cfg.JWTSecret, cfg.AuthServiceURL, cfg.ProjectDefectServiceURL, cfg.ServiceToken)
+    attachmentHandler := handlers.NewAttachmentHandler(db, cfg.JWTSecret, cfg.AuthServiceURL, cfg.ProjectDefectServiceURL, cfg.ServiceToken, cfg.UploadPath)
+    floorPlanHandler := handlers.NewFloorPlanHandler(db, cfg.JWTSecret, cfg.AuthServiceURL, cfg.ProjectDefectServiceURL, cfg.ServiceToken, cfg.UploadPath)
+    reportHandler := handlers.NewReportHandler(db, cfg.JWTSecret, cfg.AuthServiceURL, cfg.ProjectDefectServiceURL, cfg.ServiceToken)
+    purgeHandler := handlers.NewPurgeHandler(db, cfg.JWTSecret, cfg.AuthServiceURL, cfg.ProjectDefectServiceURL, cfg.ServiceToken, cfg.UploadPath)
     
     // Protected routes
     api := r.Group("/api")
@@ -54,6 +56,16 @@ func main() {
             attachments.DELETE("/:id", attachmentHandler.DeleteAttachment)
         }
         
+        // Чертежи этажей
+        floorPlans := api.Group("/floor-plans")
+        {
+            floorPlans.POST("/project/:project_id", floorPlanHandler.UploadFloorPlan)
+            floorPlans.GET("/project/:project_id", floorPlanHandler.GetFloorPlans)
+            floorPlans.GET("/:id", floorPlanHandler.GetFloorPlan)
+            floorPlans.GET("/:id/file", floorPlanHandler.GetFloorPlanFile)
+            floorPlans.DELETE("/:id", floorPlanHandler.DeleteFloorPlan)
+        }
+        
         // Отчеты
         reports := api.Group("/reports")
         {
@@ -61,9 +73,21 @@ func main() {
             reports.GET("/project/:project_id", reportHandler.GetProjectReport)
             reports.GET("/defects/export", reportHandler.ExportDefectsCSV)
             reports.GET("/user-activity", reportHandler.GetUserActivityReport)
+            reports.GET("/contractors", reportHandler.GetContractorsReport)
+            reports.GET("/time", reportHandler.GetTimeReport)
+            reports.GET("/costs", reportHandler.GetCostsReport)
         }
     }
     
+    // Внутренние маршруты для других сервисов (через gateway не проксируются)
+    internal := r.Group("/internal")
+    internal.Use(middleware.ServiceTokenMiddleware(cfg.ServiceToken))
+    {
+        // Окончательное удаление данных при очистке корзины project-defect-service
+        internal.DELETE("/defects/:defect_id/content", purgeHandler.PurgeDefectContent)
+        internal.DELETE("/projects/:project_id/content", purgeHandler.PurgeProjectContent)
+    }
+    
     // Health check
     r.GET("/health", func(c *gin.Context) {
         c.JSON(200, gin.H{
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ServiceTokenMiddleware пропускает только запросы других сервисов с общим токеном
+func ServiceTokenMiddleware(serviceToken string) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        token := c.GetHeader("X-Service-Token")
+        if serviceToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(serviceToken)) != 1 {
+            c.JSON(http.StatusUnauthorized, gin.H{
+                "success": false,
+                "error":   "Invalid service token",
+            })
+            c.Abort()
+            return
+        }
+        
+        c.Next()
+    }
+}
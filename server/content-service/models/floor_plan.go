@@ -0,0 +1,17 @@
+package models
+
+// FloorPlan - чертеж этажа (изображение или страница PDF), на который
+// инспекторы ставят метки дефектов. LocationID ссылается на этаж в
+// project-defect-service.
+type FloorPlan struct {
+	BaseModel
+	ProjectID  uint   `gorm:"not null;index" json:"project_id"`
+	LocationID *uint  `gorm:"index" json:"location_id,omitempty"`
+	Name       string `gorm:"not null" json:"name"`
+	PageNumber int    `gorm:"not null;default:1" json:"page_number"`
+	Filename   string `gorm:"not null" json:"filename"`
+	Filepath   string `gorm:"not null" json:"-"`
+	FileSize   int64  `json:"file_size"`
+	MimeType   string `json:"mime_type"`
+	UploadedBy uint   `gorm:"not null" json:"uploaded_by"`
+}
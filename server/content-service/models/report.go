@@ -33,4 +33,38 @@ type UserActivity struct {
 	DefectsCreated  int64  `json:"defects_created"`
 	DefectsAssigned int64  `json:"defects_assigned"`
 	CommentsCount   int64  `json:"comments_count"`
-}
\ No newline at end of file
+}
+
+// ContractorRanking - подрядчик в рейтинге по дефектам (считает project-defect-service)
+type ContractorRanking struct {
+	ContractorID   uint   `json:"contractor_id"`
+	Name           string `json:"name"`
+	OpenDefects    int64  `json:"open_defects"`
+	OverdueDefects int64  `json:"overdue_defects"`
+	ClosedDefects  int64  `json:"closed_defects"`
+}
+
+// TimeReportRow - строка отчета по затратам времени (считает project-defect-service).
+// ID пустой у записей собственных сотрудников при группировке по подрядчикам
+type TimeReportRow struct {
+	ID      *uint   `json:"id"`
+	Name    string  `json:"name,omitempty"`
+	Entries int64   `json:"entries"`
+	Minutes int64   `json:"minutes"`
+	Hours   float64 `json:"hours"`
+	Cost    float64 `json:"cost"`
+}
+
+// CostRollup - стоимость устранения и обратные начисления проекта или подрядчика
+// в одной валюте (считает project-defect-service)
+type CostRollup struct {
+	ID                 uint    `json:"id"`
+	Name               string  `json:"name"`
+	Currency           string  `json:"currency"`
+	Defects            int64   `json:"defects"`
+	EstimatedCost      float64 `json:"estimated_cost"`
+	ActualCost         float64 `json:"actual_cost"`
+	BackChargePending  float64 `json:"back_charge_pending"`
+	BackChargeApproved float64 `json:"back_charge_approved"`
+	BackChargeRejected float64 `json:"back_charge_rejected"`
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"time"
 )
 
 type Config struct {
@@ -15,6 +17,11 @@ type Config struct {
     JWTSecret       string
     ServicePort     string
     AuthServiceURL  string
+    ContentServiceURL string
+    SchedulerInterval time.Duration
+    ReminderLead      time.Duration
+    TrashRetention    time.Duration
+    ServiceToken      string
     Env             string
 }
 
@@ -28,6 +35,11 @@ func Load() *Config {
         JWTSecret:      getEnv("JWT_SECRET", "development-secret-key"),
         ServicePort:    getEnv("PROJECT_DEFECT_SERVICE_PORT", "8082"),
         AuthServiceURL: getEnv("AUTH_SERVICE_URL", "http://auth-service:8081"),
+        ContentServiceURL: getEnv("CONTENT_SERVICE_URL", "http://content-service:8083"),
+        SchedulerInterval: getDurationEnv("SCHEDULER_INTERVAL", time.Minute),
+        ReminderLead:      time.Duration(getIntEnv("DEADLINE_REMINDER_HOURS", 24)) * time.Hour,
+        TrashRetention:    time.Duration(getIntEnv("TRASH_RETENTION_DAYS", 30)) * 24 * time.Hour,
+        ServiceToken:      getEnv("SERVICE_TOKEN", "development-service-token"),
         Env:            getEnv("ENV", "development"),
     }
     
@@ -54,4 +66,20 @@ func getEnv(key, defaultValue string) string {
         return defaultValue
     }
     return value
-}
\ No newline at end of file
+}
+
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+    value, err := time.ParseDuration(os.Getenv(key))
+    if err != nil || value <= 0 {
+        return defaultValue
+    }
+    return value
+}
+
+func getIntEnv(key string, defaultValue int) int {
+    value, err := strconv.Atoi(os.Getenv(key))
+    if err != nil || value <= 0 {
+        return defaultValue
+    }
+    return value
+}
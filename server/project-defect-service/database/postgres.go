@@ -59,18 +59,52 @@ func NewConnection(cfg *Config) (*gorm.DB, error) {
     
     log.Println("Connected to PostgreSQL database")
     
-    if err := autoMigrate(db); err != nil {
+    if err := Migrate(db); err != nil {
         return nil, err
     }
     
     return db, nil
 }
 
-func autoMigrate(db *gorm.DB) error {
+// Migrate создает и обновляет схему базы; используется также тестами обработчиков
+func Migrate(db *gorm.DB) error {
+    // pg_trgm нужен для поиска похожих дефектов по названию и описанию
+    if err := db.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+        return fmt.Errorf("failed to enable pg_trgm: %w", err)
+    }
+    
+    // Справочники мигрируются раньше дефектов: внешние ключи и таблица
+    // defect_labels ссылаются на уже существующие таблицы
     models := []interface{}{
         &models.Project{},
+        &models.Location{},
+        &models.Category{},
+        &models.CategoryAssignee{},
+        &models.Label{},
+        &models.CustomField{},
+        &models.SLAPolicy{},
+        &models.BusinessCalendar{},
+        &models.BusinessHoliday{},
+        &models.Contractor{},
+        &models.ContractorMember{},
+        &models.ProjectContractor{},
+        &models.ProjectMember{},
         &models.Defect{},
         &models.DefectHistory{},
+        &models.DefectKeyRedirect{},
+        &models.CustomFieldValue{},
+        &models.DefectRelation{},
+        &models.ChecklistItem{},
+        &models.EscalationRule{},
+        &models.DefectEvent{},
+        &models.DefectWatcher{},
+        &models.ProjectWatcher{},
+        &models.WorkLog{},
+        &models.BackCharge{},
+        &models.DefectAcceptance{},
+        &models.InspectionSchedule{},
+        &models.InspectionRound{},
+        &models.InspectionItem{},
     }
     
     for _, model := range models {
@@ -79,6 +113,69 @@ func autoMigrate(db *gorm.DB) error {
         }
     }
     
+    if err := backfillDefectKeys(db); err != nil {
+        return err
+    }
+    
+    // Проекты до появления иерархии становятся корневыми
+    if err := db.Exec(`UPDATE projects SET path = '/' || id || '/'
+        WHERE path IS NULL OR path = ''`).Error; err != nil {
+        return fmt.Errorf("failed to backfill project paths: %w", err)
+    }
+    
+    // Записи истории до появления наборов изменений становятся отдельными наборами
+    if err := db.Exec(`UPDATE defect_histories SET change_set_id = 'legacy-' || id
+        WHERE change_set_id IS NULL OR change_set_id = ''`).Error; err != nil {
+        return fmt.Errorf("failed to backfill history change sets: %w", err)
+    }
+    
+    // idx_sla_policies_scope не мешает двум общим политикам (category_id NULL)
+    // одного приоритета: NULL в уникальном индексе не совпадают. Ранее созданные
+    // дубли удаляются, остается самая ранняя политика
+    if err := db.Exec(`DELETE FROM sla_policies dup USING sla_policies kept
+        WHERE dup.category_id IS NULL AND kept.category_id IS NULL
+        AND dup.deleted_at IS NULL AND kept.deleted_at IS NULL
+        AND dup.project_id = kept.project_id AND dup.priority = kept.priority AND dup.id > kept.id`).Error; err != nil {
+        return fmt.Errorf("failed to remove duplicate SLA policies: %w", err)
+    }
+    if err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_sla_policies_general
+        ON sla_policies (project_id, priority) WHERE category_id IS NULL AND deleted_at IS NULL`).Error; err != nil {
+        return fmt.Errorf("failed to create SLA policy index: %w", err)
+    }
+    
+    if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_defects_title_trgm ON defects USING gin (title gin_trgm_ops)").Error; err != nil {
+        return fmt.Errorf("failed to create trigram index: %w", err)
+    }
+    
     log.Println("Database migration completed")
     return nil
+}
+
+// backfillDefectKeys проставляет ключи проектам и номера дефектам,
+// созданным до появления человекочитаемых ключей
+func backfillDefectKeys(db *gorm.DB) error {
+    statements := []string{
+        `UPDATE projects SET "key" = 'P' || id WHERE "key" IS NULL OR "key" = ''`,
+        `UPDATE defects d SET number = n.number
+            FROM (
+                SELECT id, ROW_NUMBER() OVER (PARTITION BY project_id ORDER BY id)
+                    + COALESCE((SELECT MAX(number) FROM defects x WHERE x.project_id = defects.project_id), 0) AS number
+                FROM defects
+                WHERE number IS NULL OR number = 0
+            ) n
+            WHERE d.id = n.id`,
+        `UPDATE projects p SET defect_seq = m.max_number
+            FROM (SELECT project_id, MAX(number) AS max_number FROM defects GROUP BY project_id) m
+            WHERE p.id = m.project_id AND p.defect_seq < m.max_number`,
+        `UPDATE defects d SET "key" = p."key" || '-' || d.number
+            FROM projects p
+            WHERE d.project_id = p.id AND (d."key" IS NULL OR d."key" = '')`,
+    }
+    
+    for _, stmt := range statements {
+        if err := db.Exec(stmt).Error; err != nil {
+            return fmt.Errorf("failed to backfill defect keys: %w", err)
+        }
+    }
+    return nil
 }
\ No newline at end of file
@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"project-defect-service/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetDefectAcceptance - состояние приемки дефекта и история ее шагов
+func (h *DefectHandler) GetDefectAcceptance(c *gin.Context) {
+    defect, _, err := h.findDefect(h.scopeDefects(c, h.DB), c.Param("id"))
+    if err != nil {
+        h.notFound(c, "Defect not found")
+        return
+    }
+
+    acceptances := []models.DefectAcceptance{}
+    if err := h.DB.Where("defect_id = ?", defect.ID).Order("id").Find(&acceptances).Error; err != nil {
+        h.internalError(c, "Failed to fetch acceptance history")
+        return
+    }
+
+    h.success(c, gin.H{
+        "acceptance_status": defect.AcceptanceStatus,
+        "accepted_by":       defect.AcceptedBy,
+        "accepted_at":       defect.AcceptedAt,
+        "reopen_count":      defect.ReopenCount,
+        "acceptances":       acceptances,
+    }, "Acceptance retrieved successfully")
+}
+
+// RequestAcceptance - исправление предъявляется надзору: дефект переходит на проверку
+func (h *DefectHandler) RequestAcceptance(c *gin.Context) {
+    defect, ok := h.findWritableDefect(c, h.DB)
+    if !ok {
+        return
+    }
+
+    userID, _, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return
+    }
+
+    var req models.AcceptanceRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    if !defect.Status.IsOpen() {
+        h.error(c, http.StatusConflict, "Only open defects can be submitted for acceptance")
+        return
+    }
+    switch defect.AcceptanceStatus {
+    case models.AcceptanceRequested:
+        h.error(c, http.StatusConflict, "Acceptance is already requested")
+        return
+    case models.AcceptanceAccepted:
+        h.error(c, http.StatusConflict, "Defect is already accepted")
+        return
+    }
+    if err := h.checkStatusTransition(defect, models.StatusOnReview); err != nil {
+        h.error(c, http.StatusConflict, err.Error())
+        return
+    }
+    if status, err := h.checkAcceptancePhotos(c, defect.ID, req.PhotoIDs); err != nil {
+        h.error(c, status, err.Error())
+        return
+    }
+
+    before := *defect
+    history := newHistoryRecorder(defect.ID, userID)
+    if defect.Status != models.StatusOnReview {
+        history.add("status", string(defect.Status), string(models.StatusOnReview))
+        defect.Status = models.StatusOnReview
+        markStatusTimestamps(defect, before.Status, time.Now().UTC())
+    }
+    history.add("acceptance", formatAcceptance(defect.AcceptanceStatus), string(models.AcceptanceRequested))
+    defect.AcceptanceStatus = models.AcceptanceRequested
+
+    h.saveAcceptance(c, &before, defect, history, models.DefectAcceptance{
+        DefectID: defect.ID,
+        Decision: models.AcceptanceRequested,
+        UserID:   userID,
+        Reason:   req.Reason,
+        PhotoIDs: req.PhotoIDs,
+    }, "Acceptance requested successfully")
+}
+
+// AcceptDefect - технический надзор принимает исправление; подписант и время
+// сохраняются в дефекте, после чего дефект можно закрыть
+func (h *DefectHandler) AcceptDefect(c *gin.Context) {
+    defect, userID, req, ok := h.prepareAcceptanceDecision(c)
+    if !ok {
+        return
+    }
+
+    before := *defect
+    now := time.Now().UTC()
+    history := newHistoryRecorder(defect.ID, userID)
+    history.add("acceptance", formatAcceptance(defect.AcceptanceStatus), string(models.AcceptanceAccepted))
+    defect.AcceptanceStatus = models.AcceptanceAccepted
+    defect.AcceptedBy = &userID
+    defect.AcceptedAt = &now
+
+    h.saveAcceptance(c, &before, defect, history, models.DefectAcceptance{
+        DefectID: defect.ID,
+        Decision: models.AcceptanceAccepted,
+        UserID:   userID,
+        Reason:   req.Reason,
+        PhotoIDs: req.PhotoIDs,
+    }, "Defect accepted successfully")
+}
+
+// RejectDefect - отказ в приемке с обязательной причиной: дефект возвращается
+// в работу, счетчик переоткрытий растет
+func (h *DefectHandler) RejectDefect(c *gin.Context) {
+    defect, userID, req, ok := h.prepareAcceptanceDecision(c)
+    if !ok {
+        return
+    }
+    if strings.TrimSpace(req.Reason) == "" {
+        h.badRequest(c, "Reason is required to reject acceptance")
+        return
+    }
+
+    before := *defect
+    history := newHistoryRecorder(defect.ID, userID)
+    history.add("acceptance", formatAcceptance(defect.AcceptanceStatus), string(models.AcceptanceRejected))
+    history.add("status", string(defect.Status), string(models.StatusInProgress))
+    history.add("reopen_count", strconv.Itoa(defect.ReopenCount), strconv.Itoa(defect.ReopenCount+1))
+    defect.AcceptanceStatus = models.AcceptanceRejected
+    defect.AcceptedBy = nil
+    defect.AcceptedAt = nil
+    defect.ReopenCount++
+    defect.Status = models.StatusInProgress
+    markStatusTimestamps(defect, before.Status, time.Now().UTC())
+
+    h.saveAcceptance(c, &before, defect, history, models.DefectAcceptance{
+        DefectID: defect.ID,
+        Decision: models.AcceptanceRejected,
+        UserID:   userID,
+        Reason:   req.Reason,
+        PhotoIDs: req.PhotoIDs,
+    }, "Defect acceptance rejected")
+}
+
+// prepareAcceptanceDecision - общие проверки решения по приемке: решение принимает
+// только технический надзор (observer) и только по запрошенной приемке
+func (h *DefectHandler) prepareAcceptanceDecision(c *gin.Context) (*models.Defect, uint, *models.AcceptanceRequest, bool) {
+    userID, userRole, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return nil, 0, nil, false
+    }
+    if userRole != "observer" {
+        h.error(c, http.StatusForbidden, "Only technical supervision can accept or reject defects")
+        return nil, 0, nil, false
+    }
+
+    defect, ok := h.findWritableDefect(c, h.DB)
+    if !ok {
+        return nil, 0, nil, false
+    }
+
+    var req models.AcceptanceRequest
+    if !h.validateRequest(c, &req) {
+        return nil, 0, nil, false
+    }
+    if defect.AcceptanceStatus != models.AcceptanceRequested {
+        h.error(c, http.StatusConflict, "Acceptance was not requested for this defect")
+        return nil, 0, nil, false
+    }
+    if status, err := h.checkAcceptancePhotos(c, defect.ID, req.PhotoIDs); err != nil {
+        h.error(c, status, err.Error())
+        return nil, 0, nil, false
+    }
+    return defect, userID, &req, true
+}
+
+// saveAcceptance сохраняет дефект, историю и шаг приемки в одной транзакции
+func (h *DefectHandler) saveAcceptance(c *gin.Context, before, defect *models.Defect, history *historyRecorder, step models.DefectAcceptance, message string) {
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := saveVersioned(tx, before, defect, &defect.Version); err != nil {
+            return err
+        }
+        if err := history.save(tx); err != nil {
+            return err
+        }
+        if err := tx.Create(&step).Error; err != nil {
+            return err
+        }
+        return afterStatusChange(tx, defect, before.Status, step.UserID)
+    })
+    if errors.Is(err, errVersionConflict) {
+        h.respondDefectConflict(c, defect.ID)
+        return
+    }
+    if err != nil {
+        h.internalError(c, "Failed to save acceptance")
+        return
+    }
+
+    setETag(c, defect.Version)
+    h.success(c, gin.H{
+        "defect":     defect,
+        "acceptance": step,
+    }, message)
+}
+
+// checkAcceptancePhotos проверяет, что фото - изображения, приложенные к этому дефекту
+// в content-service; вложения запрашиваются от имени текущего пользователя
+func (h *DefectHandler) checkAcceptancePhotos(c *gin.Context, defectID uint, photoIDs []uint) (int, error) {
+    if len(photoIDs) == 0 {
+        return 0, nil
+    }
+
+    var result struct {
+        Data struct {
+            Attachments []struct {
+                ID       uint   `json:"id"`
+                MimeType string `json:"mime_type"`
+            } `json:"attachments"`
+        } `json:"data"`
+    }
+
+    resp, err := h.Client.R().
+        SetHeader("Authorization", c.GetHeader("Authorization")).
+        SetResult(&result).
+        Get(fmt.Sprintf("%s/api/attachments/defect/%d", h.ContentServiceURL, defectID))
+    if err != nil {
+        return http.StatusBadGateway, fmt.Errorf("failed to fetch attachments from content-service: %w", err)
+    }
+    if resp.StatusCode() != http.StatusOK {
+        return http.StatusBadGateway, fmt.Errorf("content-service returned status: %d", resp.StatusCode())
+    }
+
+    images := make(map[uint]bool, len(result.Data.Attachments))
+    for _, attachment := range result.Data.Attachments {
+        images[attachment.ID] = strings.HasPrefix(attachment.MimeType, "image/")
+    }
+    for _, id := range photoIDs {
+        image, found := images[id]
+        if !found {
+            return http.StatusBadRequest, fmt.Errorf("attachment %d not found for this defect", id)
+        }
+        if !image {
+            return http.StatusBadRequest, fmt.Errorf("attachment %d is not a photo", id)
+        }
+    }
+    return 0, nil
+}
+
+// formatAcceptance - состояние приемки для истории
+func formatAcceptance(status models.AcceptanceStatus) string {
+    if status == "" {
+        return "none"
+    }
+    return string(status)
+}
@@ -0,0 +1,397 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"project-defect-service/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// projectTeam - команда проекта вместе с участниками вышестоящих портфеля и программы
+func (h *Handler) projectTeam(project *models.Project) ([]models.ProjectMember, error) {
+    projectIDs := pathIDs(project.Path)
+    if len(projectIDs) == 0 {
+        projectIDs = []uint{project.ID}
+    }
+
+    var members []models.ProjectMember
+    if err := h.DB.Where("project_id IN ?", projectIDs).Order("user_id, id").Find(&members).Error; err != nil {
+        return nil, err
+    }
+
+    // Пользователь может состоять и в проекте, и в портфеле - оставляем собственное участие
+    team := []models.ProjectMember{}
+    index := make(map[uint]int, len(members))
+    for _, member := range members {
+        member.Inherited = member.ProjectID != project.ID
+        if i, ok := index[member.UserID]; ok {
+            if !member.Inherited {
+                team[i] = member
+            }
+            continue
+        }
+        index[member.UserID] = len(team)
+        team = append(team, member)
+    }
+    return team, nil
+}
+
+// assigneeChecker проверяет исполнителей дефектов проекта. Пользователи
+// запрашиваются в auth-service один раз и только когда действительно нужны
+type assigneeChecker struct {
+    h       *Handler
+    c       *gin.Context
+    project *models.Project
+    users   map[uint]userInfo
+    team    map[uint]bool
+}
+
+func (h *Handler) newAssigneeChecker(c *gin.Context, project *models.Project) *assigneeChecker {
+    return &assigneeChecker{h: h, c: c, project: project}
+}
+
+func (a *assigneeChecker) load() error {
+    if a.users != nil {
+        return nil
+    }
+    users, err := a.h.fetchUsers(a.c)
+    if err != nil {
+        return err
+    }
+    members, err := a.h.projectTeam(a.project)
+    if err != nil {
+        return err
+    }
+    a.team = make(map[uint]bool, len(members))
+    for _, member := range members {
+        a.team[member.UserID] = true
+    }
+    a.users = users
+    return nil
+}
+
+// check проверяет исполнителя: пользователь существует и не отключен; у дефекта
+// подрядчика это сотрудник подрядчика (членство проверяет validateDefectContractor),
+// иначе инженер из команды проекта. В проект без команды инженера назначить нельзя:
+// сначала нужно добавить участников. Ошибка с кодом 400 - недопустимый исполнитель, 500 - auth-service недоступен
+func (a *assigneeChecker) check(contractorID, assigneeID *uint) (int, error) {
+    if assigneeID == nil || *assigneeID == 0 {
+        return http.StatusOK, nil
+    }
+    if err := a.load(); err != nil {
+        return http.StatusInternalServerError, fmt.Errorf("failed to check assignee: %w", err)
+    }
+
+    user, ok := a.users[*assigneeID]
+    if !ok {
+        return http.StatusBadRequest, fmt.Errorf("assignee not found")
+    }
+    if !user.Active {
+        return http.StatusBadRequest, fmt.Errorf("assignee account is deactivated")
+    }
+    if contractorID != nil && *contractorID != 0 {
+        if user.RoleName != models.RoleContractor {
+            return http.StatusBadRequest, fmt.Errorf("contractor defects can only be assigned to contractor members")
+        }
+        return http.StatusOK, nil
+    }
+    if user.RoleName != "engineer" {
+        return http.StatusBadRequest, fmt.Errorf("only engineers can be assigned to defects")
+    }
+    if len(a.team) == 0 {
+        return http.StatusBadRequest, fmt.Errorf("project team is empty: add members before assigning engineers")
+    }
+    if !a.team[*assigneeID] {
+        return http.StatusBadRequest, fmt.Errorf("assignee is not a member of the project team")
+    }
+    return http.StatusOK, nil
+}
+
+// engineers - инженеры, которых можно назначить автоматически, по возрастанию ID
+func (a *assigneeChecker) engineers() ([]uint, error) {
+    if err := a.load(); err != nil {
+        return nil, err
+    }
+    ids := []uint{}
+    for id := range a.users {
+        if status, _ := a.check(nil, &id); status == http.StatusOK {
+            ids = append(ids, id)
+        }
+    }
+    sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+    return ids, nil
+}
+
+// autoAssignee подбирает исполнителя нового дефекта по режиму проекта. В режиме
+// round_robin выбор зависит от очереди проекта и делается в транзакции создания
+// (nextRoundRobin), поэтому возвращаются кандидаты. Если подходящих инженеров нет,
+// используется исполнитель по умолчанию для категории
+func (h *Handler) autoAssignee(checker *assigneeChecker, project *models.Project, categoryID *uint) (*uint, []uint, error) {
+    if project.AssignmentMode == models.AssignManual {
+        return nil, nil, nil
+    }
+
+    if project.AssignmentMode == models.AssignRoundRobin || project.AssignmentMode == models.AssignLeastLoaded {
+        candidates, err := checker.engineers()
+        if err != nil {
+            return nil, nil, err
+        }
+        if len(candidates) > 0 && project.AssignmentMode == models.AssignRoundRobin {
+            return nil, candidates, nil
+        }
+        if len(candidates) > 0 {
+            assigneeID, err := h.leastLoaded(candidates)
+            return &assigneeID, nil, err
+        }
+    }
+
+    if categoryID == nil {
+        return nil, nil, nil
+    }
+    assigneeID, err := h.defaultAssigneeForCategory(project.ID, *categoryID)
+    if err != nil || assigneeID == nil {
+        return nil, nil, err
+    }
+    // Исполнитель категории мог быть отключен или выйти из команды - тогда без исполнителя
+    if status, err := checker.check(nil, assigneeID); err != nil {
+        if status == http.StatusInternalServerError {
+            return nil, nil, err
+        }
+        return nil, nil, nil
+    }
+    return assigneeID, nil, nil
+}
+
+// leastLoaded - кандидат с наименьшим числом открытых дефектов во всех проектах;
+// при равенстве - с меньшим ID
+func (h *Handler) leastLoaded(candidates []uint) (uint, error) {
+    var rows []struct {
+        AssigneeID  uint
+        OpenDefects int64
+    }
+    open := []models.DefectStatus{models.StatusNew, models.StatusInProgress, models.StatusOnReview}
+    if err := h.DB.Model(&models.Defect{}).
+        Select("assignee_id, COUNT(*) AS open_defects").
+        Where("assignee_id IN ? AND status IN ?", candidates, open).
+        Group("assignee_id").
+        Scan(&rows).Error; err != nil {
+        return 0, err
+    }
+    load := make(map[uint]int64, len(rows))
+    for _, row := range rows {
+        load[row.AssigneeID] = row.OpenDefects
+    }
+
+    best := candidates[0]
+    for _, id := range candidates[1:] {
+        if load[id] < load[best] {
+            best = id
+        }
+    }
+    return best, nil
+}
+
+// nextRoundRobin выбирает следующего по очереди кандидата и сдвигает очередь проекта.
+// Вызывается после allocateDefectNumber: строка проекта уже заблокирована,
+// поэтому параллельно созданные дефекты достаются разным инженерам
+func nextRoundRobin(tx *gorm.DB, projectID uint, candidates []uint) (uint, error) {
+    var row struct {
+        AssignCursor *uint
+    }
+    if err := tx.Model(&models.Project{}).Select("assign_cursor").Where("id = ?", projectID).Scan(&row).Error; err != nil {
+        return 0, err
+    }
+
+    next := candidates[0]
+    if row.AssignCursor != nil {
+        for _, id := range candidates {
+            if id > *row.AssignCursor {
+                next = id
+                break
+            }
+        }
+    }
+    if err := tx.Model(&models.Project{}).Where("id = ?", projectID).UpdateColumn("assign_cursor", next).Error; err != nil {
+        return 0, err
+    }
+    return next, nil
+}
+
+// GetProjectMembers - команда проекта, включая участников портфеля и программы
+func (h *ProjectHandler) GetProjectMembers(c *gin.Context) {
+    var project models.Project
+    if err := h.DB.First(&project, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+
+    team, err := h.projectTeam(&project)
+    if err != nil {
+        h.internalError(c, "Failed to fetch project members")
+        return
+    }
+
+    h.success(c, gin.H{
+        "members": team,
+    }, "Project members retrieved successfully")
+}
+
+// AddProjectMember - добавление пользователя в команду проекта
+func (h *ProjectHandler) AddProjectMember(c *gin.Context) {
+    var project models.Project
+    if err := h.DB.First(&project, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+
+    if !h.canManageProject(c, &project) {
+        return
+    }
+
+    var req models.ProjectMemberRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    users, err := h.fetchUsers(c)
+    if err != nil {
+        h.internalError(c, "Failed to check user: "+err.Error())
+        return
+    }
+    user, ok := users[req.UserID]
+    if !ok {
+        h.badRequest(c, "User not found")
+        return
+    }
+    if !user.Active {
+        h.badRequest(c, "User account is deactivated")
+        return
+    }
+
+    member := models.ProjectMember{ProjectID: project.ID, UserID: req.UserID}
+    if err := h.DB.Where(member).FirstOrCreate(&member).Error; err != nil {
+        h.internalError(c, "Failed to add project member")
+        return
+    }
+
+    h.success(c, gin.H{
+        "member": member,
+    }, "Project member added successfully")
+}
+
+// DeleteProjectMember - исключение из команды; назначенные дефекты остаются за пользователем
+func (h *ProjectHandler) DeleteProjectMember(c *gin.Context) {
+    var project models.Project
+    if err := h.DB.First(&project, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+
+    if !h.canManageProject(c, &project) {
+        return
+    }
+
+    result := h.DB.Unscoped().Where("project_id = ? AND user_id = ?", project.ID, c.Param("user_id")).
+        Delete(&models.ProjectMember{})
+    if result.Error != nil {
+        h.internalError(c, "Failed to remove project member")
+        return
+    }
+    if result.RowsAffected == 0 {
+        h.notFound(c, "Project member not found")
+        return
+    }
+
+    h.success(c, nil, "Project member removed successfully")
+}
+
+// GetWorkload - открытые, просроченные и критические дефекты каждого активного инженера.
+// С project_id - только инженеры команды проекта и только дефекты его поддерева
+func (h *DefectHandler) GetWorkload(c *gin.Context) {
+    users, err := h.fetchUsers(c)
+    if err != nil {
+        h.internalError(c, "Failed to fetch users: "+err.Error())
+        return
+    }
+
+    open := []models.DefectStatus{models.StatusNew, models.StatusInProgress, models.StatusOnReview}
+    query := h.DB.Model(&models.Defect{}).
+        Select(`defects.assignee_id,
+            COUNT(*) AS open_defects,
+            COUNT(*) FILTER (WHERE `+models.DefectDueAtSQL+` <= ?) AS overdue_defects,
+            COUNT(*) FILTER (WHERE defects.priority = ?) AS critical_defects`,
+            time.Now().UTC(), models.PriorityCritical).
+        Where("defects.assignee_id IS NOT NULL AND defects.status IN ?", open).
+        Group("defects.assignee_id")
+
+    var team map[uint]bool
+    if projectID := c.Query("project_id"); projectID != "" {
+        id, err := strconv.ParseUint(projectID, 10, 32)
+        if err != nil {
+            h.badRequest(c, "Invalid project ID")
+            return
+        }
+        var project models.Project
+        if err := h.DB.First(&project, id).Error; err != nil {
+            h.notFound(c, "Project not found")
+            return
+        }
+        members, err := h.projectTeam(&project)
+        if err != nil {
+            h.internalError(c, "Failed to fetch project members")
+            return
+        }
+        if len(members) > 0 {
+            team = make(map[uint]bool, len(members))
+            for _, member := range members {
+                team[member.UserID] = true
+            }
+        }
+        query = query.Where("defects.project_id IN (?)",
+            h.DB.Model(&models.Project{}).Select("id").Where("path LIKE ?", project.Path+"%"))
+    }
+
+    var rows []struct {
+        AssigneeID uint
+        models.ProjectStats
+    }
+    if err := query.Scan(&rows).Error; err != nil {
+        h.internalError(c, "Failed to calculate workload")
+        return
+    }
+    stats := make(map[uint]models.ProjectStats, len(rows))
+    for _, row := range rows {
+        stats[row.AssigneeID] = row.ProjectStats
+    }
+
+    workload := []models.EngineerWorkload{}
+    for _, user := range users {
+        if user.RoleName != "engineer" || !user.Active || (team != nil && !team[user.ID]) {
+            continue
+        }
+        workload = append(workload, models.EngineerWorkload{
+            UserID:          user.ID,
+            Email:           user.Email,
+            FullName:        user.FullName,
+            OpenDefects:     stats[user.ID].OpenDefects,
+            OverdueDefects:  stats[user.ID].OverdueDefects,
+            CriticalDefects: stats[user.ID].CriticalDefects,
+        })
+    }
+    sort.Slice(workload, func(i, j int) bool {
+        if workload[i].OpenDefects != workload[j].OpenDefects {
+            return workload[i].OpenDefects > workload[j].OpenDefects
+        }
+        return workload[i].FullName < workload[j].FullName
+    })
+
+    h.success(c, gin.H{
+        "workload": workload,
+    }, "Workload retrieved successfully")
+}
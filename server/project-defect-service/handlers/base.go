@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
+	"github.com/go-resty/resty/v2"
 	"gorm.io/gorm"
 )
 
@@ -15,15 +17,20 @@ type Handler struct {
     Validate     *validator.Validate
     JWTSecret    string
     AuthServiceURL string
+    // HTTP-клиент для запросов в другие сервисы
+    Client       *resty.Client
 }
 
 func NewHandler(db *gorm.DB, jwtSecret, authServiceURL string) *Handler {
     validate := validator.New()
+    client := resty.New()
+    client.SetTimeout(10 * time.Second)
     return &Handler{
         DB:            db,
         Validate:      validate,
         JWTSecret:     jwtSecret,
         AuthServiceURL: authServiceURL,
+        Client:        client,
     }
 }
 
@@ -0,0 +1,444 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"project-defect-service/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CategoryHandler struct {
+    Handler
+}
+
+func NewCategoryHandler(db *gorm.DB, jwtSecret, authServiceURL string) *CategoryHandler {
+    return &CategoryHandler{
+        Handler: *NewHandler(db, jwtSecret, authServiceURL),
+    }
+}
+
+// GetCategories - классификатор: общие категории и категории проекта (?project_id=),
+// плоским списком или деревом (?tree=true)
+func (h *CategoryHandler) GetCategories(c *gin.Context) {
+    query := h.DB.Model(&models.Category{})
+    if projectID := c.Query("project_id"); projectID != "" {
+        query = query.Where("project_id IS NULL OR project_id = ?", projectID)
+    } else {
+        query = query.Where("project_id IS NULL")
+    }
+    if level := c.Query("level"); level != "" {
+        query = query.Where("level = ?", level)
+    }
+
+    var categories []models.Category
+    if err := query.Order("path").Find(&categories).Error; err != nil {
+        h.internalError(c, "Failed to fetch categories")
+        return
+    }
+
+    if c.Query("tree") == "true" {
+        h.success(c, gin.H{
+            "categories": buildCategoryTree(categories),
+        }, "Categories retrieved successfully")
+        return
+    }
+
+    h.success(c, gin.H{
+        "categories": categories,
+    }, "Categories retrieved successfully")
+}
+
+func (h *CategoryHandler) CreateCategory(c *gin.Context) {
+    var req models.CategoryCreateRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    if !h.canManageCategories(c, req.ProjectID) {
+        return
+    }
+
+    category := models.Category{
+        ProjectID:         req.ProjectID,
+        Level:             req.Level,
+        Name:              req.Name,
+        Code:              req.Code,
+        DefaultAssigneeID: req.DefaultAssigneeID,
+    }
+
+    parentPath := "/"
+    var parentLevel models.CategoryLevel
+    if req.ParentID != nil {
+        var parent models.Category
+        if err := h.DB.First(&parent, *req.ParentID).Error; err != nil {
+            h.badRequest(c, "Parent category not found")
+            return
+        }
+        // Проектная категория может дополнять общий классификатор, но не наоборот
+        if parent.ProjectID != nil && (req.ProjectID == nil || *parent.ProjectID != *req.ProjectID) {
+            h.badRequest(c, "Parent category belongs to another project")
+            return
+        }
+        category.ParentID = &parent.ID
+        parentPath = parent.Path
+        parentLevel = parent.Level
+    }
+
+    if req.Level.ParentLevel() != parentLevel {
+        h.badRequest(c, fmt.Sprintf("Category of level %s cannot be placed here", req.Level))
+        return
+    }
+
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Create(&category).Error; err != nil {
+            return err
+        }
+        category.Path = fmt.Sprintf("%s%d/", parentPath, category.ID)
+        return tx.Model(&category).Update("path", category.Path).Error
+    })
+    if err != nil {
+        h.internalError(c, "Failed to create category")
+        return
+    }
+
+    h.success(c, gin.H{
+        "category": category,
+    }, "Category created successfully")
+}
+
+func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
+    var category models.Category
+    if err := h.DB.First(&category, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Category not found")
+        return
+    }
+
+    if !h.canManageCategories(c, category.ProjectID) {
+        return
+    }
+
+    var req models.CategoryUpdateRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    if req.Name != nil {
+        category.Name = *req.Name
+    }
+    if req.Code != nil {
+        category.Code = *req.Code
+    }
+    // default_assignee_id = 0 снимает исполнителя по умолчанию
+    if req.DefaultAssigneeID != nil {
+        category.DefaultAssigneeID = req.DefaultAssigneeID
+        if *req.DefaultAssigneeID == 0 {
+            category.DefaultAssigneeID = nil
+        }
+    }
+
+    if err := h.DB.Save(&category).Error; err != nil {
+        h.internalError(c, "Failed to update category")
+        return
+    }
+
+    h.success(c, gin.H{
+        "category": category,
+    }, "Category updated successfully")
+}
+
+func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
+    var category models.Category
+    if err := h.DB.First(&category, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Category not found")
+        return
+    }
+
+    if !h.canManageCategories(c, category.ProjectID) {
+        return
+    }
+
+    var childrenCount int64
+    h.DB.Model(&models.Category{}).Where("parent_id = ?", category.ID).Count(&childrenCount)
+    if childrenCount > 0 {
+        h.badRequest(c, "Cannot delete category with nested categories")
+        return
+    }
+
+    var defectsCount int64
+    h.DB.Model(&models.Defect{}).Where("category_id = ?", category.ID).Count(&defectsCount)
+    if defectsCount > 0 {
+        h.badRequest(c, "Cannot delete category with existing defects")
+        return
+    }
+
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Where("category_id = ?", category.ID).Delete(&models.CategoryAssignee{}).Error; err != nil {
+            return err
+        }
+        return tx.Delete(&category).Error
+    })
+    if err != nil {
+        h.internalError(c, "Failed to delete category")
+        return
+    }
+
+    h.success(c, nil, "Category deleted successfully")
+}
+
+// GetCategoryAssignees - исполнители по умолчанию, переопределенные в проекте
+func (h *CategoryHandler) GetCategoryAssignees(c *gin.Context) {
+    var assignees []models.CategoryAssignee
+    if err := h.DB.Where("project_id = ?", c.Param("id")).Find(&assignees).Error; err != nil {
+        h.internalError(c, "Failed to fetch category assignees")
+        return
+    }
+
+    h.success(c, gin.H{
+        "assignees": assignees,
+    }, "Category assignees retrieved successfully")
+}
+
+func (h *CategoryHandler) SetCategoryAssignee(c *gin.Context) {
+    var project models.Project
+    if err := h.DB.First(&project, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+
+    if !h.canManageProject(c, &project) {
+        return
+    }
+
+    categoryID, err := strconv.ParseUint(c.Param("category_id"), 10, 32)
+    if err != nil {
+        h.badRequest(c, "Invalid category ID")
+        return
+    }
+    if err := h.validateDefectCategory(project.ID, uintPtr(uint(categoryID))); err != nil {
+        h.badRequest(c, err.Error())
+        return
+    }
+
+    var req models.CategoryAssigneeRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    var assignee models.CategoryAssignee
+    err = h.DB.
+        Where(models.CategoryAssignee{ProjectID: project.ID, CategoryID: uint(categoryID)}).
+        Assign(models.CategoryAssignee{AssigneeID: req.AssigneeID}).
+        FirstOrCreate(&assignee).Error
+    if err != nil {
+        h.internalError(c, "Failed to set category assignee")
+        return
+    }
+
+    h.success(c, gin.H{
+        "assignee": assignee,
+    }, "Category assignee set successfully")
+}
+
+func (h *CategoryHandler) DeleteCategoryAssignee(c *gin.Context) {
+    var project models.Project
+    if err := h.DB.First(&project, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+
+    if !h.canManageProject(c, &project) {
+        return
+    }
+
+    if err := h.DB.Unscoped().
+        Where("project_id = ? AND category_id = ?", project.ID, c.Param("category_id")).
+        Delete(&models.CategoryAssignee{}).Error; err != nil {
+        h.internalError(c, "Failed to delete category assignee")
+        return
+    }
+
+    h.success(c, nil, "Category assignee deleted successfully")
+}
+
+// canManageCategories - общий классификатор ведут менеджеры, проектный - менеджер проекта
+func (h *Handler) canManageCategories(c *gin.Context, projectID *uint) bool {
+    if projectID != nil {
+        var project models.Project
+        if err := h.DB.First(&project, *projectID).Error; err != nil {
+            h.badRequest(c, "Project not found")
+            return false
+        }
+        return h.canManageProject(c, &project)
+    }
+
+    _, userRole, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return false
+    }
+    if userRole != "manager" {
+        h.error(c, http.StatusForbidden, "Only managers can change the shared category taxonomy")
+        return false
+    }
+    return true
+}
+
+// validateDefectCategory проверяет, что категория общая или принадлежит проекту дефекта
+func (h *Handler) validateDefectCategory(projectID uint, categoryID *uint) error {
+    if categoryID == nil || *categoryID == 0 {
+        return nil
+    }
+
+    var count int64
+    h.DB.Model(&models.Category{}).
+        Where("id = ? AND (project_id IS NULL OR project_id = ?)", *categoryID, projectID).
+        Count(&count)
+    if count == 0 {
+        return fmt.Errorf("category not found in this project")
+    }
+    return nil
+}
+
+// defaultAssigneeForCategory ищет исполнителя по умолчанию, поднимаясь от категории
+// к виду работ. На каждом уровне настройка проекта важнее настройки категории.
+func (h *Handler) defaultAssigneeForCategory(projectID, categoryID uint) (*uint, error) {
+    var category models.Category
+    if err := h.DB.First(&category, categoryID).Error; err != nil {
+        return nil, err
+    }
+
+    ancestorIDs := pathIDs(category.Path)
+
+    var overrides []models.CategoryAssignee
+    if err := h.DB.Where("project_id = ? AND category_id IN ?", projectID, ancestorIDs).Find(&overrides).Error; err != nil {
+        return nil, err
+    }
+    overrideByCategory := make(map[uint]uint)
+    for _, override := range overrides {
+        overrideByCategory[override.CategoryID] = override.AssigneeID
+    }
+
+    var ancestors []models.Category
+    if err := h.DB.Where("id IN ?", ancestorIDs).Find(&ancestors).Error; err != nil {
+        return nil, err
+    }
+    defaultByCategory := make(map[uint]*uint)
+    for _, ancestor := range ancestors {
+        defaultByCategory[ancestor.ID] = ancestor.DefaultAssigneeID
+    }
+
+    for i := len(ancestorIDs) - 1; i >= 0; i-- {
+        id := ancestorIDs[i]
+        if assigneeID, ok := overrideByCategory[id]; ok {
+            return &assigneeID, nil
+        }
+        if assigneeID := defaultByCategory[id]; assigneeID != nil {
+            return assigneeID, nil
+        }
+    }
+    return nil, nil
+}
+
+// GetCategoryLabels - подписи категорий для отчетов: путь имен от вида работ до
+// категории, например "Электрика / Проводка". ids - список ID через запятую;
+// удаленные категории тоже подписываются, на них могут ссылаться старые дефекты
+func (h *CategoryHandler) GetCategoryLabels(c *gin.Context) {
+    var ids []uint
+    for _, part := range strings.Split(c.Query("ids"), ",") {
+        if part = strings.TrimSpace(part); part == "" {
+            continue
+        }
+        id, err := strconv.ParseUint(part, 10, 32)
+        if err != nil {
+            h.badRequest(c, "Invalid category ID: "+part)
+            return
+        }
+        ids = append(ids, uint(id))
+    }
+
+    labels := make(map[string]string, len(ids))
+    if len(ids) == 0 {
+        h.success(c, gin.H{"labels": labels}, "Category labels retrieved successfully")
+        return
+    }
+
+    var categories []models.Category
+    if err := h.DB.Unscoped().Where("id IN ?", ids).Find(&categories).Error; err != nil {
+        h.internalError(c, "Failed to fetch categories")
+        return
+    }
+    var ancestorIDs []uint
+    for _, category := range categories {
+        ancestorIDs = append(ancestorIDs, pathIDs(category.Path)...)
+    }
+    var ancestors []models.Category
+    if err := h.DB.Unscoped().Select("id", "name").Where("id IN ?", append(ancestorIDs, ids...)).Find(&ancestors).Error; err != nil {
+        h.internalError(c, "Failed to fetch categories")
+        return
+    }
+    names := make(map[uint]string, len(ancestors))
+    for _, ancestor := range ancestors {
+        names[ancestor.ID] = ancestor.Name
+    }
+
+    for _, category := range categories {
+        var parts []string
+        for _, id := range pathIDs(category.Path) {
+            if name, ok := names[id]; ok {
+                parts = append(parts, name)
+            }
+        }
+        if len(parts) == 0 {
+            parts = []string{category.Name}
+        }
+        labels[strconv.FormatUint(uint64(category.ID), 10)] = strings.Join(parts, " / ")
+    }
+
+    h.success(c, gin.H{"labels": labels}, "Category labels retrieved successfully")
+}
+
+// pathIDs разбирает материализованный путь /1/5/12/ в ID от корня к узлу
+func pathIDs(path string) []uint {
+    var ids []uint
+    for _, part := range strings.Split(strings.Trim(path, "/"), "/") {
+        if id, err := strconv.ParseUint(part, 10, 32); err == nil {
+            ids = append(ids, uint(id))
+        }
+    }
+    return ids
+}
+
+func buildCategoryTree(categories []models.Category) []models.Category {
+    children := make(map[uint][]models.Category)
+    var roots []models.Category
+
+    for _, category := range categories {
+        if category.ParentID != nil {
+            children[*category.ParentID] = append(children[*category.ParentID], category)
+        }
+    }
+
+    var attach func(category models.Category) models.Category
+    attach = func(category models.Category) models.Category {
+        for _, child := range children[category.ID] {
+            category.Children = append(category.Children, attach(child))
+        }
+        return category
+    }
+
+    for _, category := range categories {
+        if category.ParentID == nil {
+            roots = append(roots, attach(category))
+        }
+    }
+    return roots
+}
+
+func uintPtr(v uint) *uint {
+    return &v
+}
@@ -0,0 +1,380 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"project-defect-service/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ChecklistHandler struct {
+    Handler
+}
+
+func NewChecklistHandler(db *gorm.DB, jwtSecret, authServiceURL string) *ChecklistHandler {
+    return &ChecklistHandler{
+        Handler: *NewHandler(db, jwtSecret, authServiceURL),
+    }
+}
+
+func (h *ChecklistHandler) GetChecklist(c *gin.Context) {
+    defect, _, err := h.findDefect(h.scopeDefects(c, h.DB), c.Param("id"))
+    if err != nil {
+        h.notFound(c, "Defect not found")
+        return
+    }
+
+    h.respondWithChecklist(c, defect, "Checklist retrieved successfully")
+}
+
+func (h *ChecklistHandler) CreateChecklistItem(c *gin.Context) {
+    defect, ok := h.findWritableDefect(c, h.DB)
+    if !ok {
+        return
+    }
+
+    userID, _, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return
+    }
+
+    var req models.ChecklistItemCreateRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    item := models.ChecklistItem{
+        DefectID:   defect.ID,
+        Title:      strings.TrimSpace(req.Title),
+        AssigneeID: req.AssigneeID,
+    }
+    if item.Title == "" {
+        h.badRequest(c, "Title is required")
+        return
+    }
+
+    err = h.DB.Transaction(func(tx *gorm.DB) error {
+        // Без явной позиции пункт добавляется в конец, иначе последующие сдвигаются
+        if req.Position == nil {
+            var last int
+            if err := tx.Model(&models.ChecklistItem{}).
+                Where("defect_id = ?", defect.ID).
+                Select("COALESCE(MAX(position), 0)").
+                Scan(&last).Error; err != nil {
+                return err
+            }
+            item.Position = last + 1
+        } else {
+            item.Position = *req.Position
+            if err := tx.Model(&models.ChecklistItem{}).
+                Where("defect_id = ? AND position >= ?", defect.ID, item.Position).
+                Update("position", gorm.Expr("position + 1")).Error; err != nil {
+                return err
+            }
+        }
+
+        if err := tx.Create(&item).Error; err != nil {
+            return err
+        }
+        return recordDefectChange(tx, defect.ID, userID, "checklist", "none", item.Title)
+    })
+    if err != nil {
+        h.internalError(c, "Failed to create checklist item")
+        return
+    }
+
+    h.respondWithChecklist(c, defect, "Checklist item created successfully")
+}
+
+func (h *ChecklistHandler) UpdateChecklistItem(c *gin.Context) {
+    defect, item, ok := h.findChecklistItem(c)
+    if !ok {
+        return
+    }
+
+    userID, _, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return
+    }
+
+    var req models.ChecklistItemUpdateRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    type change struct{ old, new string }
+    var changes []change
+
+    if req.Title != nil {
+        title := strings.TrimSpace(*req.Title)
+        if title == "" {
+            h.badRequest(c, "Title cannot be empty")
+            return
+        }
+        if title != item.Title {
+            changes = append(changes, change{item.Title, title})
+            item.Title = title
+        }
+    }
+    // assignee_id = 0 снимает исполнителя пункта
+    if req.AssigneeID != nil && !sameUint(req.AssigneeID, item.AssigneeID) {
+        if *req.AssigneeID == 0 {
+            req.AssigneeID = nil
+        }
+        changes = append(changes, change{
+            fmt.Sprintf("%s: assignee %s", item.Title, formatOptionalID(item.AssigneeID)),
+            fmt.Sprintf("%s: assignee %s", item.Title, formatOptionalID(req.AssigneeID)),
+        })
+        item.AssigneeID = req.AssigneeID
+    }
+    if req.Done != nil && *req.Done != item.Done {
+        changes = append(changes, change{checklistItemState(item), checklistItemState(&models.ChecklistItem{Title: item.Title, Done: *req.Done})})
+        item.Done = *req.Done
+        if item.Done {
+            now := time.Now().UTC()
+            item.DoneBy = &userID
+            item.DoneAt = &now
+        } else {
+            item.DoneBy = nil
+            item.DoneAt = nil
+        }
+    }
+
+    err = h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Save(item).Error; err != nil {
+            return err
+        }
+        history := newHistoryRecorder(defect.ID, userID)
+        for _, ch := range changes {
+            history.add("checklist", ch.old, ch.new)
+        }
+        return history.save(tx)
+    })
+    if err != nil {
+        h.internalError(c, "Failed to update checklist item")
+        return
+    }
+
+    h.respondWithChecklist(c, defect, "Checklist item updated successfully")
+}
+
+func (h *ChecklistHandler) DeleteChecklistItem(c *gin.Context) {
+    defect, item, ok := h.findChecklistItem(c)
+    if !ok {
+        return
+    }
+
+    userID, _, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return
+    }
+
+    err = h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Delete(item).Error; err != nil {
+            return err
+        }
+        return recordDefectChange(tx, defect.ID, userID, "checklist", item.Title, "none")
+    })
+    if err != nil {
+        h.internalError(c, "Failed to delete checklist item")
+        return
+    }
+
+    h.respondWithChecklist(c, defect, "Checklist item deleted successfully")
+}
+
+// ReorderChecklist - новый порядок пунктов; в запросе должны быть все пункты дефекта
+func (h *ChecklistHandler) ReorderChecklist(c *gin.Context) {
+    defect, ok := h.findWritableDefect(c, h.DB)
+    if !ok {
+        return
+    }
+
+    userID, _, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return
+    }
+
+    var req models.ChecklistOrderRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    var items []models.ChecklistItem
+    if err := h.DB.Where("defect_id = ?", defect.ID).Order("position, id").Find(&items).Error; err != nil {
+        h.internalError(c, "Failed to fetch checklist")
+        return
+    }
+
+    byID := make(map[uint]models.ChecklistItem, len(items))
+    for _, item := range items {
+        byID[item.ID] = item
+    }
+    ids := uniqueUints(req.ItemIDs)
+    if len(ids) != len(req.ItemIDs) || len(ids) != len(items) {
+        h.badRequest(c, "item_ids must list every checklist item exactly once")
+        return
+    }
+    for _, id := range ids {
+        if _, ok := byID[id]; !ok {
+            h.badRequest(c, fmt.Sprintf("Checklist item %d not found", id))
+            return
+        }
+    }
+
+    oldOrder := checklistTitles(items)
+    reordered := make([]models.ChecklistItem, len(ids))
+    for i, id := range ids {
+        reordered[i] = byID[id]
+    }
+    newOrder := checklistTitles(reordered)
+
+    err = h.DB.Transaction(func(tx *gorm.DB) error {
+        for i, id := range ids {
+            if err := tx.Model(&models.ChecklistItem{}).Where("id = ?", id).Update("position", i+1).Error; err != nil {
+                return err
+            }
+        }
+        if oldOrder == newOrder {
+            return nil
+        }
+        return recordDefectChange(tx, defect.ID, userID, "checklist_order", oldOrder, newOrder)
+    })
+    if err != nil {
+        h.internalError(c, "Failed to reorder checklist")
+        return
+    }
+
+    h.respondWithChecklist(c, defect, "Checklist reordered successfully")
+}
+
+func (h *ChecklistHandler) findChecklistItem(c *gin.Context) (*models.Defect, *models.ChecklistItem, bool) {
+    defect, ok := h.findWritableDefect(c, h.DB)
+    if !ok {
+        return nil, nil, false
+    }
+
+    var item models.ChecklistItem
+    if err := h.DB.Where("defect_id = ?", defect.ID).First(&item, c.Param("item_id")).Error; err != nil {
+        h.notFound(c, "Checklist item not found")
+        return nil, nil, false
+    }
+    return defect, &item, true
+}
+
+func (h *ChecklistHandler) respondWithChecklist(c *gin.Context, defect *models.Defect, message string) {
+    var items []models.ChecklistItem
+    if err := h.DB.Where("defect_id = ?", defect.ID).Order("position, id").Find(&items).Error; err != nil {
+        h.internalError(c, "Failed to fetch checklist")
+        return
+    }
+
+    h.success(c, gin.H{
+        "defect_id": defect.ID,
+        "checklist": items,
+        "progress":  checklistProgress(items),
+    }, message)
+}
+
+// attachChecklistProgress заполняет прогресс чек-листа одним запросом на список дефектов
+func (h *Handler) attachChecklistProgress(defects []models.Defect) error {
+    if len(defects) == 0 {
+        return nil
+    }
+
+    ids := make([]uint, len(defects))
+    for i, defect := range defects {
+        ids[i] = defect.ID
+    }
+
+    var rows []struct {
+        DefectID uint
+        Total    int
+        Done     int
+    }
+    if err := h.DB.Model(&models.ChecklistItem{}).
+        Select("defect_id, COUNT(*) AS total, COUNT(*) FILTER (WHERE done) AS done").
+        Where("defect_id IN ?", ids).
+        Group("defect_id").
+        Scan(&rows).Error; err != nil {
+        return err
+    }
+
+    byDefect := make(map[uint]*models.ChecklistProgress, len(rows))
+    for _, row := range rows {
+        byDefect[row.DefectID] = newChecklistProgress(row.Total, row.Done)
+    }
+    for i := range defects {
+        defects[i].ChecklistProgress = byDefect[defects[i].ID]
+    }
+    return nil
+}
+
+// checkChecklistComplete - в проектах с обязательным чек-листом на проверку
+// можно отправить только дефект с выполненными пунктами
+func (h *Handler) checkChecklistComplete(defect *models.Defect) error {
+    var project models.Project
+    if err := h.DB.Select("id", "require_checklist_for_review").First(&project, defect.ProjectID).Error; err != nil {
+        return err
+    }
+    if !project.RequireChecklistForReview {
+        return nil
+    }
+
+    var pending int64
+    if err := h.DB.Model(&models.ChecklistItem{}).
+        Where("defect_id = ? AND done = ?", defect.ID, false).
+        Count(&pending).Error; err != nil {
+        return err
+    }
+    if pending > 0 {
+        return fmt.Errorf("%d checklist items are not done", pending)
+    }
+    return nil
+}
+
+func checklistProgress(items []models.ChecklistItem) *models.ChecklistProgress {
+    done := 0
+    for _, item := range items {
+        if item.Done {
+            done++
+        }
+    }
+    return newChecklistProgress(len(items), done)
+}
+
+func newChecklistProgress(total, done int) *models.ChecklistProgress {
+    progress := &models.ChecklistProgress{Total: total, Done: done}
+    if total > 0 {
+        progress.Percent = done * 100 / total
+    }
+    return progress
+}
+
+// checklistItemState - пункт с отметкой выполнения для истории
+func checklistItemState(item *models.ChecklistItem) string {
+    if item.Done {
+        return "[x] " + item.Title
+    }
+    return "[ ] " + item.Title
+}
+
+func checklistTitles(items []models.ChecklistItem) string {
+    if len(items) == 0 {
+        return "none"
+    }
+
+    titles := make([]string, len(items))
+    for i, item := range items {
+        titles[i] = item.Title
+    }
+    return strings.Join(titles, "; ")
+}
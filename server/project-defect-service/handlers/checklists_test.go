@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"project-defect-service/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func TestChecklistProgress(t *testing.T) {
+    items := func(done ...bool) []models.ChecklistItem {
+        result := make([]models.ChecklistItem, len(done))
+        for i, d := range done {
+            result[i].Done = d
+        }
+        return result
+    }
+
+    tests := []struct {
+        name  string
+        items []models.ChecklistItem
+        want  models.ChecklistProgress
+    }{
+        {"empty checklist", nil, models.ChecklistProgress{Total: 0, Done: 0, Percent: 0}},
+        {"nothing done", items(false, false), models.ChecklistProgress{Total: 2, Done: 0, Percent: 0}},
+        {"half done", items(true, false), models.ChecklistProgress{Total: 2, Done: 1, Percent: 50}},
+        {"percent rounds down", items(true, true, false), models.ChecklistProgress{Total: 3, Done: 2, Percent: 66}},
+        {"all done", items(true, true, true), models.ChecklistProgress{Total: 3, Done: 3, Percent: 100}},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := checklistProgress(tt.items); *got != tt.want {
+                t.Errorf("checklistProgress() = %+v, want %+v", *got, tt.want)
+            }
+        })
+    }
+}
+
+// seedChecklistDefect - дефект в работе с выполненным и невыполненным пунктами чек-листа
+func seedChecklistDefect(t *testing.T, db *gorm.DB, requireChecklist bool) (*models.Defect, *models.ChecklistItem) {
+    t.Helper()
+    project := seedProject(t, db, "TWR")
+    if err := db.Model(project).Update("require_checklist_for_review", requireChecklist).Error; err != nil {
+        t.Fatal(err)
+    }
+    defect := seedDefect(t, db, project, models.StatusInProgress)
+    items := []models.ChecklistItem{
+        {DefectID: defect.ID, Title: "Remove plaster", Position: 1, Done: true},
+        {DefectID: defect.ID, Title: "Seal crack", Position: 2},
+    }
+    if err := db.Create(&items).Error; err != nil {
+        t.Fatal(err)
+    }
+    return defect, &items[1]
+}
+
+func checklistGateRouter(db *gorm.DB) *gin.Engine {
+    r := testRouter(1, "admin")
+    defects := NewDefectHandler(db, "", "", "")
+    checklist := NewChecklistHandler(db, "", "")
+    r.PUT("/api/defects/:id", defects.UpdateDefect)
+    r.PATCH("/api/defects/:id/status", defects.UpdateDefectStatus)
+    r.PUT("/api/defects/:id/checklist/:item_id", checklist.UpdateChecklistItem)
+    return r
+}
+
+func TestReviewRequiresCompletedChecklist(t *testing.T) {
+    db := testDB(t)
+    defect, pending := seedChecklistDefect(t, db, true)
+    r := checklistGateRouter(db)
+    statusPath := fmt.Sprintf("/api/defects/%d/status", defect.ID)
+
+    w := doJSON(t, r, http.MethodPatch, statusPath, gin.H{"status": "on_review"}, ifMatch(defect.Version)...)
+    if w.Code != http.StatusConflict {
+        t.Fatalf("review with pending item: status %d, want %d; body %s", w.Code, http.StatusConflict, w.Body.String())
+    }
+    w = doJSON(t, r, http.MethodPut, fmt.Sprintf("/api/defects/%d", defect.ID), gin.H{"status": "on_review"}, ifMatch(defect.Version)...)
+    if w.Code != http.StatusConflict {
+        t.Fatalf("review through update with pending item: status %d, want %d", w.Code, http.StatusConflict)
+    }
+    var stored models.Defect
+    if err := db.First(&stored, defect.ID).Error; err != nil {
+        t.Fatal(err)
+    }
+    if stored.Status != models.StatusInProgress {
+        t.Fatalf("status after rejected review = %s, want %s", stored.Status, models.StatusInProgress)
+    }
+
+    w = doJSON(t, r, http.MethodPut, fmt.Sprintf("/api/defects/%d/checklist/%d", defect.ID, pending.ID), gin.H{"done": true})
+    if w.Code != http.StatusOK {
+        t.Fatalf("complete checklist item: status %d, body %s", w.Code, w.Body.String())
+    }
+
+    w = doJSON(t, r, http.MethodPatch, statusPath, gin.H{"status": "on_review"}, ifMatch(stored.Version)...)
+    if w.Code != http.StatusOK {
+        t.Fatalf("review with completed checklist: status %d, body %s", w.Code, w.Body.String())
+    }
+    if reviewed := decodeDefect(t, w); reviewed.Status != models.StatusOnReview {
+        t.Errorf("status = %s, want %s", reviewed.Status, models.StatusOnReview)
+    }
+}
+
+func TestReviewIgnoresChecklistWhenNotRequired(t *testing.T) {
+    db := testDB(t)
+    defect, _ := seedChecklistDefect(t, db, false)
+    r := checklistGateRouter(db)
+
+    w := doJSON(t, r, http.MethodPatch, fmt.Sprintf("/api/defects/%d/status", defect.ID), gin.H{"status": "on_review"}, ifMatch(defect.Version)...)
+    if w.Code != http.StatusOK {
+        t.Fatalf("review without checklist requirement: status %d, body %s", w.Code, w.Body.String())
+    }
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// errVersionConflict - запись изменили после того, как клиент ее прочитал
+var errVersionConflict = errors.New("version conflict")
+
+// isUniqueViolation - запись нарушает уникальный индекс: ее параллельно создал
+// другой запрос, поэтому проверка перед сохранением ее не увидела
+func isUniqueViolation(err error) bool {
+    var pgErr *pgconn.PgError
+    return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// Служебные колонки не сравниваются при поиске изменений
+var unversionedColumns = map[string]bool{
+    "id":         true,
+    "created_at": true,
+    "updated_at": true,
+    "deleted_at": true,
+    "version":    true,
+}
+
+// setETag отдает версию записи в заголовке ETag
+func setETag(c *gin.Context, version uint) {
+    c.Header("ETag", strconv.Quote(strconv.FormatUint(uint64(version), 10)))
+}
+
+// checkIfMatch требует заголовок If-Match с текущей версией записи.
+// Без заголовка отвечает 428, при несовпадении - 412 с текущим состоянием.
+func (h *Handler) checkIfMatch(c *gin.Context, version uint, current func() gin.H) bool {
+    header := c.GetHeader("If-Match")
+    if header == "" {
+        h.error(c, http.StatusPreconditionRequired, "If-Match header is required")
+        return false
+    }
+
+    for _, tag := range strings.Split(header, ",") {
+        tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+        if tag == "*" {
+            return true
+        }
+        if value, err := strconv.Unquote(tag); err == nil {
+            tag = value
+        }
+        if tag == strconv.FormatUint(uint64(version), 10) {
+            return true
+        }
+    }
+
+    h.respondVersionConflict(c, version, current())
+    return false
+}
+
+// respondVersionConflict - ответ 412 с текущим состоянием записи и ее ETag
+func (h *Handler) respondVersionConflict(c *gin.Context, version uint, current gin.H) {
+    setETag(c, version)
+    c.JSON(http.StatusPreconditionFailed, Response{
+        Success: false,
+        Error:   "Record was modified by another user",
+        Data:    current,
+    })
+}
+
+// saveVersioned записывает только измененные относительно before колонки и
+// увеличивает версию. Запись обновляется, только если ее версия не изменилась
+// с момента чтения, иначе возвращается errVersionConflict.
+func saveVersioned(tx *gorm.DB, before, after interface{}, version *uint) error {
+    stmt := &gorm.Statement{DB: tx}
+    if err := stmt.Parse(after); err != nil {
+        return err
+    }
+
+    ctx := context.Background()
+    beforeValue := reflect.Indirect(reflect.ValueOf(before))
+    afterValue := reflect.Indirect(reflect.ValueOf(after))
+
+    var columns []string
+    for _, field := range stmt.Schema.Fields {
+        if field.DBName == "" || unversionedColumns[field.DBName] {
+            continue
+        }
+        oldValue, _ := field.ValueOf(ctx, beforeValue)
+        newValue, _ := field.ValueOf(ctx, afterValue)
+        if !reflect.DeepEqual(oldValue, newValue) {
+            columns = append(columns, field.DBName)
+        }
+    }
+    if len(columns) == 0 {
+        return nil
+    }
+
+    oldVersion := *version
+    *version = oldVersion + 1
+    result := tx.Model(after).
+        Where("version = ?", oldVersion).
+        Select(append(columns, "version", "updated_at")).
+        Updates(after)
+    if result.Error != nil {
+        *version = oldVersion
+        return result.Error
+    }
+    if result.RowsAffected == 0 {
+        *version = oldVersion
+        return errVersionConflict
+    }
+    return nil
+}
@@ -0,0 +1,455 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"project-defect-service/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ContractorHandler struct {
+    Handler
+}
+
+func NewContractorHandler(db *gorm.DB, jwtSecret, authServiceURL string) *ContractorHandler {
+    return &ContractorHandler{
+        Handler: *NewHandler(db, jwtSecret, authServiceURL),
+    }
+}
+
+func (h *ContractorHandler) GetContractors(c *gin.Context) {
+    query := h.DB.Model(&models.Contractor{})
+    if search := c.Query("search"); search != "" {
+        query = query.Where("name ILIKE ?", "%"+escapeLike(search)+"%")
+    }
+
+    var contractors []models.Contractor
+    if err := query.Order("name").Find(&contractors).Error; err != nil {
+        h.internalError(c, "Failed to fetch contractors")
+        return
+    }
+
+    h.success(c, gin.H{
+        "contractors": contractors,
+    }, "Contractors retrieved successfully")
+}
+
+func (h *ContractorHandler) GetContractor(c *gin.Context) {
+    var contractor models.Contractor
+    if err := h.DB.Preload("Members").First(&contractor, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Contractor not found")
+        return
+    }
+
+    h.success(c, gin.H{
+        "contractor": contractor,
+    }, "Contractor retrieved successfully")
+}
+
+func (h *ContractorHandler) CreateContractor(c *gin.Context) {
+    if !h.requireManagerRole(c) {
+        return
+    }
+
+    var req models.ContractorRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    contractor := models.Contractor{
+        Name:         req.Name,
+        TaxID:        req.TaxID,
+        ContactEmail: req.ContactEmail,
+        ContactPhone: req.ContactPhone,
+    }
+    if err := h.DB.Create(&contractor).Error; err != nil {
+        h.internalError(c, "Failed to create contractor")
+        return
+    }
+
+    h.success(c, gin.H{
+        "contractor": contractor,
+    }, "Contractor created successfully")
+}
+
+func (h *ContractorHandler) UpdateContractor(c *gin.Context) {
+    if !h.requireManagerRole(c) {
+        return
+    }
+
+    var contractor models.Contractor
+    if err := h.DB.First(&contractor, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Contractor not found")
+        return
+    }
+
+    var req models.ContractorRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    contractor.Name = req.Name
+    contractor.TaxID = req.TaxID
+    contractor.ContactEmail = req.ContactEmail
+    contractor.ContactPhone = req.ContactPhone
+    if err := h.DB.Save(&contractor).Error; err != nil {
+        h.internalError(c, "Failed to update contractor")
+        return
+    }
+
+    h.success(c, gin.H{
+        "contractor": contractor,
+    }, "Contractor updated successfully")
+}
+
+func (h *ContractorHandler) DeleteContractor(c *gin.Context) {
+    if !h.requireManagerRole(c) {
+        return
+    }
+
+    var contractor models.Contractor
+    if err := h.DB.First(&contractor, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Contractor not found")
+        return
+    }
+
+    // Подрядчик с договорами на проектах остается: на него ссылаются дефекты
+    var contracts int64
+    h.DB.Model(&models.ProjectContractor{}).Where("contractor_id = ?", contractor.ID).Count(&contracts)
+    if contracts > 0 {
+        h.badRequest(c, "Cannot delete contractor linked to projects")
+        return
+    }
+    var charges int64
+    h.DB.Model(&models.BackCharge{}).Where("contractor_id = ?", contractor.ID).Count(&charges)
+    if charges > 0 {
+        h.badRequest(c, "Cannot delete contractor with back-charges")
+        return
+    }
+
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Unscoped().Where("contractor_id = ?", contractor.ID).Delete(&models.ContractorMember{}).Error; err != nil {
+            return err
+        }
+        return tx.Delete(&contractor).Error
+    })
+    if err != nil {
+        h.internalError(c, "Failed to delete contractor")
+        return
+    }
+
+    h.success(c, nil, "Contractor deleted successfully")
+}
+
+// AddContractorMember - сотрудник подрядчика; пользователь должен иметь роль contractor
+func (h *ContractorHandler) AddContractorMember(c *gin.Context) {
+    if !h.requireManagerRole(c) {
+        return
+    }
+
+    var contractor models.Contractor
+    if err := h.DB.First(&contractor, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Contractor not found")
+        return
+    }
+
+    var req models.ContractorMemberRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    users, err := h.fetchUsers(c)
+    if err != nil {
+        h.internalError(c, "Failed to check user: "+err.Error())
+        return
+    }
+    user, ok := users[req.UserID]
+    if !ok {
+        h.badRequest(c, "User not found")
+        return
+    }
+    if user.RoleName != models.RoleContractor {
+        h.badRequest(c, "Only users with the contractor role can be contractor members")
+        return
+    }
+
+    var existing models.ContractorMember
+    if err := h.DB.Where("user_id = ?", req.UserID).First(&existing).Error; err == nil {
+        if existing.ContractorID == contractor.ID {
+            h.success(c, gin.H{
+                "member": existing,
+            }, "Contractor member added successfully")
+            return
+        }
+        h.error(c, http.StatusConflict, "User already belongs to another contractor")
+        return
+    }
+
+    member := models.ContractorMember{ContractorID: contractor.ID, UserID: req.UserID}
+    if err := h.DB.Create(&member).Error; err != nil {
+        h.internalError(c, "Failed to add contractor member")
+        return
+    }
+
+    h.success(c, gin.H{
+        "member": member,
+    }, "Contractor member added successfully")
+}
+
+func (h *ContractorHandler) RemoveContractorMember(c *gin.Context) {
+    if !h.requireManagerRole(c) {
+        return
+    }
+
+    if err := h.DB.Unscoped().
+        Where("contractor_id = ? AND user_id = ?", c.Param("id"), c.Param("user_id")).
+        Delete(&models.ContractorMember{}).Error; err != nil {
+        h.internalError(c, "Failed to remove contractor member")
+        return
+    }
+
+    h.success(c, nil, "Contractor member removed successfully")
+}
+
+// GetProjectContractors - договоры подрядчиков на проекте с объемом работ
+func (h *ContractorHandler) GetProjectContractors(c *gin.Context) {
+    var contracts []models.ProjectContractor
+    if err := h.DB.Preload("Contractor").Preload("Scope").
+        Where("project_id = ?", c.Param("id")).
+        Order("id").
+        Find(&contracts).Error; err != nil {
+        h.internalError(c, "Failed to fetch project contractors")
+        return
+    }
+
+    h.success(c, gin.H{
+        "contractors": contracts,
+    }, "Project contractors retrieved successfully")
+}
+
+// SetProjectContractor - договор подрядчика на проекте; category_ids задают объем работ
+func (h *ContractorHandler) SetProjectContractor(c *gin.Context) {
+    var project models.Project
+    if err := h.DB.First(&project, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+
+    if !h.canManageProject(c, &project) {
+        return
+    }
+    if !project.Level.HoldsDefects() {
+        h.badRequest(c, "Contractors can only be linked to projects, not portfolios or programs")
+        return
+    }
+
+    var contractor models.Contractor
+    if err := h.DB.First(&contractor, c.Param("contractor_id")).Error; err != nil {
+        h.notFound(c, "Contractor not found")
+        return
+    }
+
+    var req models.ProjectContractorRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    scope := make([]models.Category, 0, len(req.CategoryIDs))
+    for _, categoryID := range req.CategoryIDs {
+        if err := h.validateDefectCategory(project.ID, uintPtr(categoryID)); err != nil {
+            h.badRequest(c, err.Error())
+            return
+        }
+        scope = append(scope, models.Category{BaseModel: models.BaseModel{ID: categoryID}})
+    }
+
+    var contract models.ProjectContractor
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.
+            Where(models.ProjectContractor{ProjectID: project.ID, ContractorID: contractor.ID}).
+            Assign(models.ProjectContractor{ContractNumber: req.ContractNumber}).
+            FirstOrCreate(&contract).Error; err != nil {
+            return err
+        }
+        return tx.Model(&contract).Omit("Scope.*").Association("Scope").Replace(scope)
+    })
+    if err != nil {
+        h.internalError(c, "Failed to set project contractor")
+        return
+    }
+
+    h.DB.Preload("Contractor").Preload("Scope").First(&contract, contract.ID)
+    h.success(c, gin.H{
+        "contractor": contract,
+    }, "Project contractor set successfully")
+}
+
+func (h *ContractorHandler) DeleteProjectContractor(c *gin.Context) {
+    var project models.Project
+    if err := h.DB.First(&project, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+
+    if !h.canManageProject(c, &project) {
+        return
+    }
+
+    // Открытые дефекты сначала передаются другому подрядчику
+    var openDefects int64
+    h.DB.Model(&models.Defect{}).
+        Where("project_id = ? AND contractor_id = ? AND status IN ?", project.ID, c.Param("contractor_id"),
+            []models.DefectStatus{models.StatusNew, models.StatusInProgress, models.StatusOnReview}).
+        Count(&openDefects)
+    if openDefects > 0 {
+        h.badRequest(c, "Cannot remove contractor with open defects in this project")
+        return
+    }
+
+    var contract models.ProjectContractor
+    if err := h.DB.Where("project_id = ? AND contractor_id = ?", project.ID, c.Param("contractor_id")).
+        First(&contract).Error; err != nil {
+        h.notFound(c, "Contractor is not linked to this project")
+        return
+    }
+
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Model(&contract).Association("Scope").Clear(); err != nil {
+            return err
+        }
+        return tx.Unscoped().Delete(&contract).Error
+    })
+    if err != nil {
+        h.internalError(c, "Failed to remove project contractor")
+        return
+    }
+
+    h.success(c, nil, "Project contractor removed successfully")
+}
+
+// GetContractorRanking - подрядчики по числу открытых и просроченных дефектов.
+// project_id ограничивает рейтинг подрядчиками проекта, sort_by=open|overdue
+func (h *ContractorHandler) GetContractorRanking(c *gin.Context) {
+    sortBy := c.DefaultQuery("sort_by", "overdue")
+    orders := map[string]string{
+        "overdue": "overdue_defects DESC, open_defects DESC, contractors.name",
+        "open":    "open_defects DESC, overdue_defects DESC, contractors.name",
+    }
+    order, ok := orders[sortBy]
+    if !ok {
+        h.badRequest(c, "sort_by must be open or overdue")
+        return
+    }
+
+    open := []models.DefectStatus{models.StatusNew, models.StatusInProgress, models.StatusOnReview}
+    defectJoin := "LEFT JOIN defects ON defects.contractor_id = contractors.id AND defects.deleted_at IS NULL"
+    query := h.DB.Model(&models.Contractor{})
+    if projectID := c.Query("project_id"); projectID != "" {
+        id, err := strconv.ParseUint(projectID, 10, 32)
+        if err != nil {
+            h.badRequest(c, "Invalid project ID")
+            return
+        }
+        query = query.
+            Joins(defectJoin+" AND defects.project_id = ?", id).
+            Where("contractors.id IN (?)", h.DB.Model(&models.ProjectContractor{}).Select("contractor_id").Where("project_id = ?", id))
+    } else {
+        query = query.Joins(defectJoin)
+    }
+
+    ranking := []models.ContractorRanking{}
+    if err := query.
+        Select(`contractors.id AS contractor_id, contractors.name,
+            COUNT(defects.id) FILTER (WHERE defects.status IN ?) AS open_defects,
+            COUNT(defects.id) FILTER (WHERE defects.status IN ? AND `+models.DefectDueAtSQL+` <= ?) AS overdue_defects,
+            COUNT(defects.id) FILTER (WHERE defects.status = ?) AS closed_defects`,
+            open, open, time.Now().UTC(), models.StatusClosed).
+        Group("contractors.id, contractors.name").
+        Order(order).
+        Scan(&ranking).Error; err != nil {
+        h.internalError(c, "Failed to rank contractors")
+        return
+    }
+
+    h.success(c, gin.H{
+        "ranking": ranking,
+    }, "Contractor ranking retrieved successfully")
+}
+
+// requireManagerRole - справочник подрядчиков ведут пользователи с ролью manager
+func (h *Handler) requireManagerRole(c *gin.Context) bool {
+    _, userRole, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return false
+    }
+    if userRole != "manager" {
+        h.error(c, http.StatusForbidden, "Only managers can manage contractors")
+        return false
+    }
+    return true
+}
+
+// scopeDefects - сотрудники подрядчиков видят только дефекты своей организации
+func (h *Handler) scopeDefects(c *gin.Context, db *gorm.DB) *gorm.DB {
+    userID, userRole, err := h.GetUserFromContext(c)
+    if err != nil {
+        return db
+    }
+    return h.scopeDefectsFor(db, userID, userRole)
+}
+
+// scopeDefectsFor - то же ограничение для пользователя, переданного другим сервисом
+func (h *Handler) scopeDefectsFor(db *gorm.DB, userID uint, userRole string) *gorm.DB {
+    if userRole != models.RoleContractor {
+        return db
+    }
+    return db.Where("defects.contractor_id IN (?)",
+        h.DB.Model(&models.ContractorMember{}).Select("contractor_id").Where("user_id = ?", userID))
+}
+
+// validateDefectContractor проверяет, что у подрядчика есть договор на проекте,
+// категория дефекта входит в объем работ, а исполнитель - его сотрудник
+func (h *Handler) validateDefectContractor(projectID uint, contractorID, categoryID, assigneeID *uint) error {
+    if contractorID == nil || *contractorID == 0 {
+        return nil
+    }
+
+    var contract models.ProjectContractor
+    if err := h.DB.Where("project_id = ? AND contractor_id = ?", projectID, *contractorID).First(&contract).Error; err != nil {
+        return fmt.Errorf("contractor is not linked to this project")
+    }
+
+    if categoryID != nil && *categoryID != 0 {
+        var scopeSize int64
+        h.DB.Table("project_contractor_categories").Where("project_contractor_id = ?", contract.ID).Count(&scopeSize)
+        if scopeSize > 0 {
+            // Подкатегории входят в объем работ вместе с видом работ или категорией
+            var inScope int64
+            h.DB.Table("project_contractor_categories AS pcc").
+                Joins("JOIN categories AS scope ON scope.id = pcc.category_id").
+                Joins("JOIN categories AS cat ON cat.path LIKE scope.path || '%'").
+                Where("pcc.project_contractor_id = ? AND cat.id = ?", contract.ID, *categoryID).
+                Count(&inScope)
+            if inScope == 0 {
+                return fmt.Errorf("category is outside the contractor's scope of work")
+            }
+        }
+    }
+
+    if assigneeID != nil && *assigneeID != 0 {
+        var members int64
+        h.DB.Model(&models.ContractorMember{}).
+            Where("contractor_id = ? AND user_id = ?", *contractorID, *assigneeID).
+            Count(&members)
+        if members == 0 {
+            return fmt.Errorf("assignee is not a member of the contractor")
+        }
+    }
+    return nil
+}
@@ -0,0 +1,400 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"project-defect-service/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type BackChargeHandler struct {
+    Handler
+}
+
+func NewBackChargeHandler(db *gorm.DB, jwtSecret, authServiceURL string) *BackChargeHandler {
+    return &BackChargeHandler{
+        Handler: *NewHandler(db, jwtSecret, authServiceURL),
+    }
+}
+
+// GetBackCharges - затраты, выставленные по дефекту. Сотрудники подрядчика
+// видят только выставленные их организации
+func (h *BackChargeHandler) GetBackCharges(c *gin.Context) {
+    defect, _, err := h.findDefect(h.scopeDefects(c, h.DB), c.Param("id"))
+    if err != nil {
+        h.notFound(c, "Defect not found")
+        return
+    }
+
+    query := h.DB.Preload("Contractor").Where("defect_id = ?", defect.ID)
+    if userID, userRole, _ := h.GetUserFromContext(c); userRole == models.RoleContractor {
+        query = query.Where("contractor_id IN (?)",
+            h.DB.Model(&models.ContractorMember{}).Select("contractor_id").Where("user_id = ?", userID))
+    }
+
+    charges := []models.BackCharge{}
+    if err := query.Order("id").Find(&charges).Error; err != nil {
+        h.internalError(c, "Failed to fetch back-charges")
+        return
+    }
+
+    h.success(c, gin.H{
+        "back_charges": charges,
+    }, "Back-charges retrieved successfully")
+}
+
+// CreateBackCharge - выставление затрат ответственному подрядчику менеджером проекта
+func (h *BackChargeHandler) CreateBackCharge(c *gin.Context) {
+    defect, ok := h.findWritableDefect(c, h.DB)
+    if !ok {
+        return
+    }
+
+    userID, userRole, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return
+    }
+    var project models.Project
+    if err := h.DB.First(&project, defect.ProjectID).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+    if !h.managesProject(userID, userRole, &project) {
+        h.error(c, http.StatusForbidden, "Only project managers can create back-charges")
+        return
+    }
+
+    var req models.BackChargeRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    charge := models.BackCharge{
+        DefectID:  defect.ID,
+        Reason:    req.Reason,
+        Status:    models.BackChargePending,
+        CreatedBy: userID,
+    }
+    switch {
+    case req.ContractorID != nil && *req.ContractorID != 0:
+        charge.ContractorID = *req.ContractorID
+    case defect.ContractorID != nil:
+        charge.ContractorID = *defect.ContractorID
+    default:
+        h.badRequest(c, "contractor_id is required for defects without a contractor")
+        return
+    }
+    var contracts int64
+    h.DB.Model(&models.ProjectContractor{}).
+        Where("project_id = ? AND contractor_id = ?", defect.ProjectID, charge.ContractorID).
+        Count(&contracts)
+    if contracts == 0 {
+        h.badRequest(c, "Contractor has no contract on this project")
+        return
+    }
+
+    switch {
+    case req.Amount != nil:
+        charge.Amount = *req.Amount
+        charge.Currency = costCurrency(req.Currency, req.Amount)
+    case defect.ActualCost != nil && *defect.ActualCost > 0:
+        if req.Currency != "" && req.Currency != defect.Currency {
+            h.badRequest(c, "currency must match the defect cost currency when amount is omitted")
+            return
+        }
+        charge.Amount = *defect.ActualCost
+        charge.Currency = defect.Currency
+    default:
+        h.badRequest(c, "amount is required when the defect has no actual cost")
+        return
+    }
+
+    err = h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Create(&charge).Error; err != nil {
+            return err
+        }
+        return recordDefectChange(tx, defect.ID, userID, "back_charge", "none", formatBackCharge(&charge))
+    })
+    if err != nil {
+        h.internalError(c, "Failed to create back-charge")
+        return
+    }
+
+    h.success(c, gin.H{
+        "back_charge": charge,
+    }, "Back-charge created successfully")
+}
+
+// DecideBackCharge - согласование или отклонение затрат менеджером; решение окончательное
+func (h *BackChargeHandler) DecideBackCharge(c *gin.Context) {
+    userID, userRole, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return
+    }
+    if userRole != "manager" {
+        h.error(c, http.StatusForbidden, "Only managers can approve back-charges")
+        return
+    }
+
+    var charge models.BackCharge
+    if err := h.DB.First(&charge, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Back-charge not found")
+        return
+    }
+
+    var req models.BackChargeDecisionRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    if charge.Status != models.BackChargePending {
+        h.error(c, http.StatusConflict, fmt.Sprintf("Back-charge is already %s", charge.Status))
+        return
+    }
+    var defect models.Defect
+    if err := h.DB.First(&defect, charge.DefectID).Error; err != nil {
+        h.notFound(c, "Defect not found")
+        return
+    }
+    if !h.checkProjectWritable(c, defect.ProjectID) {
+        return
+    }
+
+    old := formatBackCharge(&charge)
+    now := time.Now().UTC()
+    charge.Status = req.Status
+    charge.DecidedBy = &userID
+    charge.DecidedAt = &now
+    charge.DecisionNote = req.Note
+
+    err = h.DB.Transaction(func(tx *gorm.DB) error {
+        // Условие по статусу защищает от одновременного решения двумя менеджерами
+        result := tx.Model(&models.BackCharge{}).
+            Where("id = ? AND status = ?", charge.ID, models.BackChargePending).
+            Updates(map[string]interface{}{
+                "status":        charge.Status,
+                "decided_by":    charge.DecidedBy,
+                "decided_at":    charge.DecidedAt,
+                "decision_note": charge.DecisionNote,
+            })
+        if result.Error != nil {
+            return result.Error
+        }
+        if result.RowsAffected == 0 {
+            return errVersionConflict
+        }
+        return recordDefectChange(tx, defect.ID, userID, "back_charge", old, formatBackCharge(&charge))
+    })
+    if errors.Is(err, errVersionConflict) {
+        h.error(c, http.StatusConflict, "Back-charge has already been decided")
+        return
+    }
+    if err != nil {
+        h.internalError(c, "Failed to update back-charge")
+        return
+    }
+
+    h.success(c, gin.H{
+        "back_charge": charge,
+    }, "Back-charge updated successfully")
+}
+
+// DeleteBackCharge - отзыв несогласованных затрат менеджером проекта
+func (h *BackChargeHandler) DeleteBackCharge(c *gin.Context) {
+    var charge models.BackCharge
+    if err := h.DB.First(&charge, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Back-charge not found")
+        return
+    }
+
+    var defect models.Defect
+    if err := h.DB.First(&defect, charge.DefectID).Error; err != nil {
+        h.notFound(c, "Defect not found")
+        return
+    }
+    var project models.Project
+    if err := h.DB.First(&project, defect.ProjectID).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+    if !h.canManageProject(c, &project) {
+        return
+    }
+    if charge.Status != models.BackChargePending {
+        h.error(c, http.StatusConflict, "Only pending back-charges can be withdrawn")
+        return
+    }
+
+    userID, _, _ := h.GetUserFromContext(c)
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Delete(&charge).Error; err != nil {
+            return err
+        }
+        return recordDefectChange(tx, defect.ID, userID, "back_charge", formatBackCharge(&charge), "none")
+    })
+    if err != nil {
+        h.internalError(c, "Failed to delete back-charge")
+        return
+    }
+
+    h.success(c, nil, "Back-charge withdrawn successfully")
+}
+
+// GetCostRollup - стоимость устранения и выставленные затраты по проектам
+// (group_by=project) или ответственным подрядчикам (group_by=contractor), по валютам.
+// project_id ограничивает отчет проектом вместе с вложенными
+func (h *BackChargeHandler) GetCostRollup(c *gin.Context) {
+    type grouping struct{ defectKey, chargeKey, name string }
+    groups := map[string]grouping{
+        "project": {
+            defectKey: "defects.project_id",
+            chargeKey: "defects.project_id",
+            name:      "projects.name",
+        },
+        "contractor": {
+            defectKey: "defects.contractor_id",
+            chargeKey: "back_charges.contractor_id",
+            name:      "contractors.name",
+        },
+    }
+    groupBy := c.DefaultQuery("group_by", "project")
+    group, ok := groups[groupBy]
+    if !ok {
+        h.badRequest(c, "group_by must be project or contractor")
+        return
+    }
+
+    scope := func(query *gorm.DB) *gorm.DB {
+        return query.Joins("JOIN projects ON projects.id = defects.project_id")
+    }
+    if projectID := c.Query("project_id"); projectID != "" {
+        var project models.Project
+        if err := h.DB.First(&project, projectID).Error; err != nil {
+            h.notFound(c, "Project not found")
+            return
+        }
+        scope = func(query *gorm.DB) *gorm.DB {
+            return query.Joins("JOIN projects ON projects.id = defects.project_id").
+                Where("projects.path LIKE ?", project.Path+"%")
+        }
+    }
+
+    var costs []models.CostRollup
+    defectQuery := scope(h.DB.Model(&models.Defect{})).
+        Where("(defects.estimated_cost IS NOT NULL OR defects.actual_cost IS NOT NULL)")
+    if groupBy == "contractor" {
+        defectQuery = defectQuery.
+            Joins("JOIN contractors ON contractors.id = defects.contractor_id")
+    }
+    if err := defectQuery.
+        Select(group.defectKey + ` AS id, MAX(` + group.name + `) AS name, defects.currency,
+            COUNT(*) AS defects,
+            COALESCE(SUM(defects.estimated_cost), 0) AS estimated_cost,
+            COALESCE(SUM(defects.actual_cost), 0) AS actual_cost`).
+        Group(group.defectKey + ", defects.currency").
+        Scan(&costs).Error; err != nil {
+        h.internalError(c, "Failed to calculate repair costs")
+        return
+    }
+
+    var charges []models.CostRollup
+    if err := scope(h.DB.Model(&models.BackCharge{}).
+        Joins("JOIN defects ON defects.id = back_charges.defect_id AND defects.deleted_at IS NULL")).
+        Joins("JOIN contractors ON contractors.id = back_charges.contractor_id").
+        Select(group.chargeKey+` AS id, MAX(`+group.name+`) AS name, back_charges.currency,
+            COALESCE(SUM(back_charges.amount) FILTER (WHERE back_charges.status = ?), 0) AS back_charge_pending,
+            COALESCE(SUM(back_charges.amount) FILTER (WHERE back_charges.status = ?), 0) AS back_charge_approved,
+            COALESCE(SUM(back_charges.amount) FILTER (WHERE back_charges.status = ?), 0) AS back_charge_rejected`,
+            models.BackChargePending, models.BackChargeApproved, models.BackChargeRejected).
+        Group(group.chargeKey + ", back_charges.currency").
+        Scan(&charges).Error; err != nil {
+        h.internalError(c, "Failed to calculate back-charges")
+        return
+    }
+
+    // Строки двух запросов объединяются по группе и валюте
+    type rollupKey struct {
+        id       uint
+        currency string
+    }
+    rows := map[rollupKey]*models.CostRollup{}
+    for i := range costs {
+        rows[rollupKey{costs[i].ID, costs[i].Currency}] = &costs[i]
+    }
+    for _, charge := range charges {
+        key := rollupKey{charge.ID, charge.Currency}
+        row, ok := rows[key]
+        if !ok {
+            row = &models.CostRollup{ID: charge.ID, Name: charge.Name, Currency: charge.Currency}
+            rows[key] = row
+        }
+        row.BackChargePending = charge.BackChargePending
+        row.BackChargeApproved = charge.BackChargeApproved
+        row.BackChargeRejected = charge.BackChargeRejected
+    }
+
+    rollup := make([]models.CostRollup, 0, len(rows))
+    for _, row := range rows {
+        row.EstimatedCost = roundMoney(row.EstimatedCost)
+        row.ActualCost = roundMoney(row.ActualCost)
+        row.BackChargePending = roundMoney(row.BackChargePending)
+        row.BackChargeApproved = roundMoney(row.BackChargeApproved)
+        row.BackChargeRejected = roundMoney(row.BackChargeRejected)
+        rollup = append(rollup, *row)
+    }
+    sort.Slice(rollup, func(i, j int) bool {
+        if rollup[i].Name != rollup[j].Name {
+            return rollup[i].Name < rollup[j].Name
+        }
+        if rollup[i].ID != rollup[j].ID {
+            return rollup[i].ID < rollup[j].ID
+        }
+        return rollup[i].Currency < rollup[j].Currency
+    })
+
+    h.success(c, gin.H{
+        "group_by": groupBy,
+        "rollup":   rollup,
+    }, "Cost roll-up generated successfully")
+}
+
+// costCurrency - валюта стоимости: указанная, а если задана хотя бы одна сумма - по умолчанию
+func costCurrency(currency string, amounts ...*float64) string {
+    if currency != "" {
+        return currency
+    }
+    for _, amount := range amounts {
+        if amount != nil {
+            return models.DefaultCurrency
+        }
+    }
+    return ""
+}
+
+func formatCost(amount *float64) string {
+    if amount == nil {
+        return "none"
+    }
+    return fmt.Sprintf("%.2f", *amount)
+}
+
+func formatCurrency(currency string) string {
+    if currency == "" {
+        return "none"
+    }
+    return currency
+}
+
+// formatBackCharge - запись в истории дефекта: "#3 1500.00 RUB to contractor 2: pending"
+func formatBackCharge(charge *models.BackCharge) string {
+    return fmt.Sprintf("#%d %.2f %s to contractor %d: %s",
+        charge.ID, charge.Amount, charge.Currency, charge.ContractorID, charge.Status)
+}
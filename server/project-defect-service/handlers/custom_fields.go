@@ -0,0 +1,420 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"project-defect-service/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Ключ поля используется в API и фильтрах (cf.<key>=...), поэтому только латиница, цифры и _
+var customFieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+type CustomFieldHandler struct {
+    Handler
+}
+
+func NewCustomFieldHandler(db *gorm.DB, jwtSecret, authServiceURL string) *CustomFieldHandler {
+    return &CustomFieldHandler{
+        Handler: *NewHandler(db, jwtSecret, authServiceURL),
+    }
+}
+
+func (h *CustomFieldHandler) GetCustomFields(c *gin.Context) {
+    var fields []models.CustomField
+    if err := h.DB.Where("project_id = ?", c.Param("id")).Order("position, id").Find(&fields).Error; err != nil {
+        h.internalError(c, "Failed to fetch custom fields")
+        return
+    }
+
+    h.success(c, gin.H{
+        "custom_fields": fields,
+    }, "Custom fields retrieved successfully")
+}
+
+func (h *CustomFieldHandler) CreateCustomField(c *gin.Context) {
+    var project models.Project
+    if err := h.DB.First(&project, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+
+    if !h.canManageProject(c, &project) {
+        return
+    }
+
+    var req models.CustomFieldCreateRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    if !customFieldKeyPattern.MatchString(req.Key) {
+        h.badRequest(c, "Field key must contain only lowercase latin letters, digits and underscores")
+        return
+    }
+    if err := validateFieldOptions(req.Type, req.Options); err != nil {
+        h.badRequest(c, err.Error())
+        return
+    }
+
+    var existing int64
+    if err := h.DB.Model(&models.CustomField{}).Where("project_id = ? AND key = ?", project.ID, req.Key).Count(&existing).Error; err != nil {
+        h.internalError(c, "Failed to check custom field key")
+        return
+    }
+    if existing > 0 {
+        h.error(c, http.StatusConflict, "Field with this key already exists in the project")
+        return
+    }
+
+    field := models.CustomField{
+        ProjectID: project.ID,
+        Key:       req.Key,
+        Name:      req.Name,
+        Type:      req.Type,
+        Required:  req.Required,
+        Options:   req.Options,
+        Position:  req.Position,
+    }
+
+    if err := h.DB.Create(&field).Error; err != nil {
+        if isUniqueViolation(err) {
+            h.error(c, http.StatusConflict, "Field with this key already exists in the project")
+            return
+        }
+        h.internalError(c, "Failed to create custom field")
+        return
+    }
+
+    h.success(c, gin.H{
+        "custom_field": field,
+    }, "Custom field created successfully")
+}
+
+// UpdateCustomField - ключ и тип поля не меняются, чтобы не ломать сохраненные значения
+func (h *CustomFieldHandler) UpdateCustomField(c *gin.Context) {
+    field, ok := h.findManagedField(c)
+    if !ok {
+        return
+    }
+
+    var req models.CustomFieldUpdateRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    if req.Name != nil {
+        field.Name = *req.Name
+    }
+    if req.Required != nil {
+        field.Required = *req.Required
+    }
+    if req.Position != nil {
+        field.Position = *req.Position
+    }
+    if req.Options != nil {
+        if err := validateFieldOptions(field.Type, req.Options); err != nil {
+            h.badRequest(c, err.Error())
+            return
+        }
+        field.Options = req.Options
+    }
+
+    if err := h.DB.Save(field).Error; err != nil {
+        h.internalError(c, "Failed to update custom field")
+        return
+    }
+
+    h.success(c, gin.H{
+        "custom_field": field,
+    }, "Custom field updated successfully")
+}
+
+func (h *CustomFieldHandler) DeleteCustomField(c *gin.Context) {
+    field, ok := h.findManagedField(c)
+    if !ok {
+        return
+    }
+
+    // Поле удаляется окончательно: иначе удаленная запись держит уникальный ключ
+    // проекта и поле с тем же ключом нельзя создать заново
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Unscoped().Where("field_id = ?", field.ID).Delete(&models.CustomFieldValue{}).Error; err != nil {
+            return err
+        }
+        return tx.Unscoped().Delete(field).Error
+    })
+    if err != nil {
+        h.internalError(c, "Failed to delete custom field")
+        return
+    }
+
+    h.success(c, nil, "Custom field deleted successfully")
+}
+
+func (h *CustomFieldHandler) findManagedField(c *gin.Context) (*models.CustomField, bool) {
+    var field models.CustomField
+    if err := h.DB.First(&field, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Custom field not found")
+        return nil, false
+    }
+
+    var project models.Project
+    if err := h.DB.First(&project, field.ProjectID).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return nil, false
+    }
+
+    if !h.canManageProject(c, &project) {
+        return nil, false
+    }
+    return &field, true
+}
+
+func validateFieldOptions(fieldType models.CustomFieldType, options []string) error {
+    if !fieldType.HasOptions() {
+        if len(options) > 0 {
+            return fmt.Errorf("options are only allowed for select fields")
+        }
+        return nil
+    }
+    if len(options) == 0 {
+        return fmt.Errorf("select fields require at least one option")
+    }
+
+    seen := make(map[string]bool, len(options))
+    for _, option := range options {
+        if strings.TrimSpace(option) == "" || seen[option] {
+            return fmt.Errorf("options must be unique and non-empty")
+        }
+        seen[option] = true
+    }
+    return nil
+}
+
+// customFieldChange - изменение значения одного поля дефекта
+type customFieldChange struct {
+    Field    models.CustomField
+    OldValue *models.CustomFieldValue
+    NewValue *models.CustomFieldValue
+}
+
+// prepareCustomFieldChanges проверяет значения полей из запроса по определениям полей проекта.
+// При создании дефекта (creating) все обязательные поля должны быть заполнены.
+func (h *Handler) prepareCustomFieldChanges(projectID, defectID uint, raw map[string]interface{}, creating bool) ([]customFieldChange, error) {
+    var fields []models.CustomField
+    if err := h.DB.Where("project_id = ?", projectID).Find(&fields).Error; err != nil {
+        return nil, err
+    }
+
+    fieldByKey := make(map[string]models.CustomField, len(fields))
+    for _, field := range fields {
+        fieldByKey[field.Key] = field
+    }
+    for key := range raw {
+        if _, ok := fieldByKey[key]; !ok {
+            return nil, fmt.Errorf("unknown custom field %s", key)
+        }
+    }
+
+    existing := make(map[uint]*models.CustomFieldValue)
+    if !creating {
+        var values []models.CustomFieldValue
+        if err := h.DB.Where("defect_id = ?", defectID).Find(&values).Error; err != nil {
+            return nil, err
+        }
+        for i := range values {
+            existing[values[i].FieldID] = &values[i]
+        }
+    }
+
+    var changes []customFieldChange
+    for _, field := range fields {
+        rawValue, provided := raw[field.Key]
+        oldValue := existing[field.ID]
+
+        if !provided {
+            if creating && field.Required {
+                return nil, fmt.Errorf("custom field %s is required", field.Key)
+            }
+            continue
+        }
+
+        newValue, err := field.ParseValue(rawValue)
+        if err != nil {
+            return nil, err
+        }
+        if newValue == nil && field.Required {
+            return nil, fmt.Errorf("custom field %s is required", field.Key)
+        }
+        if oldValue.String() == newValue.String() {
+            continue
+        }
+
+        changes = append(changes, customFieldChange{Field: field, OldValue: oldValue, NewValue: newValue})
+    }
+    return changes, nil
+}
+
+// checkCustomFieldUsers проверяет значения полей типа user так же, как исполнителей:
+// пользователь существует в auth-service и не отключен. Пользователи запрашиваются,
+// только если в запросе есть такие значения. Ошибка с кодом 400 - недопустимый
+// пользователь, 500 - auth-service недоступен
+func (h *Handler) checkCustomFieldUsers(c *gin.Context, changes []customFieldChange) (int, error) {
+    var users map[uint]userInfo
+    for _, change := range changes {
+        if change.NewValue == nil || change.NewValue.ValueUser == nil {
+            continue
+        }
+        if users == nil {
+            var err error
+            if users, err = h.fetchUsers(c); err != nil {
+                return http.StatusInternalServerError, fmt.Errorf("failed to check custom field users: %w", err)
+            }
+        }
+        user, ok := users[*change.NewValue.ValueUser]
+        if !ok {
+            return http.StatusBadRequest, fmt.Errorf("custom field %s: user not found", change.Field.Key)
+        }
+        if !user.Active {
+            return http.StatusBadRequest, fmt.Errorf("custom field %s: user account is deactivated", change.Field.Key)
+        }
+    }
+    return http.StatusOK, nil
+}
+
+// saveCustomFieldChanges записывает значения полей в рамках транзакции сохранения дефекта
+func saveCustomFieldChanges(tx *gorm.DB, defectID uint, changes []customFieldChange) error {
+    for _, change := range changes {
+        if err := tx.Unscoped().
+            Where("defect_id = ? AND field_id = ?", defectID, change.Field.ID).
+            Delete(&models.CustomFieldValue{}).Error; err != nil {
+            return err
+        }
+        if change.NewValue == nil {
+            continue
+        }
+        change.NewValue.DefectID = defectID
+        if err := tx.Create(change.NewValue).Error; err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// attachCustomFields заполняет defect.CustomFields значениями полей одним запросом на список
+func (h *Handler) attachCustomFields(defects []models.Defect) error {
+    if len(defects) == 0 {
+        return nil
+    }
+
+    ids := make([]uint, len(defects))
+    for i, defect := range defects {
+        ids[i] = defect.ID
+    }
+
+    var rows []struct {
+        models.CustomFieldValue
+        Key string
+    }
+    if err := h.DB.Model(&models.CustomFieldValue{}).
+        Select("custom_field_values.*, custom_fields.key").
+        Joins("JOIN custom_fields ON custom_fields.id = custom_field_values.field_id AND custom_fields.deleted_at IS NULL").
+        Where("custom_field_values.defect_id IN ?", ids).
+        Scan(&rows).Error; err != nil {
+        return err
+    }
+
+    byDefect := make(map[uint]map[string]interface{})
+    for i := range rows {
+        row := &rows[i]
+        if byDefect[row.DefectID] == nil {
+            byDefect[row.DefectID] = make(map[string]interface{})
+        }
+        byDefect[row.DefectID][row.Key] = row.CustomFieldValue.Interface()
+    }
+
+    for i := range defects {
+        defects[i].CustomFields = byDefect[defects[i].ID]
+    }
+    return nil
+}
+
+// applyCustomFieldFilters - фильтры вида cf.<key>=<value>. Для текстовых полей
+// ищется подстрока, для множественного выбора - наличие варианта, для остальных -
+// точное совпадение.
+func (h *Handler) applyCustomFieldFilters(c *gin.Context, query *gorm.DB) (*gorm.DB, error) {
+    for param, values := range c.Request.URL.Query() {
+        key, ok := strings.CutPrefix(param, "cf.")
+        if !ok || len(values) == 0 {
+            continue
+        }
+        value := values[0]
+
+        fieldsQuery := h.DB.Where("key = ?", key)
+        if projectID := c.Query("project_id"); projectID != "" {
+            fieldsQuery = fieldsQuery.Where("project_id = ?", projectID)
+        }
+        var fields []models.CustomField
+        if err := fieldsQuery.Find(&fields).Error; err != nil {
+            return nil, err
+        }
+        if len(fields) == 0 {
+            return nil, fmt.Errorf("unknown custom field %s", key)
+        }
+
+        var conditions []string
+        var args []interface{}
+        for _, field := range fields {
+            condition, arg, err := customFieldCondition(field, value)
+            if err != nil {
+                return nil, err
+            }
+            conditions = append(conditions, "(v.field_id = ? AND "+condition+")")
+            args = append(args, field.ID, arg)
+        }
+
+        query = query.Where(
+            "EXISTS (SELECT 1 FROM custom_field_values v WHERE v.defect_id = defects.id AND v.deleted_at IS NULL AND ("+
+                strings.Join(conditions, " OR ")+"))",
+            args...,
+        )
+    }
+    return query, nil
+}
+
+func customFieldCondition(field models.CustomField, value string) (string, interface{}, error) {
+    switch field.Type {
+    case models.FieldText:
+        return "v.value_text ILIKE ?", "%" + value + "%", nil
+    case models.FieldSelect:
+        return "v.value_text = ?", value, nil
+    case models.FieldMultiSelect:
+        return "jsonb_exists(v.value_options, ?)", value, nil
+    case models.FieldNumber:
+        number, err := strconv.ParseFloat(value, 64)
+        if err != nil {
+            return "", nil, fmt.Errorf("custom field %s expects a number", field.Key)
+        }
+        return "v.value_number = ?", number, nil
+    case models.FieldDate:
+        if _, err := time.Parse("2006-01-02", value); err != nil {
+            return "", nil, fmt.Errorf("custom field %s expects a date in YYYY-MM-DD format", field.Key)
+        }
+        return "v.value_date = ?", value, nil
+    case models.FieldUser:
+        userID, err := strconv.ParseUint(value, 10, 32)
+        if err != nil {
+            return "", nil, fmt.Errorf("custom field %s expects a user ID", field.Key)
+        }
+        return "v.value_user = ?", userID, nil
+    }
+    return "", nil, fmt.Errorf("custom field %s has unsupported type %s", field.Key, field.Type)
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"project-defect-service/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// customFieldsFixture - проект с обязательным, числовым, списочным полем и полем-пользователем.
+// auth-service заменен сервером с активным пользователем 2 и отключенным 3
+func customFieldsFixture(t *testing.T) (*gorm.DB, *models.Project, *gin.Engine) {
+    t.Helper()
+    db := testDB(t)
+    project := seedProject(t, db, "TWR")
+
+    auth := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Type", "application/json")
+        fmt.Fprint(w, `{"success": true, "data": {"users": [
+            {"id": 2, "role_name": "engineer", "is_active": true},
+            {"id": 3, "role_name": "engineer", "is_active": false}
+        ]}}`)
+    }))
+    t.Cleanup(auth.Close)
+
+    r := testRouter(1, "manager")
+    defects := NewDefectHandler(db, "", auth.URL, "")
+    fields := NewCustomFieldHandler(db, "", auth.URL)
+    r.POST("/api/defects", defects.CreateDefect)
+    r.PUT("/api/defects/:id", defects.UpdateDefect)
+    r.POST("/api/projects/:id/custom-fields", fields.CreateCustomField)
+    r.DELETE("/api/custom-fields/:id", fields.DeleteCustomField)
+
+    for _, field := range []gin.H{
+        {"key": "supplier", "name": "Supplier", "type": "text", "required": true},
+        {"key": "area", "name": "Area", "type": "number"},
+        {"key": "material", "name": "Material", "type": "select", "options": []string{"brick", "concrete"}},
+        {"key": "inspector", "name": "Inspector", "type": "user"},
+    } {
+        w := doJSON(t, r, http.MethodPost, fmt.Sprintf("/api/projects/%d/custom-fields", project.ID), field)
+        if w.Code != http.StatusOK {
+            t.Fatalf("create field %v: status %d, body %s", field["key"], w.Code, w.Body.String())
+        }
+    }
+    return db, project, r
+}
+
+func TestCreateDefectValidatesCustomFields(t *testing.T) {
+    _, project, r := customFieldsFixture(t)
+
+    tests := []struct {
+        name   string
+        fields gin.H
+        want   int
+    }{
+        {"required field missing", gin.H{"area": 12.5}, http.StatusBadRequest},
+        {"required field empty", gin.H{"supplier": nil}, http.StatusBadRequest},
+        {"unknown field", gin.H{"supplier": "Stroymontazh", "color": "red"}, http.StatusBadRequest},
+        {"number as text", gin.H{"supplier": "Stroymontazh", "area": "large"}, http.StatusBadRequest},
+        {"unknown option", gin.H{"supplier": "Stroymontazh", "material": "wood"}, http.StatusBadRequest},
+        {"unknown user", gin.H{"supplier": "Stroymontazh", "inspector": 99}, http.StatusBadRequest},
+        {"deactivated user", gin.H{"supplier": "Stroymontazh", "inspector": 3}, http.StatusBadRequest},
+        {"valid", gin.H{"supplier": "Stroymontazh", "area": 12.5, "material": "brick", "inspector": 2}, http.StatusOK},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            w := doJSON(t, r, http.MethodPost, "/api/defects", gin.H{
+                "title":         "Crack",
+                "priority":      "medium",
+                "project_id":    project.ID,
+                "custom_fields": tt.fields,
+            })
+            if w.Code != tt.want {
+                t.Fatalf("status %d, want %d; body %s", w.Code, tt.want, w.Body.String())
+            }
+            if tt.want != http.StatusOK {
+                return
+            }
+            got := decodeDefect(t, w).CustomFields
+            if got["supplier"] != "Stroymontazh" || got["area"] != 12.5 || got["material"] != "brick" || got["inspector"] != float64(2) {
+                t.Errorf("custom_fields = %v", got)
+            }
+        })
+    }
+}
+
+func TestUpdateDefectValidatesCustomFields(t *testing.T) {
+    db, project, r := customFieldsFixture(t)
+
+    w := doJSON(t, r, http.MethodPost, "/api/defects", gin.H{
+        "title":         "Crack",
+        "priority":      "medium",
+        "project_id":    project.ID,
+        "custom_fields": gin.H{"supplier": "Stroymontazh", "material": "brick"},
+    })
+    if w.Code != http.StatusOK {
+        t.Fatalf("create defect: status %d, body %s", w.Code, w.Body.String())
+    }
+    defect := decodeDefect(t, w)
+    path := fmt.Sprintf("/api/defects/%d", defect.ID)
+
+    rejected := []struct {
+        name string
+        body gin.H
+    }{
+        {"required field cleared", gin.H{"custom_fields": gin.H{"supplier": nil}}},
+        {"unknown option", gin.H{"custom_fields": gin.H{"material": "wood"}}},
+        {"unknown field", gin.H{"custom_fields": gin.H{"color": "red"}}},
+        {"deactivated user", gin.H{"custom_fields": gin.H{"inspector": 3}}},
+    }
+    for _, tt := range rejected {
+        w := doJSON(t, r, http.MethodPut, path, tt.body, ifMatch(defect.Version)...)
+        if w.Code != http.StatusBadRequest {
+            t.Errorf("%s: status %d, want %d; body %s", tt.name, w.Code, http.StatusBadRequest, w.Body.String())
+        }
+    }
+
+    // Обязательное поле можно не передавать при правке: остается прежнее значение
+    w = doJSON(t, r, http.MethodPut, path, gin.H{"title": "Wide crack"}, ifMatch(defect.Version)...)
+    if w.Code != http.StatusOK {
+        t.Fatalf("update without custom fields: status %d, body %s", w.Code, w.Body.String())
+    }
+    defect = decodeDefect(t, w)
+    if defect.CustomFields["supplier"] != "Stroymontazh" || defect.CustomFields["material"] != "brick" {
+        t.Fatalf("custom_fields after rejected updates = %v", defect.CustomFields)
+    }
+
+    w = doJSON(t, r, http.MethodPut, path, gin.H{"custom_fields": gin.H{"material": "concrete"}}, ifMatch(defect.Version)...)
+    if w.Code != http.StatusOK {
+        t.Fatalf("update option: status %d, body %s", w.Code, w.Body.String())
+    }
+    if got := decodeDefect(t, w).CustomFields["material"]; got != "concrete" {
+        t.Errorf("material = %v, want concrete", got)
+    }
+
+    var history models.DefectHistory
+    if err := db.Where("defect_id = ? AND field = ?", defect.ID, "cf.material").First(&history).Error; err != nil {
+        t.Fatalf("custom field change is not in history: %v", err)
+    }
+    if history.OldValue != "brick" || history.NewValue != "concrete" {
+        t.Errorf("history cf.material = %q -> %q, want brick -> concrete", history.OldValue, history.NewValue)
+    }
+}
+
+func TestCustomFieldKeyIsFreedByDelete(t *testing.T) {
+    db, project, r := customFieldsFixture(t)
+    fieldsPath := fmt.Sprintf("/api/projects/%d/custom-fields", project.ID)
+    area := gin.H{"key": "area", "name": "Area", "type": "number"}
+
+    if w := doJSON(t, r, http.MethodPost, fieldsPath, area); w.Code != http.StatusConflict {
+        t.Fatalf("duplicate key: status %d, want %d", w.Code, http.StatusConflict)
+    }
+
+    var field models.CustomField
+    if err := db.Where("project_id = ? AND key = ?", project.ID, "area").First(&field).Error; err != nil {
+        t.Fatal(err)
+    }
+    if w := doJSON(t, r, http.MethodDelete, fmt.Sprintf("/api/custom-fields/%d", field.ID), nil); w.Code != http.StatusOK {
+        t.Fatalf("delete field: status %d, body %s", w.Code, w.Body.String())
+    }
+    if w := doJSON(t, r, http.MethodPost, fieldsPath, area); w.Code != http.StatusOK {
+        t.Fatalf("recreate deleted key: status %d, body %s", w.Code, w.Body.String())
+    }
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"project-defect-service/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Средний радиус Земли в метрах для расчета расстояний по формуле гаверсинусов
+const earthRadiusMeters = 6371000.0
+
+// applyDefectFilters применяет к запросу фильтры дефектов из query-параметров.
+// Используется списком дефектов, группировками и вложенными маршрутами.
+func (h *Handler) applyDefectFilters(c *gin.Context, query *gorm.DB) (*gorm.DB, error) {
+    // Фильтрация по проекту
+    if projectID := c.Query("project_id"); projectID != "" {
+        query = query.Where("defects.project_id = ?", projectID)
+    }
+
+    // Фильтрация по статусу
+    if status := c.Query("status"); status != "" {
+        query = query.Where("defects.status = ?", status)
+    }
+
+    // Фильтрация по приоритету
+    if priority := c.Query("priority"); priority != "" {
+        query = query.Where("defects.priority = ?", priority)
+    }
+
+    // Фильтрация по исполнителю
+    if assigneeID := c.Query("assignee_id"); assigneeID != "" {
+        query = query.Where("defects.assignee_id = ?", assigneeID)
+    }
+
+    // Фильтрация по подрядчику
+    if contractorID := c.Query("contractor_id"); contractorID != "" {
+        query = query.Where("defects.contractor_id = ?", contractorID)
+    }
+
+    // Фильтрация по SLA-состоянию: sla=ok|at_risk|breached|met|none
+    if state := c.Query("sla"); state != "" {
+        condition, args, err := slaCondition(state, time.Now().UTC())
+        if err != nil {
+            return nil, err
+        }
+        query = query.Where(condition, args...)
+    }
+
+    // Фильтрация по месту любого уровня: включаются все вложенные места
+    if locationID := c.Query("location_id"); locationID != "" {
+        id, err := strconv.ParseUint(locationID, 10, 32)
+        if err != nil {
+            return nil, fmt.Errorf("invalid location_id")
+        }
+        query = query.Where("defects.location_id IN (?)", h.subtreeIDs(&models.Location{}, uint(id)))
+    }
+
+    // Фильтрация по категории любого уровня: включаются все подкатегории
+    if categoryID := c.Query("category_id"); categoryID != "" {
+        id, err := strconv.ParseUint(categoryID, 10, 32)
+        if err != nil {
+            return nil, fmt.Errorf("invalid category_id")
+        }
+        query = query.Where("defects.category_id IN (?)", h.subtreeIDs(&models.Category{}, uint(id)))
+    }
+
+    // Фильтрация по меткам: labels=1,2&labels_match=any|all
+    if labels := c.Query("labels"); labels != "" {
+        var labelIDs []uint
+        for _, part := range strings.Split(labels, ",") {
+            id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 32)
+            if err != nil {
+                return nil, fmt.Errorf("invalid labels")
+            }
+            labelIDs = append(labelIDs, uint(id))
+        }
+        labelIDs = uniqueUints(labelIDs)
+
+        switch c.DefaultQuery("labels_match", "any") {
+        case "any":
+            query = query.Where("defects.id IN (?)",
+                h.DB.Table("defect_labels").Select("defect_id").Where("label_id IN ?", labelIDs))
+        case "all":
+            query = query.Where("defects.id IN (?)",
+                h.DB.Table("defect_labels").Select("defect_id").Where("label_id IN ?", labelIDs).
+                    Group("defect_id").Having("COUNT(DISTINCT label_id) = ?", len(labelIDs)))
+        default:
+            return nil, fmt.Errorf("labels_match must be any or all")
+        }
+    }
+
+    // Фильтрация по дополнительным полям: cf.<key>=<value>
+    query, err := h.applyCustomFieldFilters(c, query)
+    if err != nil {
+        return nil, err
+    }
+
+    // Прямоугольник карты: bbox=minLng,minLat,maxLng,maxLat
+    if bbox := c.Query("bbox"); bbox != "" {
+        coords, err := parseFloatList(bbox, 4)
+        if err != nil {
+            return nil, fmt.Errorf("invalid bbox: %w", err)
+        }
+        query = query.Where(
+            "defects.longitude BETWEEN ? AND ? AND defects.latitude BETWEEN ? AND ?",
+            coords[0], coords[2], coords[1], coords[3],
+        )
+    }
+
+    // Поиск в радиусе: near=lat,lng&radius=метры
+    if near := c.Query("near"); near != "" {
+        point, err := parseFloatList(near, 2)
+        if err != nil {
+            return nil, fmt.Errorf("invalid near: %w", err)
+        }
+        radius, err := strconv.ParseFloat(c.DefaultQuery("radius", "100"), 64)
+        if err != nil || radius <= 0 {
+            return nil, fmt.Errorf("invalid radius")
+        }
+        // LEAST отсекает погрешность округления выше 1 у почти противоположных точек,
+        // иначе ASIN падает с ошибкой области определения
+        query = query.Where(
+            `defects.latitude IS NOT NULL AND defects.longitude IS NOT NULL AND
+            2 * ? * ASIN(LEAST(1, SQRT(
+                POWER(SIN(RADIANS(defects.latitude - ?) / 2), 2) +
+                COS(RADIANS(?)) * COS(RADIANS(defects.latitude)) *
+                POWER(SIN(RADIANS(defects.longitude - ?) / 2), 2)
+            ))) <= ?`,
+            earthRadiusMeters, point[0], point[0], point[1], radius,
+        )
+    }
+
+    return query, nil
+}
+
+// defectOrder - ORDER BY по параметрам sort_by (любая колонка дефекта) и order
+func (h *Handler) defectOrder(c *gin.Context) (string, error) {
+    sortBy := c.DefaultQuery("sort_by", "created_at")
+    stmt := &gorm.Statement{DB: h.DB}
+    if err := stmt.Parse(&models.Defect{}); err != nil {
+        return "", err
+    }
+    if field := stmt.Schema.LookUpField(sortBy); field == nil || field.DBName != sortBy {
+        return "", fmt.Errorf("invalid sort_by %s", sortBy)
+    }
+
+    order := strings.ToLower(c.DefaultQuery("order", "desc"))
+    if order != "asc" && order != "desc" {
+        return "", fmt.Errorf("order must be asc or desc")
+    }
+    return "defects." + sortBy + " " + order, nil
+}
+
+// subtreeIDs - подзапрос ID элемента дерева (места, категории) и всех его потомков по Path
+func (h *Handler) subtreeIDs(model interface{}, id uint) *gorm.DB {
+    return h.DB.Model(model).
+        Select("id").
+        Where("path LIKE (?)", h.DB.Model(model).Select("path || '%'").Where("id = ?", id))
+}
+
+func parseFloatList(value string, count int) ([]float64, error) {
+    parts := strings.Split(value, ",")
+    if len(parts) != count {
+        return nil, fmt.Errorf("expected %d comma-separated numbers", count)
+    }
+
+    result := make([]float64, count)
+    for i, part := range parts {
+        v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+        if err != nil {
+            return nil, err
+        }
+        result[i] = v
+    }
+    return result, nil
+}
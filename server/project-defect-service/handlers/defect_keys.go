@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"project-defect-service/models"
+
+	"gorm.io/gorm"
+)
+
+// findDefect ищет дефект по числовому ID или по ключу вида TWR-57.
+// Для устаревшего ключа (дефект переносили в другой проект) возвращает
+// актуальный дефект и redirected = true.
+func (h *Handler) findDefect(db *gorm.DB, ref string) (*models.Defect, bool, error) {
+    var defect models.Defect
+
+    if id, err := strconv.ParseUint(ref, 10, 32); err == nil {
+        if err := db.First(&defect, uint(id)).Error; err != nil {
+            return nil, false, err
+        }
+        return &defect, false, nil
+    }
+
+    projectKey, number, ok := models.ParseDefectKey(ref)
+    if !ok {
+        return nil, false, gorm.ErrRecordNotFound
+    }
+    key := models.FormatDefectKey(projectKey, number)
+
+    err := db.Where("key = ?", key).First(&defect).Error
+    if err == nil {
+        return &defect, false, nil
+    }
+    if err != gorm.ErrRecordNotFound {
+        return nil, false, err
+    }
+
+    var redirect models.DefectKeyRedirect
+    if err := h.DB.Where("old_key = ?", key).First(&redirect).Error; err != nil {
+        return nil, false, err
+    }
+
+    if err := db.First(&defect, redirect.DefectID).Error; err != nil {
+        return nil, false, err
+    }
+    return &defect, true, nil
+}
+
+// allocateDefectNumber резервирует следующий порядковый номер дефекта в проекте.
+// Инкремент выполняется одним UPDATE ... RETURNING, поэтому строка проекта
+// остается заблокированной до конца транзакции и параллельные запросы
+// получают разные номера.
+func allocateDefectNumber(tx *gorm.DB, projectID uint) (uint, string, error) {
+    var row struct {
+        DefectSeq uint
+        Key       string
+    }
+
+    result := tx.Raw(
+        `UPDATE projects SET defect_seq = defect_seq + 1
+            WHERE id = ? AND deleted_at IS NULL
+            RETURNING defect_seq, "key"`,
+        projectID,
+    ).Scan(&row)
+    if result.Error != nil {
+        return 0, "", result.Error
+    }
+    if result.RowsAffected == 0 {
+        return 0, "", gorm.ErrRecordNotFound
+    }
+
+    return row.DefectSeq, models.FormatDefectKey(row.Key, row.DefectSeq), nil
+}
+
+// Ключи вида P<ID> назначаются проектам без ключа (createProject)
+var generatedProjectKeyPattern = regexp.MustCompile(`^P[0-9]+$`)
+
+// normalizeProjectKey приводит ключ проекта к верхнему регистру и проверяет формат.
+// Ключи вида P<число> зарезервированы за автоматическими, иначе ключ, выбранный
+// пользователем, занял бы ключ будущего проекта с таким ID
+func normalizeProjectKey(key string) (string, error) {
+    key = strings.ToUpper(strings.TrimSpace(key))
+    if !models.IsValidProjectKey(key) {
+        return "", fmt.Errorf("project key must be 2-10 latin letters or digits starting with a letter")
+    }
+    if generatedProjectKeyPattern.MatchString(key) {
+        return "", fmt.Errorf("project keys of the form P<number> are reserved for generated keys")
+    }
+    return key, nil
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"project-defect-service/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNormalizeProjectKey(t *testing.T) {
+    tests := []struct {
+        key     string
+        want    string
+        wantErr bool
+    }{
+        {"TWR", "TWR", false},
+        {" twr ", "TWR", false},
+        {"Bld2", "BLD2", false},
+        {"PX", "PX", false},
+        {"P12A", "P12A", false},
+        {"ABCDEFGHIJ", "ABCDEFGHIJ", false},
+        {"T", "", true},
+        {"ABCDEFGHIJK", "", true},
+        {"2TWR", "", true},
+        {"TW-R", "", true},
+        {"", "", true},
+        // P<число> зарезервированы за автоматическими ключами
+        {"P12", "", true},
+        {"p7", "", true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.key, func(t *testing.T) {
+            got, err := normalizeProjectKey(tt.key)
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("normalizeProjectKey(%q) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+            }
+            if got != tt.want {
+                t.Errorf("normalizeProjectKey(%q) = %q, want %q", tt.key, got, tt.want)
+            }
+        })
+    }
+}
+
+func defectKeysRouter(h *DefectHandler) *gin.Engine {
+    r := testRouter(1, "admin")
+    r.POST("/api/defects", h.CreateDefect)
+    r.GET("/api/defects/:id", h.GetDefect)
+    r.PUT("/api/defects/:id", h.UpdateDefect)
+    return r
+}
+
+func createTestDefect(t *testing.T, r *gin.Engine, projectID uint, title string) models.Defect {
+    t.Helper()
+    w := doJSON(t, r, http.MethodPost, "/api/defects", gin.H{
+        "title":      title,
+        "priority":   "medium",
+        "project_id": projectID,
+    })
+    if w.Code != http.StatusOK {
+        t.Fatalf("create defect: status %d, body %s", w.Code, w.Body.String())
+    }
+    return decodeDefect(t, w)
+}
+
+func TestCreateDefectNumbersPerProject(t *testing.T) {
+    db := testDB(t)
+    tower := seedProject(t, db, "TWR")
+    building := seedProject(t, db, "BLD")
+    r := defectKeysRouter(NewDefectHandler(db, "", "", ""))
+
+    steps := []struct {
+        projectID uint
+        wantKey   string
+    }{
+        {tower.ID, "TWR-1"},
+        {tower.ID, "TWR-2"},
+        {building.ID, "BLD-1"},
+        {tower.ID, "TWR-3"},
+    }
+    for _, step := range steps {
+        defect := createTestDefect(t, r, step.projectID, "Crack")
+        if defect.Key != step.wantKey {
+            t.Errorf("created defect key = %q, want %q", defect.Key, step.wantKey)
+        }
+    }
+}
+
+func TestCreateDefectConcurrentNumbersAreUnique(t *testing.T) {
+    db := testDB(t)
+    project := seedProject(t, db, "TWR")
+    r := defectKeysRouter(NewDefectHandler(db, "", "", ""))
+
+    const count = 10
+    var wg sync.WaitGroup
+    codes := make([]int, count)
+    for i := 0; i < count; i++ {
+        wg.Add(1)
+        go func(i int) {
+            defer wg.Done()
+            w := doJSON(t, r, http.MethodPost, "/api/defects", gin.H{
+                "title":      fmt.Sprintf("Defect %d", i),
+                "priority":   "medium",
+                "project_id": project.ID,
+            })
+            codes[i] = w.Code
+        }(i)
+    }
+    wg.Wait()
+    for i, code := range codes {
+        if code != http.StatusOK {
+            t.Fatalf("request %d: status %d", i, code)
+        }
+    }
+
+    var numbers []uint
+    if err := db.Model(&models.Defect{}).Where("project_id = ?", project.ID).Order("number").Pluck("number", &numbers).Error; err != nil {
+        t.Fatal(err)
+    }
+    for i, number := range numbers {
+        if number != uint(i+1) {
+            t.Fatalf("defect numbers = %v, want 1..%d without gaps or repeats", numbers, count)
+        }
+    }
+    if len(numbers) != count {
+        t.Fatalf("created %d defects, want %d", len(numbers), count)
+    }
+}
+
+func TestMovedDefectOldKeyRedirects(t *testing.T) {
+    db := testDB(t)
+    tower := seedProject(t, db, "TWR")
+    building := seedProject(t, db, "BLD")
+    r := defectKeysRouter(NewDefectHandler(db, "", "", ""))
+
+    createTestDefect(t, r, building.ID, "Leak")
+    defect := createTestDefect(t, r, tower.ID, "Crack")
+
+    w := doJSON(t, r, http.MethodPut, "/api/defects/TWR-1", gin.H{"project_id": building.ID}, ifMatch(defect.Version)...)
+    if w.Code != http.StatusOK {
+        t.Fatalf("move defect: status %d, body %s", w.Code, w.Body.String())
+    }
+    if moved := decodeDefect(t, w); moved.Key != "BLD-2" {
+        t.Fatalf("moved defect key = %q, want BLD-2", moved.Key)
+    }
+
+    w = doJSON(t, r, http.MethodGet, "/api/defects/twr-1?tab=history", nil)
+    if w.Code != http.StatusMovedPermanently {
+        t.Fatalf("old key: status %d, want %d", w.Code, http.StatusMovedPermanently)
+    }
+    if location := w.Header().Get("Location"); location != "/api/defects/BLD-2?tab=history" {
+        t.Errorf("old key Location = %q, want /api/defects/BLD-2?tab=history", location)
+    }
+
+    w = doJSON(t, r, http.MethodGet, "/api/defects/BLD-2", nil)
+    if w.Code != http.StatusOK {
+        t.Fatalf("new key: status %d, body %s", w.Code, w.Body.String())
+    }
+
+    // Номер перенесенного дефекта не выдается заново: старый ключ остается редиректом
+    if next := createTestDefect(t, r, tower.ID, "Gap"); next.Key != "TWR-2" {
+        t.Errorf("next defect key = %q, want TWR-2", next.Key)
+    }
+}
@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"project-defect-service/models"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -11,43 +13,48 @@ import (
 
 type DefectHandler struct {
     Handler
+    ContentServiceURL string
 }
 
-func NewDefectHandler(db *gorm.DB, jwtSecret, authServiceURL string) *DefectHandler {
+func NewDefectHandler(db *gorm.DB, jwtSecret, authServiceURL, contentServiceURL string) *DefectHandler {
     return &DefectHandler{
-        Handler: *NewHandler(db, jwtSecret, authServiceURL),
+        Handler:           *NewHandler(db, jwtSecret, authServiceURL),
+        ContentServiceURL: contentServiceURL,
     }
 }
 
 func (h *DefectHandler) GetDefects(c *gin.Context) {
-    var defects []models.Defect
-    
-    query := h.DB
-    
-    // Фильтрация по проекту
-    if projectID := c.Query("project_id"); projectID != "" {
-        query = query.Where("project_id = ?", projectID)
-    }
-    
-    // Фильтрация по статусу
-    if status := c.Query("status"); status != "" {
-        query = query.Where("status = ?", status)
+    h.listDefects(c, h.DB)
+}
+
+// GetProjectDefects - дефекты проекта с теми же фильтрами, сортировкой и пагинацией
+func (h *DefectHandler) GetProjectDefects(c *gin.Context) {
+    var project models.Project
+    if err := h.DB.First(&project, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
     }
     
-    // Фильтрация по приоритету
-    if priority := c.Query("priority"); priority != "" {
-        query = query.Where("priority = ?", priority)
-    }
+    h.listDefects(c, h.DB.Where("defects.project_id = ?", project.ID))
+}
+
+// listDefects - страница дефектов по фильтрам из query-параметров
+func (h *DefectHandler) listDefects(c *gin.Context, base *gorm.DB) {
+    var defects []models.Defect
     
-    // Фильтрация по исполнителю
-    if assigneeID := c.Query("assignee_id"); assigneeID != "" {
-        query = query.Where("assignee_id = ?", assigneeID)
+    query, err := h.applyDefectFilters(c, h.scopeDefects(c, base))
+    if err != nil {
+        h.badRequest(c, err.Error())
+        return
     }
     
     // Сортировка
-    sortBy := c.DefaultQuery("sort_by", "created_at")
-    order := c.DefaultQuery("order", "desc")
-    query = query.Order(sortBy + " " + order)
+    order, err := h.defectOrder(c)
+    if err != nil {
+        h.badRequest(c, err.Error())
+        return
+    }
+    query = query.Order(order)
     
     // Пагинация
     page, pageSize := h.getPaginationParams(c)
@@ -61,6 +68,11 @@ func (h *DefectHandler) GetDefects(c *gin.Context) {
         return
     }
     
+    if err := h.attachDefectDetails(defects); err != nil {
+        h.internalError(c, "Failed to fetch defect details")
+        return
+    }
+    
     h.success(c, gin.H{
         "defects": defects,
         "pagination": gin.H{
@@ -73,16 +85,29 @@ func (h *DefectHandler) GetDefects(c *gin.Context) {
 }
 
 func (h *DefectHandler) GetDefect(c *gin.Context) {
-    defectID := c.Param("id")
-    
-    var defect models.Defect
-    if err := h.DB.
-        Preload("History").
-        First(&defect, defectID).Error; err != nil {
+    defect, redirected, err := h.findDefect(h.scopeDefects(c, h.DB.Preload("History").Preload("Location").Preload("Category").Preload("Labels").
+        Preload("Checklist", func(db *gorm.DB) *gorm.DB { return db.Order("position, id") })), c.Param("id"))
+    if err != nil {
         h.notFound(c, "Defect not found")
         return
     }
     
+    // Дефект переносили в другой проект - отправляем на актуальный ключ
+    if redirected {
+        location := "/api/defects/" + defect.Key
+        if c.Request.URL.RawQuery != "" {
+            location += "?" + c.Request.URL.RawQuery
+        }
+        c.Redirect(http.StatusMovedPermanently, location)
+        return
+    }
+    
+    if err := h.attachDefectDetail(defect); err != nil {
+        h.internalError(c, "Failed to fetch defect details")
+        return
+    }
+    
+    setETag(c, defect.Version)
     h.success(c, gin.H{
         "defect": defect,
     }, "Defect retrieved successfully")
@@ -106,6 +131,138 @@ func (h *DefectHandler) CreateDefect(c *gin.Context) {
         h.badRequest(c, "Project not found")
         return
     }
+    if project.Phase.IsReadOnly() {
+        h.respondProjectReadOnly(c, &project)
+        return
+    }
+    if project.IsTemplate {
+        h.badRequest(c, "Project templates cannot contain defects")
+        return
+    }
+    if !project.Level.HoldsDefects() {
+        h.badRequest(c, "Defects can only be created in projects, not portfolios or programs")
+        return
+    }
+    
+    if err := h.validateDefectLocation(project.ID, req.LocationID); err != nil {
+        h.badRequest(c, err.Error())
+        return
+    }
+    if err := validateCoordinates(req.Latitude, req.Longitude); err != nil {
+        h.badRequest(c, err.Error())
+        return
+    }
+    if req.LocationID != nil && *req.LocationID == 0 {
+        req.LocationID = nil
+    }
+    if err := h.validateDefectCategory(project.ID, req.CategoryID); err != nil {
+        h.badRequest(c, err.Error())
+        return
+    }
+    if req.CategoryID != nil && *req.CategoryID == 0 {
+        req.CategoryID = nil
+    }
+    if req.ContractorID != nil && *req.ContractorID == 0 {
+        req.ContractorID = nil
+    }
+    if req.AssigneeID != nil && *req.AssigneeID == 0 {
+        req.AssigneeID = nil
+    }
+    // Исполнитель не указан - назначаем по режиму проекта (см. autoAssignee),
+    // у подрядчика исполнителя назначает он сам
+    checker := h.newAssigneeChecker(c, &project)
+    var roundRobin []uint
+    if req.AssigneeID == nil && req.ContractorID == nil {
+        req.AssigneeID, roundRobin, err = h.autoAssignee(checker, &project, req.CategoryID)
+        if err != nil {
+            h.internalError(c, "Failed to resolve default assignee")
+            return
+        }
+    }
+    if status, err := checker.check(req.ContractorID, req.AssigneeID); err != nil {
+        h.error(c, status, err.Error())
+        return
+    }
+    if err := h.validateDefectContractor(project.ID, req.ContractorID, req.CategoryID, req.AssigneeID); err != nil {
+        h.badRequest(c, err.Error())
+        return
+    }
+    if status, err := h.validatePlanPin(c, project.ID, req.PlanID, req.PlanX, req.PlanY); err != nil {
+        h.error(c, status, err.Error())
+        return
+    }
+    if req.PlanID != nil && *req.PlanID == 0 {
+        req.PlanID = nil
+    }
+    
+    if req.ParentID != nil && *req.ParentID == 0 {
+        req.ParentID = nil
+    }
+    if err := h.validateDefectParent(0, project.ID, req.ParentID); err != nil {
+        h.badRequest(c, err.Error())
+        return
+    }
+    if req.InspectionRoundID != nil && *req.InspectionRoundID == 0 {
+        req.InspectionRoundID = nil
+    }
+    if err := h.validateInspectionRound(project.ID, req.InspectionRoundID, req.LocationID); err != nil {
+        h.badRequest(c, err.Error())
+        return
+    }
+    
+    fieldChanges, err := h.prepareCustomFieldChanges(project.ID, 0, req.CustomFields, true)
+    if err != nil {
+        h.badRequest(c, err.Error())
+        return
+    }
+    if status, err := h.checkCustomFieldUsers(c, fieldChanges); err != nil {
+        h.error(c, status, err.Error())
+        return
+    }
+    
+    // Похожие дефекты подсказывает GET /defects/duplicates. Создание блокируется,
+    // только если клиент просил проверку (check_duplicates) и еще не выбрал
+    // "создать" или "связать": тогда он получает кандидатов
+    var original *models.Defect
+    switch req.DuplicateAction {
+    case "":
+        if !req.CheckDuplicates {
+            break
+        }
+        candidates, err := h.findDuplicateCandidates(duplicateQuery{
+            ProjectID:   project.ID,
+            Title:       req.Title,
+            Description: req.Description,
+            LocationID:  req.LocationID,
+            CategoryID:  req.CategoryID,
+        })
+        if err != nil {
+            h.internalError(c, "Failed to search for duplicates")
+            return
+        }
+        if len(candidates) > 0 {
+            c.JSON(http.StatusConflict, Response{
+                Success: false,
+                Error:   "Possible duplicates found",
+                Data: gin.H{
+                    "candidates": candidates,
+                    "actions":    []string{models.DuplicateActionCreate, models.DuplicateActionLink},
+                },
+            })
+            return
+        }
+    case models.DuplicateActionLink:
+        if req.DuplicateOfID == nil {
+            h.badRequest(c, "duplicate_of_id is required to link as duplicate")
+            return
+        }
+        var target models.Defect
+        if err := h.DB.Where("project_id = ?", project.ID).First(&target, *req.DuplicateOfID).Error; err != nil {
+            h.badRequest(c, "Original defect not found in the project")
+            return
+        }
+        original = &target
+    }
     
     defect := models.Defect{
         Title:       req.Title,
@@ -116,23 +273,84 @@ func (h *DefectHandler) CreateDefect(c *gin.Context) {
         ProjectID:   req.ProjectID,
         AuthorID:    userID,
         AssigneeID:  req.AssigneeID,
+        ContractorID: req.ContractorID,
+        EstimatedCost: req.EstimatedCost,
+        ActualCost:  req.ActualCost,
+        Currency:    costCurrency(req.Currency, req.EstimatedCost, req.ActualCost),
+        ParentID:    req.ParentID,
+        InspectionRoundID: req.InspectionRoundID,
+        CategoryID:  req.CategoryID,
+        LocationID:  req.LocationID,
+        Latitude:    req.Latitude,
+        Longitude:   req.Longitude,
+        PlanID:      req.PlanID,
+        PlanX:       req.PlanX,
+        PlanY:       req.PlanY,
+    }
+    
+    // Сроки по SLA-политике проекта; указанный вручную дедлайн сохраняется
+    if err := h.applySLA(&defect, defect.Deadline != nil && !defect.Deadline.IsZero()); err != nil {
+        h.internalError(c, "Failed to apply SLA policy")
+        return
     }
     
-    if err := h.DB.Create(&defect).Error; err != nil {
+    err = h.DB.Transaction(func(tx *gorm.DB) error {
+        number, key, err := allocateDefectNumber(tx, defect.ProjectID)
+        if err != nil {
+            return err
+        }
+        defect.Number = number
+        defect.Key = key
+        if len(roundRobin) > 0 {
+            assigneeID, err := nextRoundRobin(tx, defect.ProjectID, roundRobin)
+            if err != nil {
+                return err
+            }
+            defect.AssigneeID = &assigneeID
+        }
+        if err := tx.Create(&defect).Error; err != nil {
+            return err
+        }
+        if err := watchDefect(tx, defect.ID, &defect.AuthorID, models.WatchAuthor); err != nil {
+            return err
+        }
+        if err := watchDefect(tx, defect.ID, defect.AssigneeID, models.WatchAssignee); err != nil {
+            return err
+        }
+        if err := saveCustomFieldChanges(tx, defect.ID, fieldChanges); err != nil {
+            return err
+        }
+        if original == nil {
+            return nil
+        }
+        relation := models.DefectRelation{
+            SourceID:  defect.ID,
+            TargetID:  original.ID,
+            Type:      models.RelationDuplicateOf,
+            CreatedBy: userID,
+        }
+        if err := tx.Create(&relation).Error; err != nil {
+            return err
+        }
+        return recordDefectChange(tx, defect.ID, userID, "relations", "none",
+            fmt.Sprintf("%s %s", relation.Type, original.Key))
+    })
+    if err != nil {
         h.internalError(c, "Failed to create defect")
         return
     }
     
+    h.attachDefectDetail(&defect)
+    
+    setETag(c, defect.Version)
     h.success(c, gin.H{
         "defect": defect,
     }, "Defect created successfully")
 }
 
 func (h *DefectHandler) UpdateDefect(c *gin.Context) {
-    defectID := c.Param("id")
-    
-    var defect models.Defect
-    if err := h.DB.First(&defect, defectID).Error; err != nil {
+    defect, _, err := h.findDefect(h.scopeDefects(c, h.DB), c.Param("id"))
+    if err != nil {
         h.notFound(c, "Defect not found")
         return
     }
@@ -148,65 +366,360 @@ func (h *DefectHandler) UpdateDefect(c *gin.Context) {
         return
     }
     
+    if !h.checkIfMatch(c, defect.Version, h.currentDefectState(defect.ID)) {
+        return
+    }
+    
+    if !h.applyDefectUpdate(c, defect, &req, newHistoryRecorder(defect.ID, userID)) {
+        return
+    }
+    
+    h.DB.Preload("History").Preload("Location").Preload("Category").Preload("Labels").First(defect, defect.ID)
+    h.attachDefectDetail(defect)
+    
+    setETag(c, defect.Version)
+    h.success(c, gin.H{
+        "defect": defect,
+    }, "Defect updated successfully")
+}
+
+// applyDefectUpdate применяет изменения дефекта и сохраняет их вместе с историей
+// в одной транзакции. При ошибке отвечает клиенту и возвращает false.
+func (h *DefectHandler) applyDefectUpdate(c *gin.Context, defect *models.Defect, req *models.DefectUpdateRequest, history *historyRecorder) bool {
+    userID := history.userID
+    before := *defect
+    var err error
+    
+    if !h.checkProjectWritable(c, defect.ProjectID) {
+        return false
+    }
+    
+    // Перенос в другой проект: новый номер, старый ключ остается редиректом
+    var movedFromKey string
+    if req.ProjectID != nil && *req.ProjectID != defect.ProjectID {
+        var target models.Project
+        if err := h.DB.First(&target, *req.ProjectID).Error; err != nil {
+            h.badRequest(c, "Project not found")
+            return false
+        }
+        if target.Phase.IsReadOnly() {
+            h.respondProjectReadOnly(c, &target)
+            return false
+        }
+        if target.IsTemplate {
+            h.badRequest(c, "Project templates cannot contain defects")
+            return false
+        }
+        if !target.Level.HoldsDefects() {
+            h.badRequest(c, "Defects can only be moved to projects, not portfolios or programs")
+            return false
+        }
+        movedFromKey = defect.Key
+        history.add("project", fmt.Sprintf("%d", defect.ProjectID), fmt.Sprintf("%d", target.ID))
+        defect.ProjectID = target.ID
+    }
+    
     // Логируем изменения
     if req.Title != nil && *req.Title != defect.Title {
-        h.logDefectChange(defect.ID, userID, "title", defect.Title, *req.Title)
+        history.add("title", defect.Title, *req.Title)
         defect.Title = *req.Title
     }
     if req.Description != nil && *req.Description != defect.Description {
-        h.logDefectChange(defect.ID, userID, "description", defect.Description, *req.Description)
+        history.add("description", defect.Description, *req.Description)
         defect.Description = *req.Description
     }
+    oldStatus := defect.Status
     if req.Status != nil && *req.Status != defect.Status {
-        h.logDefectChange(defect.ID, userID, "status", string(defect.Status), string(*req.Status))
+        if err := h.checkStatusTransition(defect, *req.Status); err != nil {
+            h.error(c, http.StatusConflict, err.Error())
+            return false
+        }
+        history.add("status", string(defect.Status), string(*req.Status))
         defect.Status = *req.Status
     }
+    oldPriority, oldCategoryID := defect.Priority, defect.CategoryID
     if req.Priority != nil && *req.Priority != defect.Priority {
-        h.logDefectChange(defect.ID, userID, "priority", string(defect.Priority), string(*req.Priority))
+        history.add("priority", string(defect.Priority), string(*req.Priority))
         defect.Priority = *req.Priority
     }
-    if req.Deadline != nil && *req.Deadline != *defect.Deadline {
-        oldDeadline := "none"
-        if defect.Deadline != nil && !defect.Deadline.IsZero() {
-            oldDeadline = defect.Deadline.Format("2006-01-02")
+    if req.Deadline != nil && formatDeadline(req.Deadline) != formatDeadline(defect.Deadline) {
+        history.add("deadline", formatDeadline(defect.Deadline), formatDeadline(req.Deadline))
+        defect.Deadline = req.Deadline
+    }
+    if req.AssigneeID != nil && !sameUint(req.AssigneeID, defect.AssigneeID) {
+        history.add("assignee", formatOptionalID(defect.AssigneeID), formatOptionalID(req.AssigneeID))
+        defect.AssigneeID = req.AssigneeID
+        if *req.AssigneeID == 0 {
+            defect.AssigneeID = nil
         }
-        newDeadline := "none"
-        if !req.Deadline.IsZero() {
-            newDeadline = req.Deadline.Format("2006-01-02")
+    }
+    
+    // Стоимость устранения; подрядчики свою стоимость не меняют
+    if req.EstimatedCost != nil || req.ActualCost != nil || req.Currency != nil {
+        if _, userRole, _ := h.GetUserFromContext(c); userRole == models.RoleContractor {
+            h.error(c, http.StatusForbidden, "Contractors cannot change repair costs")
+            return false
         }
-        h.logDefectChange(defect.ID, userID, "deadline", oldDeadline, newDeadline)
-        defect.Deadline = req.Deadline
     }
-    if req.AssigneeID != nil && *req.AssigneeID != *defect.AssigneeID {
-        oldAssignee := "none"
-        if defect.AssigneeID != nil {
-            oldAssignee = fmt.Sprintf("%d", *defect.AssigneeID)
+    if req.EstimatedCost != nil && formatCost(req.EstimatedCost) != formatCost(defect.EstimatedCost) {
+        history.add("estimated_cost", formatCost(defect.EstimatedCost), formatCost(req.EstimatedCost))
+        defect.EstimatedCost = req.EstimatedCost
+    }
+    if req.ActualCost != nil && formatCost(req.ActualCost) != formatCost(defect.ActualCost) {
+        history.add("actual_cost", formatCost(defect.ActualCost), formatCost(req.ActualCost))
+        defect.ActualCost = req.ActualCost
+    }
+    currency := defect.Currency
+    if req.Currency != nil {
+        currency = *req.Currency
+    }
+    if currency = costCurrency(currency, defect.EstimatedCost, defect.ActualCost); currency != defect.Currency {
+        history.add("currency", formatCurrency(defect.Currency), formatCurrency(currency))
+        defect.Currency = currency
+    }
+    
+    // Метки и дополнительные поля принадлежат проекту и при переносе снимаются (см. транзакцию ниже)
+    if movedFromKey != "" {
+        var labels []models.Label
+        h.DB.Model(defect).Association("Labels").Find(&labels)
+        if len(labels) > 0 {
+            history.add("labels", labelNames(labels), "none")
         }
-        newAssignee := "none"
-        if *req.AssigneeID != 0 {
-            newAssignee = fmt.Sprintf("%d", *req.AssigneeID)
+        
+        var values []models.CustomFieldValue
+        h.DB.Preload("Field").Where("defect_id = ?", defect.ID).Find(&values)
+        for i := range values {
+            history.add("cf."+values[i].Field.Key, values[i].String(), "none")
         }
-        h.logDefectChange(defect.ID, userID, "assignee", oldAssignee, newAssignee)
-        defect.AssigneeID = req.AssigneeID
     }
     
-    if err := h.DB.Save(&defect).Error; err != nil {
-        h.internalError(c, "Failed to update defect")
-        return
+    // Родительский дефект; parent_id = 0 отвязывает дефект от родителя.
+    // Родитель и дочерние дефекты живут в одном проекте.
+    if req.ParentID == nil && movedFromKey != "" && defect.ParentID != nil {
+        zero := uint(0)
+        req.ParentID = &zero
+    }
+    if req.ParentID != nil && !sameUint(req.ParentID, defect.ParentID) {
+        if err := h.validateDefectParent(defect.ID, defect.ProjectID, req.ParentID); err != nil {
+            h.badRequest(c, err.Error())
+            return false
+        }
+        history.add("parent", formatOptionalID(defect.ParentID), formatOptionalID(req.ParentID))
+        defect.ParentID = req.ParentID
+        if *req.ParentID == 0 {
+            defect.ParentID = nil
+        }
     }
     
-    h.DB.Preload("History").First(&defect, defect.ID)
+    // Проектная категория после переноса в другой проект может стать недоступной
+    if req.CategoryID == nil && movedFromKey != "" && defect.CategoryID != nil {
+        if h.validateDefectCategory(defect.ProjectID, defect.CategoryID) != nil {
+            zero := uint(0)
+            req.CategoryID = &zero
+        }
+    }
+    if req.CategoryID != nil && !sameUint(req.CategoryID, defect.CategoryID) {
+        if err := h.validateDefectCategory(defect.ProjectID, req.CategoryID); err != nil {
+            h.badRequest(c, err.Error())
+            return false
+        }
+        history.add("category", formatOptionalID(defect.CategoryID), formatOptionalID(req.CategoryID))
+        defect.CategoryID = req.CategoryID
+        if *req.CategoryID == 0 {
+            defect.CategoryID = nil
+        }
+        defect.Category = nil
+    }
     
-    h.success(c, gin.H{
-        "defect": defect,
-    }, "Defect updated successfully")
+    // Подрядчик; contractor_id = 0 снимает подрядчика. Без договора в новом проекте
+    // подрядчик при переносе снимается. Сотрудники подрядчика не передают дефект другим организациям
+    if req.ContractorID == nil && movedFromKey != "" && defect.ContractorID != nil {
+        if h.validateDefectContractor(defect.ProjectID, defect.ContractorID, nil, nil) != nil {
+            zero := uint(0)
+            req.ContractorID = &zero
+        }
+    }
+    if req.ContractorID != nil && !sameUint(req.ContractorID, defect.ContractorID) {
+        if _, userRole, _ := h.GetUserFromContext(c); userRole == models.RoleContractor {
+            h.error(c, http.StatusForbidden, "Contractors cannot reassign defects to another organization")
+            return false
+        }
+        history.add("contractor", formatOptionalID(defect.ContractorID), formatOptionalID(req.ContractorID))
+        defect.ContractorID = req.ContractorID
+        if *req.ContractorID == 0 {
+            defect.ContractorID = nil
+        }
+    }
+    if !sameUint(defect.ContractorID, before.ContractorID) || !sameUint(defect.CategoryID, before.CategoryID) ||
+        !sameUint(defect.AssigneeID, before.AssigneeID) {
+        if err := h.validateDefectContractor(defect.ProjectID, defect.ContractorID, defect.CategoryID, defect.AssigneeID); err != nil {
+            h.badRequest(c, err.Error())
+            return false
+        }
+    }
+    // Исполнителя проверяем при его смене и при смене подрядчика: меняются требования к роли
+    if !sameUint(defect.AssigneeID, before.AssigneeID) || !sameUint(defect.ContractorID, before.ContractorID) {
+        var project models.Project
+        if err := h.DB.First(&project, defect.ProjectID).Error; err != nil {
+            h.internalError(c, "Failed to fetch project")
+            return false
+        }
+        if status, err := h.newAssigneeChecker(c, &project).check(defect.ContractorID, defect.AssigneeID); err != nil {
+            h.error(c, status, err.Error())
+            return false
+        }
+    }
+    
+    // Сроки SLA зависят от проекта, приоритета и категории: при их изменении
+    // пересчитываются, дедлайн - если он не задан в этом же запросе
+    priorityChanged := req.Priority != nil && *req.Priority != oldPriority
+    categoryChanged := !sameUint(defect.CategoryID, oldCategoryID)
+    if priorityChanged || categoryChanged || movedFromKey != "" {
+        oldDeadline := formatDeadline(defect.Deadline)
+        if err := h.applySLA(defect, req.Deadline != nil); err != nil {
+            h.internalError(c, "Failed to apply SLA policy")
+            return false
+        }
+        if newDeadline := formatDeadline(defect.Deadline); newDeadline != oldDeadline {
+            history.add("deadline", oldDeadline, newDeadline)
+        }
+    }
+    
+    // Место должно принадлежать проекту; при переносе в другой проект старое место сбрасывается
+    if req.LocationID == nil && movedFromKey != "" && defect.LocationID != nil {
+        zero := uint(0)
+        req.LocationID = &zero
+    }
+    if req.LocationID != nil && !sameUint(req.LocationID, defect.LocationID) {
+        if err := h.validateDefectLocation(defect.ProjectID, req.LocationID); err != nil {
+            h.badRequest(c, err.Error())
+            return false
+        }
+        history.add("location", formatOptionalID(defect.LocationID), formatOptionalID(req.LocationID))
+        defect.LocationID = req.LocationID
+        if *req.LocationID == 0 {
+            defect.LocationID = nil
+        }
+        defect.Location = nil
+    }
+    if req.Latitude != nil || req.Longitude != nil {
+        if err := validateCoordinates(req.Latitude, req.Longitude); err != nil {
+            h.badRequest(c, err.Error())
+            return false
+        }
+        oldCoordinates := formatCoordinates(defect.Latitude, defect.Longitude)
+        newCoordinates := formatCoordinates(req.Latitude, req.Longitude)
+        if oldCoordinates != newCoordinates {
+            history.add("coordinates", oldCoordinates, newCoordinates)
+            defect.Latitude = req.Latitude
+            defect.Longitude = req.Longitude
+        }
+    }
+    
+    // Метка на чертеже; plan_id = 0 снимает метку. Чертеж другого проекта
+    // после переноса дефекта не подходит, поэтому метка тоже снимается.
+    if req.PlanID == nil && movedFromKey != "" && defect.PlanID != nil {
+        zero := uint(0)
+        req.PlanID = &zero
+    }
+    if req.PlanID != nil || req.PlanX != nil || req.PlanY != nil {
+        planID, planX, planY := defect.PlanID, defect.PlanX, defect.PlanY
+        if req.PlanID != nil {
+            planID = req.PlanID
+        }
+        if req.PlanX != nil {
+            planX = req.PlanX
+        }
+        if req.PlanY != nil {
+            planY = req.PlanY
+        }
+        if planID != nil && *planID == 0 {
+            planID, planX, planY = nil, nil, nil
+        }
+        
+        if status, err := h.validatePlanPin(c, defect.ProjectID, planID, planX, planY); err != nil {
+            h.error(c, status, err.Error())
+            return false
+        }
+        
+        oldPin := formatPlanPin(defect.PlanID, defect.PlanX, defect.PlanY)
+        newPin := formatPlanPin(planID, planX, planY)
+        if oldPin != newPin {
+            history.add("plan_pin", oldPin, newPin)
+            defect.PlanID, defect.PlanX, defect.PlanY = planID, planX, planY
+        }
+    }
+    
+    // Дополнительные поля принадлежат проекту: при переносе старые значения удаляются
+    var fieldChanges []customFieldChange
+    if movedFromKey == "" && req.CustomFields != nil {
+        fieldChanges, err = h.prepareCustomFieldChanges(defect.ProjectID, defect.ID, req.CustomFields, false)
+        if err != nil {
+            h.badRequest(c, err.Error())
+            return false
+        }
+        if status, err := h.checkCustomFieldUsers(c, fieldChanges); err != nil {
+            h.error(c, status, err.Error())
+            return false
+        }
+    }
+    for _, change := range fieldChanges {
+        history.add("cf."+change.Field.Key, change.OldValue.String(), change.NewValue.String())
+    }
+    
+    err = h.DB.Transaction(func(tx *gorm.DB) error {
+        if movedFromKey != "" {
+            number, key, err := allocateDefectNumber(tx, defect.ProjectID)
+            if err != nil {
+                return err
+            }
+            defect.Number = number
+            defect.Key = key
+            
+            redirect := models.DefectKeyRedirect{OldKey: movedFromKey, DefectID: defect.ID}
+            if err := tx.Create(&redirect).Error; err != nil {
+                return err
+            }
+            
+            if err := tx.Exec("DELETE FROM defect_labels WHERE defect_id = ?", defect.ID).Error; err != nil {
+                return err
+            }
+            if err := tx.Unscoped().Where("defect_id = ?", defect.ID).Delete(&models.CustomFieldValue{}).Error; err != nil {
+                return err
+            }
+        }
+        if err := saveCustomFieldChanges(tx, defect.ID, fieldChanges); err != nil {
+            return err
+        }
+        markStatusTimestamps(defect, oldStatus, time.Now().UTC())
+        resetAcceptance(defect, oldStatus)
+        if err := saveVersioned(tx, &before, defect, &defect.Version); err != nil {
+            return err
+        }
+        if err := history.save(tx); err != nil {
+            return err
+        }
+        if !sameUint(defect.AssigneeID, before.AssigneeID) {
+            if err := watchDefect(tx, defect.ID, defect.AssigneeID, models.WatchAssignee); err != nil {
+                return err
+            }
+        }
+        return afterStatusChange(tx, defect, oldStatus, userID)
+    })
+    if errors.Is(err, errVersionConflict) {
+        h.respondDefectConflict(c, defect.ID)
+        return false
+    }
+    if err != nil {
+        h.internalError(c, "Failed to update defect")
+        return false
+    }
+    return true
 }
 
 func (h *DefectHandler) UpdateDefectStatus(c *gin.Context) {
-    defectID := c.Param("id")
-    
-    var defect models.Defect
-    if err := h.DB.First(&defect, defectID).Error; err != nil {
+    defect, _, err := h.findDefect(h.scopeDefects(c, h.DB), c.Param("id"))
+    if err != nil {
         h.notFound(c, "Defect not found")
         return
     }
@@ -225,20 +738,126 @@ func (h *DefectHandler) UpdateDefectStatus(c *gin.Context) {
         return
     }
     
+    if !h.checkIfMatch(c, defect.Version, h.currentDefectState(defect.ID)) {
+        return
+    }
+    
+    if !h.checkProjectWritable(c, defect.ProjectID) {
+        return
+    }
+    
+    if err := h.checkStatusTransition(defect, req.Status); err != nil {
+        h.error(c, http.StatusConflict, err.Error())
+        return
+    }
+    
     // Логируем изменение статуса
-    h.logDefectChange(defect.ID, userID, "status", string(defect.Status), string(req.Status))
+    before := *defect
+    history := newHistoryRecorder(defect.ID, userID)
+    if req.Status != defect.Status {
+        history.add("status", string(defect.Status), string(req.Status))
+    }
+    oldStatus := defect.Status
     defect.Status = req.Status
     
-    if err := h.DB.Save(&defect).Error; err != nil {
+    markStatusTimestamps(defect, oldStatus, time.Now().UTC())
+    resetAcceptance(defect, oldStatus)
+    
+    err = h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := saveVersioned(tx, &before, defect, &defect.Version); err != nil {
+            return err
+        }
+        if err := history.save(tx); err != nil {
+            return err
+        }
+        return afterStatusChange(tx, defect, oldStatus, userID)
+    })
+    if errors.Is(err, errVersionConflict) {
+        h.respondDefectConflict(c, defect.ID)
+        return
+    }
+    if err != nil {
         h.internalError(c, "Failed to update defect status")
         return
     }
     
+    setETag(c, defect.Version)
     h.success(c, gin.H{
         "defect": defect,
     }, "Defect status updated successfully")
 }
 
+// GetDefectsByLocation - количество дефектов по местам выбранного уровня
+// (type=building|floor|...), с учетом вложенных мест и фильтров списка дефектов
+func (h *DefectHandler) GetDefectsByLocation(c *gin.Context) {
+    projectID := c.Query("project_id")
+    if projectID == "" {
+        h.badRequest(c, "project_id is required")
+        return
+    }
+    
+    locationType := c.DefaultQuery("type", string(models.LocationBuilding))
+    
+    filtered, err := h.applyDefectFilters(c, h.scopeDefects(c, h.DB.Model(&models.Defect{}).Select("defects.id")))
+    if err != nil {
+        h.badRequest(c, err.Error())
+        return
+    }
+    
+    var groups []models.DefectsByLocation
+    if err := h.DB.Table("locations AS l").
+        Select("l.id AS location_id, l.name, l.type, l.path, COUNT(d.id) AS count").
+        Joins("LEFT JOIN locations AS dl ON dl.path LIKE l.path || '%' AND dl.deleted_at IS NULL").
+        Joins("LEFT JOIN defects AS d ON d.location_id = dl.id AND d.id IN (?)", filtered).
+        Where("l.project_id = ? AND l.type = ? AND l.deleted_at IS NULL", projectID, locationType).
+        Group("l.id, l.name, l.type, l.path").
+        Order("l.path").
+        Scan(&groups).Error; err != nil {
+        h.internalError(c, "Failed to group defects by location")
+        return
+    }
+    
+    h.success(c, gin.H{
+        "groups": groups,
+    }, "Defects grouped by location successfully")
+}
+
+// GetDefectsByCategory - количество дефектов по категориям выбранного уровня
+// (level=trade|category|subcategory), с учетом подкатегорий и фильтров списка дефектов
+func (h *DefectHandler) GetDefectsByCategory(c *gin.Context) {
+    level := c.DefaultQuery("level", string(models.CategoryTrade))
+    
+    filtered, err := h.applyDefectFilters(c, h.scopeDefects(c, h.DB.Model(&models.Defect{}).Select("defects.id")))
+    if err != nil {
+        h.badRequest(c, err.Error())
+        return
+    }
+    
+    query := h.DB.Table("categories AS cat").
+        Select("cat.id AS category_id, cat.name, cat.level, cat.path, COUNT(d.id) AS count").
+        Joins("LEFT JOIN categories AS dc ON dc.path LIKE cat.path || '%' AND dc.deleted_at IS NULL").
+        Joins("LEFT JOIN defects AS d ON d.category_id = dc.id AND d.id IN (?)", filtered).
+        Where("cat.level = ? AND cat.deleted_at IS NULL", level)
+    if projectID := c.Query("project_id"); projectID != "" {
+        query = query.Where("cat.project_id IS NULL OR cat.project_id = ?", projectID)
+    } else {
+        query = query.Where("cat.project_id IS NULL")
+    }
+    
+    var groups []models.DefectsByCategory
+    if err := query.
+        Group("cat.id, cat.name, cat.level, cat.path").
+        Order("cat.path").
+        Scan(&groups).Error; err != nil {
+        h.internalError(c, "Failed to group defects by category")
+        return
+    }
+    
+    h.success(c, gin.H{
+        "groups": groups,
+    }, "Defects grouped by category successfully")
+}
+
 func (h *DefectHandler) GetMyDefects(c *gin.Context) {
     userID, _, err := h.GetUserFromContext(c)
     if err != nil {
@@ -248,7 +867,7 @@ func (h *DefectHandler) GetMyDefects(c *gin.Context) {
     
     var defects []models.Defect
     
-    query := h.DB.
+    query := h.scopeDefects(c, h.DB).
         Where("author_id = ? OR assignee_id = ?", userID, userID)
     
     // Фильтрация
@@ -268,6 +887,11 @@ func (h *DefectHandler) GetMyDefects(c *gin.Context) {
         return
     }
     
+    if err := h.attachDefectDetails(defects); err != nil {
+        h.internalError(c, "Failed to fetch defect details")
+        return
+    }
+    
     h.success(c, gin.H{
         "defects": defects,
         "pagination": gin.H{
@@ -280,10 +904,8 @@ func (h *DefectHandler) GetMyDefects(c *gin.Context) {
 }
 
 func (h *DefectHandler) DeleteDefect(c *gin.Context) {
-    defectID := c.Param("id")
-    
-    var defect models.Defect
-    if err := h.DB.First(&defect, defectID).Error; err != nil {
+    defect, _, err := h.findDefect(h.scopeDefects(c, h.DB), c.Param("id"))
+    if err != nil {
         h.notFound(c, "Defect not found")
         return
     }
@@ -300,27 +922,146 @@ func (h *DefectHandler) DeleteDefect(c *gin.Context) {
         return
     }
     
-    // Удаляем связанную историю
-    if err := h.DB.Where("defect_id = ?", defectID).Delete(&models.DefectHistory{}).Error; err != nil {
-        h.internalError(c, "Failed to delete defect history")
+    if !h.checkProjectWritable(c, defect.ProjectID) {
         return
     }
     
-    if err := h.DB.Delete(&defect).Error; err != nil {
+    // Дефект уходит в корзину вместе с историей, окончательно его удалит планировщик
+    err = h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Model(defect).Update("deleted_by", userID).Error; err != nil {
+            return err
+        }
+        if err := tx.Delete(defect).Error; err != nil {
+            return err
+        }
+        return recordDefectChange(tx, defect.ID, userID, "deleted", "false", "true")
+    })
+    if err != nil {
         h.internalError(c, "Failed to delete defect")
         return
     }
     
-    h.success(c, nil, "Defect deleted successfully")
+    h.success(c, nil, "Defect moved to trash")
 }
 
-func (h *DefectHandler) logDefectChange(defectID uint, userID uint, field string, oldValue, newValue string) {
-    history := models.DefectHistory{
-        DefectID:  defectID,
-        Field:     field,
-        OldValue:  oldValue,
-        NewValue:  newValue,
-        ChangedBy: userID,
-    }
-    h.DB.Create(&history)
-}
\ No newline at end of file
+// currentDefectState - текущее состояние дефекта для ответа 412
+func (h *Handler) currentDefectState(defectID uint) func() gin.H {
+    return func() gin.H {
+        var defect models.Defect
+        if err := h.DB.Preload("Location").Preload("Category").Preload("Labels").First(&defect, defectID).Error; err != nil {
+            return nil
+        }
+        h.attachDefectDetail(&defect)
+        return gin.H{"defect": defect}
+    }
+}
+
+// respondDefectConflict - 412, если дефект изменили во время сохранения
+func (h *Handler) respondDefectConflict(c *gin.Context, defectID uint) {
+    current := h.currentDefectState(defectID)()
+    var version uint
+    if defect, ok := current["defect"].(models.Defect); ok {
+        version = defect.Version
+    }
+    h.respondVersionConflict(c, version, current)
+}
+
+// validateDefectParent проверяет родительский дефект: тот же проект и отсутствие циклов
+func (h *Handler) validateDefectParent(defectID, projectID uint, parentID *uint) error {
+    if parentID == nil || *parentID == 0 {
+        return nil
+    }
+    if *parentID == defectID {
+        return fmt.Errorf("defect cannot be its own parent")
+    }
+    
+    var parent models.Defect
+    if err := h.DB.First(&parent, *parentID).Error; err != nil {
+        return fmt.Errorf("parent defect not found")
+    }
+    if parent.ProjectID != projectID {
+        return fmt.Errorf("parent defect must belong to the same project")
+    }
+    
+    if defectID != 0 {
+        cycle, err := h.parentCycleExists(defectID, parent.ID)
+        if err != nil {
+            return err
+        }
+        if cycle {
+            return fmt.Errorf("parent relation would create a cycle")
+        }
+    }
+    return nil
+}
+
+// attachDefectDetails заполняет вычисляемые поля списка дефектов:
+// значения дополнительных полей, прогресс чек-листа и SLA-состояние
+func (h *Handler) attachDefectDetails(defects []models.Defect) error {
+    if err := h.attachCustomFields(defects); err != nil {
+        return err
+    }
+    if err := h.attachChecklistProgress(defects); err != nil {
+        return err
+    }
+
+    now := time.Now().UTC()
+    for i := range defects {
+        defects[i].SLAState = defects[i].SLAStatus(now)
+    }
+    return nil
+}
+
+// attachDefectDetail - attachDefectDetails для одного дефекта
+func (h *Handler) attachDefectDetail(defect *models.Defect) error {
+    defects := []models.Defect{*defect}
+    if err := h.attachDefectDetails(defects); err != nil {
+        return err
+    }
+    defect.CustomFields = defects[0].CustomFields
+    defect.ChecklistProgress = defects[0].ChecklistProgress
+    defect.SLAState = defects[0].SLAState
+    return nil
+}
+
+func formatDeadline(deadline *models.Date) string {
+    if deadline == nil || deadline.IsZero() {
+        return "none"
+    }
+    return deadline.Format("2006-01-02")
+}
+
+func validateCoordinates(latitude, longitude *float64) error {
+    if (latitude == nil) != (longitude == nil) {
+        return fmt.Errorf("latitude and longitude must be set together")
+    }
+    return nil
+}
+
+func formatCoordinates(latitude, longitude *float64) string {
+    if latitude == nil || longitude == nil {
+        return "none"
+    }
+    return fmt.Sprintf("%.6f,%.6f", *latitude, *longitude)
+}
+
+func formatOptionalID(id *uint) string {
+    if id == nil || *id == 0 {
+        return "none"
+    }
+    return fmt.Sprintf("%d", *id)
+}
+
+func sameUint(a, b *uint) bool {
+    if a == nil || *a == 0 {
+        return b == nil || *b == 0
+    }
+    return b != nil && *a == *b
+}
+
+func formatPlanPin(planID *uint, x, y *float64) string {
+    if planID == nil || x == nil || y == nil {
+        return "none"
+    }
+    return fmt.Sprintf("%d@%.4f,%.4f", *planID, *x, *y)
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"project-defect-service/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Веса и пороги оценки дубликатов. Название весит больше описания: описания
+// у инспекторов часто пустые или шаблонные.
+const (
+    duplicateTitleWeight       = 0.6
+    duplicateDescriptionWeight = 0.2
+    duplicateLocationWeight    = 0.1
+    duplicateCategoryWeight    = 0.1
+
+    duplicateTitleThreshold       = 0.3
+    duplicateDescriptionThreshold = 0.4
+    duplicateScoreThreshold       = 0.35
+    duplicateCandidatesLimit      = 10
+)
+
+// duplicateQuery - данные нового дефекта, для которого ищутся дубликаты
+type duplicateQuery struct {
+    ProjectID   uint
+    Title       string
+    Description string
+    LocationID  *uint
+    CategoryID  *uint
+}
+
+// findDuplicateCandidates ищет похожие открытые дефекты того же проекта по триграммному
+// сходству названия и описания. Если указано помещение, кандидаты берутся из него же
+// (или без помещения); совпадение помещения и категории повышает оценку.
+func (h *Handler) findDuplicateCandidates(q duplicateQuery) ([]models.DuplicateCandidate, error) {
+    title := strings.TrimSpace(q.Title)
+    description := strings.TrimSpace(q.Description)
+    if title == "" {
+        return nil, nil
+    }
+
+    query := h.DB.Table("defects").
+        Select(`defects.id AS defect_id, defects.key, defects.title, defects.status, defects.priority,
+            defects.location_id, defects.category_id,
+            similarity(defects.title, ?) AS title_similarity,
+            CASE WHEN ? = '' OR defects.description = '' THEN 0
+                ELSE similarity(defects.description, ?) END AS description_similarity`,
+            title, description, description).
+        Where("defects.deleted_at IS NULL AND defects.project_id = ?", q.ProjectID).
+        Where("defects.status IN ?", []models.DefectStatus{models.StatusNew, models.StatusInProgress, models.StatusOnReview}).
+        Where("(similarity(defects.title, ?) >= ? OR (? <> '' AND similarity(defects.description, ?) >= ?))",
+            title, duplicateTitleThreshold, description, description, duplicateDescriptionThreshold)
+    if q.LocationID != nil {
+        query = query.Where("(defects.location_id = ? OR defects.location_id IS NULL)", *q.LocationID)
+    }
+
+    var candidates []models.DuplicateCandidate
+    if err := query.Scan(&candidates).Error; err != nil {
+        return nil, err
+    }
+
+    result := candidates[:0]
+    for _, candidate := range candidates {
+        candidate.SameLocation = q.LocationID != nil && sameUint(candidate.LocationID, q.LocationID)
+        candidate.SameCategory = q.CategoryID != nil && sameUint(candidate.CategoryID, q.CategoryID)
+
+        score := duplicateTitleWeight*candidate.TitleSimilarity + duplicateDescriptionWeight*candidate.DescriptionSimilarity
+        if candidate.SameLocation {
+            score += duplicateLocationWeight
+        }
+        if candidate.SameCategory {
+            score += duplicateCategoryWeight
+        }
+        candidate.Score = float64(int(score*1000+0.5)) / 1000
+        if candidate.Score >= duplicateScoreThreshold {
+            result = append(result, candidate)
+        }
+    }
+
+    sort.SliceStable(result, func(i, j int) bool {
+        return result[i].Score > result[j].Score
+    })
+    if len(result) > duplicateCandidatesLimit {
+        result = result[:duplicateCandidatesLimit]
+    }
+    return result, nil
+}
+
+// CheckDuplicates - поиск похожих дефектов до отправки формы создания
+func (h *DefectHandler) CheckDuplicates(c *gin.Context) {
+    projectID, err := strconv.ParseUint(c.Query("project_id"), 10, 32)
+    if err != nil {
+        h.badRequest(c, "project_id is required")
+        return
+    }
+    if strings.TrimSpace(c.Query("title")) == "" {
+        h.badRequest(c, "title is required")
+        return
+    }
+
+    q := duplicateQuery{
+        ProjectID:   uint(projectID),
+        Title:       c.Query("title"),
+        Description: c.Query("description"),
+    }
+    if value := c.Query("location_id"); value != "" {
+        id, err := strconv.ParseUint(value, 10, 32)
+        if err != nil {
+            h.badRequest(c, "Invalid location_id")
+            return
+        }
+        q.LocationID = uintPtr(uint(id))
+    }
+    if value := c.Query("category_id"); value != "" {
+        id, err := strconv.ParseUint(value, 10, 32)
+        if err != nil {
+            h.badRequest(c, "Invalid category_id")
+            return
+        }
+        q.CategoryID = uintPtr(uint(id))
+    }
+
+    candidates, err := h.findDuplicateCandidates(q)
+    if err != nil {
+        h.internalError(c, "Failed to search for duplicates")
+        return
+    }
+
+    h.success(c, gin.H{
+        "candidates": candidates,
+    }, "Duplicate candidates retrieved successfully")
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"strconv"
+
+	"project-defect-service/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type EscalationHandler struct {
+    Handler
+}
+
+func NewEscalationHandler(db *gorm.DB, jwtSecret, authServiceURL string) *EscalationHandler {
+    return &EscalationHandler{
+        Handler: *NewHandler(db, jwtSecret, authServiceURL),
+    }
+}
+
+func (h *EscalationHandler) GetEscalationRules(c *gin.Context) {
+    var rules []models.EscalationRule
+    if err := h.DB.Where("project_id = ?", c.Param("id")).Order("id").Find(&rules).Error; err != nil {
+        h.internalError(c, "Failed to fetch escalation rules")
+        return
+    }
+
+    h.success(c, gin.H{
+        "escalation_rules": rules,
+    }, "Escalation rules retrieved successfully")
+}
+
+func (h *EscalationHandler) CreateEscalationRule(c *gin.Context) {
+    var project models.Project
+    if err := h.DB.First(&project, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+
+    if !h.canManageProject(c, &project) {
+        return
+    }
+
+    var req models.EscalationRuleRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    // По умолчанию правило включено; enabled=false из запроса применяется ниже
+    rule := models.EscalationRule{ProjectID: project.ID, Enabled: true}
+    if !h.applyEscalationRule(c, &rule, &req) {
+        return
+    }
+
+    if err := h.DB.Create(&rule).Error; err != nil {
+        h.internalError(c, "Failed to create escalation rule")
+        return
+    }
+
+    h.success(c, gin.H{
+        "escalation_rule": rule,
+    }, "Escalation rule created successfully")
+}
+
+func (h *EscalationHandler) UpdateEscalationRule(c *gin.Context) {
+    rule, ok := h.findManagedRule(c)
+    if !ok {
+        return
+    }
+
+    var req models.EscalationRuleRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    if !h.applyEscalationRule(c, rule, &req) {
+        return
+    }
+
+    if err := h.DB.Save(rule).Error; err != nil {
+        h.internalError(c, "Failed to update escalation rule")
+        return
+    }
+
+    h.success(c, gin.H{
+        "escalation_rule": rule,
+    }, "Escalation rule updated successfully")
+}
+
+func (h *EscalationHandler) DeleteEscalationRule(c *gin.Context) {
+    rule, ok := h.findManagedRule(c)
+    if !ok {
+        return
+    }
+
+    if err := h.DB.Delete(rule).Error; err != nil {
+        h.internalError(c, "Failed to delete escalation rule")
+        return
+    }
+
+    h.success(c, nil, "Escalation rule deleted successfully")
+}
+
+// GetDefectEvents - лента событий для рассылки уведомлений: after_id, limit,
+// project_id, defect_id, type. События отдаются по возрастанию ID.
+func (h *EscalationHandler) GetDefectEvents(c *gin.Context) {
+    query := h.DB.Model(&models.DefectEvent{})
+    if afterID := c.Query("after_id"); afterID != "" {
+        query = query.Where("id > ?", afterID)
+    }
+    if projectID := c.Query("project_id"); projectID != "" {
+        query = query.Where("project_id = ?", projectID)
+    }
+    // События видны только по доступным пользователю дефектам
+    if defectID := c.Query("defect_id"); defectID != "" {
+        defect, _, err := h.findDefect(h.scopeDefects(c, h.DB), defectID)
+        if err != nil {
+            h.notFound(c, "Defect not found")
+            return
+        }
+        query = query.Where("defect_id = ?", defect.ID)
+    } else {
+        query = query.Where("defect_id IN (?)", h.scopeDefects(c, h.DB.Model(&models.Defect{}).Select("defects.id")))
+    }
+    if eventType := c.Query("type"); eventType != "" {
+        query = query.Where("type = ?", eventType)
+    }
+
+    limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+    if limit < 1 || limit > 1000 {
+        limit = 100
+    }
+
+    var events []models.DefectEvent
+    if err := query.Order("id").Limit(limit).Find(&events).Error; err != nil {
+        h.internalError(c, "Failed to fetch events")
+        return
+    }
+
+    h.success(c, gin.H{
+        "events": events,
+    }, "Events retrieved successfully")
+}
+
+// applyEscalationRule переносит поля запроса в правило; reassign требует допустимого исполнителя
+func (h *EscalationHandler) applyEscalationRule(c *gin.Context, rule *models.EscalationRule, req *models.EscalationRuleRequest) bool {
+    if req.Action == models.ActionReassign && (req.AssigneeID == nil || *req.AssigneeID == 0) {
+        h.badRequest(c, "assignee_id is required for reassign rules")
+        return false
+    }
+    if req.Action != models.ActionReassign && req.AssigneeID != nil {
+        h.badRequest(c, "assignee_id is only supported for reassign rules")
+        return false
+    }
+
+    // Исполнитель правила проверяется как при ручном назначении; планировщик
+    // повторяет проверку перед каждым назначением
+    if req.Action == models.ActionReassign {
+        var project models.Project
+        if err := h.DB.First(&project, rule.ProjectID).Error; err != nil {
+            h.notFound(c, "Project not found")
+            return false
+        }
+        if status, err := h.newAssigneeChecker(c, &project).check(nil, req.AssigneeID); err != nil {
+            h.error(c, status, err.Error())
+            return false
+        }
+    }
+
+    rule.Name = req.Name
+    rule.Trigger = req.Trigger
+    rule.OffsetHours = req.OffsetHours
+    rule.Priority = req.Priority
+    rule.Action = req.Action
+    rule.AssigneeID = req.AssigneeID
+    if req.Enabled != nil {
+        rule.Enabled = *req.Enabled
+    }
+    return true
+}
+
+func (h *EscalationHandler) findManagedRule(c *gin.Context) (*models.EscalationRule, bool) {
+    var rule models.EscalationRule
+    if err := h.DB.First(&rule, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Escalation rule not found")
+        return nil, false
+    }
+
+    var project models.Project
+    if err := h.DB.First(&project, rule.ProjectID).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return nil, false
+    }
+
+    if !h.canManageProject(c, &project) {
+        return nil, false
+    }
+    return &rule, true
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"project-defect-service/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// floorPlanInfo - поля чертежа из content-service, нужные для проверки меток
+type floorPlanInfo struct {
+    ID         uint  `json:"id"`
+    ProjectID  uint  `json:"project_id"`
+    LocationID *uint `json:"location_id"`
+}
+
+// fetchFloorPlan запрашивает чертеж в content-service от имени текущего пользователя
+func (h *DefectHandler) fetchFloorPlan(c *gin.Context, planID uint) (*floorPlanInfo, error) {
+    var result struct {
+        Data struct {
+            Plan floorPlanInfo `json:"plan"`
+        } `json:"data"`
+    }
+
+    resp, err := h.Client.R().
+        SetHeader("Authorization", c.GetHeader("Authorization")).
+        SetResult(&result).
+        Get(fmt.Sprintf("%s/api/floor-plans/%d", h.ContentServiceURL, planID))
+    if err != nil {
+        return nil, fmt.Errorf("failed to fetch floor plan from content-service: %w", err)
+    }
+    if resp.StatusCode() == http.StatusNotFound {
+        return nil, nil
+    }
+    if resp.StatusCode() != http.StatusOK {
+        return nil, fmt.Errorf("content-service returned status: %d", resp.StatusCode())
+    }
+
+    return &result.Data.Plan, nil
+}
+
+// validatePlanPin проверяет метку на чертеже: чертеж из того же проекта,
+// координаты заданы парой
+func (h *DefectHandler) validatePlanPin(c *gin.Context, projectID uint, planID *uint, x, y *float64) (int, error) {
+    if planID == nil || *planID == 0 {
+        if x != nil || y != nil {
+            return http.StatusBadRequest, fmt.Errorf("plan_x and plan_y require plan_id")
+        }
+        return 0, nil
+    }
+    if x == nil || y == nil {
+        return http.StatusBadRequest, fmt.Errorf("plan_x and plan_y are required for a plan pin")
+    }
+
+    plan, err := h.fetchFloorPlan(c, *planID)
+    if err != nil {
+        return http.StatusBadGateway, err
+    }
+    if plan == nil || plan.ProjectID != projectID {
+        return http.StatusBadRequest, fmt.Errorf("floor plan not found in this project")
+    }
+    return 0, nil
+}
+
+// GetPlanPins - метки дефектов на чертеже со статусом и приоритетом.
+// Поддерживает те же фильтры, что и список дефектов (например, status).
+func (h *DefectHandler) GetPlanPins(c *gin.Context) {
+    planID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+    if err != nil {
+        h.badRequest(c, "Invalid plan ID")
+        return
+    }
+
+    query, err := h.applyDefectFilters(c, h.scopeDefects(c, h.DB.Model(&models.Defect{})))
+    if err != nil {
+        h.badRequest(c, err.Error())
+        return
+    }
+
+    var pins []models.PlanPin
+    if err := query.
+        Select("defects.id AS defect_id, defects.key, defects.title, defects.status, defects.priority, defects.plan_x AS x, defects.plan_y AS y").
+        Where("defects.plan_id = ? AND defects.plan_x IS NOT NULL AND defects.plan_y IS NOT NULL", planID).
+        Order("defects.id").
+        Scan(&pins).Error; err != nil {
+        h.internalError(c, "Failed to fetch plan pins")
+        return
+    }
+
+    h.success(c, gin.H{
+        "plan_id": planID,
+        "pins":    pins,
+    }, "Plan pins retrieved successfully")
+}
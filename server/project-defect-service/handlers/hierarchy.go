@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"project-defect-service/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// managesProject - пользователи с ролью manager, менеджер проекта и менеджеры
+// вышестоящих портфелей и программ: права на родителя наследуются потомками
+func (h *Handler) managesProject(userID uint, userRole string, project *models.Project) bool {
+    if userRole == "manager" || project.ManagerID == userID {
+        return true
+    }
+    ancestorIDs := pathIDs(project.Path)
+    if len(ancestorIDs) == 0 {
+        return false
+    }
+    var count int64
+    h.DB.Model(&models.Project{}).Where("id IN ? AND manager_id = ?", ancestorIDs, userID).Count(&count)
+    return count > 0
+}
+
+// findProjectParent проверяет нового родителя проекта уровня level. nil - корень.
+// path - путь переносимого проекта, чтобы не перенести его внутрь самого себя
+func (h *Handler) findProjectParent(parentID *uint, level models.ProjectLevel, path string) (*models.Project, error) {
+    var parentLevel models.ProjectLevel
+    var parent *models.Project
+    if parentID != nil && *parentID != 0 {
+        parent = &models.Project{}
+        if err := h.DB.First(parent, *parentID).Error; err != nil {
+            return nil, fmt.Errorf("parent project not found")
+        }
+        if parent.IsTemplate {
+            return nil, fmt.Errorf("project templates cannot contain projects")
+        }
+        if path != "" && strings.HasPrefix(parent.Path, path) {
+            return nil, fmt.Errorf("project cannot be moved inside itself")
+        }
+        parentLevel = parent.Level
+    }
+    if !level.CanBeChildOf(parentLevel) {
+        return nil, fmt.Errorf("%s cannot be placed here", level)
+    }
+    return parent, nil
+}
+
+// projectPath - путь проекта внутри parent (nil - корень)
+func projectPath(parent *models.Project, projectID uint) string {
+    parentPath := "/"
+    if parent != nil {
+        parentPath = parent.Path
+    }
+    return fmt.Sprintf("%s%d/", parentPath, projectID)
+}
+
+// MoveProject - перенос проекта со всем поддеревом в другой портфель или программу
+func (h *ProjectHandler) MoveProject(c *gin.Context) {
+    var project models.Project
+    if err := h.DB.First(&project, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+
+    userID, userRole, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return
+    }
+    if !h.managesProject(userID, userRole, &project) {
+        h.error(c, http.StatusForbidden, "You can only move your own projects")
+        return
+    }
+
+    var req models.ProjectMoveRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    if !h.checkIfMatch(c, project.Version, func() gin.H { return gin.H{"project": project} }) {
+        return
+    }
+    if project.IsTemplate {
+        h.badRequest(c, "Project templates cannot be placed in the hierarchy")
+        return
+    }
+
+    parent, err := h.findProjectParent(req.ParentID, project.Level, project.Path)
+    if err != nil {
+        h.badRequest(c, err.Error())
+        return
+    }
+    // Права в новом месте иерархии тоже нужны: иначе проект можно вывести из-под контроля
+    if parent != nil && !h.managesProject(userID, userRole, parent) {
+        h.error(c, http.StatusForbidden, "You can only move projects into your own portfolios and programs")
+        return
+    }
+
+    before := project
+    oldPath := project.Path
+    project.ParentID = nil
+    if parent != nil {
+        project.ParentID = &parent.ID
+    }
+    project.Path = projectPath(parent, project.ID)
+
+    err = h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := saveVersioned(tx, &before, &project, &project.Version); err != nil {
+            return err
+        }
+        if project.Path == oldPath {
+            return nil
+        }
+        // Переписываем пути всего поддерева, включая проекты в корзине
+        return tx.Unscoped().Model(&models.Project{}).
+            Where("path LIKE ? AND id <> ?", oldPath+"%", project.ID).
+            Update("path", gorm.Expr("? || SUBSTRING(path FROM ?)", project.Path, len(oldPath)+1)).Error
+    })
+    if errors.Is(err, errVersionConflict) {
+        var current models.Project
+        h.DB.First(&current, project.ID)
+        h.respondVersionConflict(c, current.Version, gin.H{"project": current})
+        return
+    }
+    if err != nil {
+        h.internalError(c, "Failed to move project")
+        return
+    }
+
+    setETag(c, project.Version)
+    h.success(c, gin.H{
+        "project": project,
+    }, "Project moved successfully")
+}
+
+// GetProjectRollup - дерево поддерева проекта со сводкой по дефектам:
+// own - дефекты самого узла, total - вместе со всеми потомками
+func (h *ProjectHandler) GetProjectRollup(c *gin.Context) {
+    var root models.Project
+    if err := h.DB.First(&root, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+
+    // Родители идут раньше потомков: путь предка короче
+    var rows []models.ProjectSummary
+    if err := h.DB.Model(&models.Project{}).
+        Select(projectSummarySelect).
+        Joins("LEFT JOIN (?) AS stats ON stats.project_id = projects.id", h.projectStatsQuery(time.Now().UTC())).
+        Where("projects.path LIKE ? AND projects.is_template = ?", root.Path+"%", false).
+        Order("LENGTH(projects.path), projects.name").
+        Scan(&rows).Error; err != nil {
+        h.internalError(c, "Failed to fetch project roll-up")
+        return
+    }
+
+    nodes := make(map[uint]*models.ProjectRollup, len(rows))
+    var tree *models.ProjectRollup
+    for _, row := range rows {
+        node := &models.ProjectRollup{
+            ID:       row.ID,
+            Key:      row.Key,
+            Name:     row.Name,
+            Level:    row.Level,
+            Phase:    row.Phase,
+            ParentID: row.ParentID,
+            Own:      row.ProjectStats,
+            Children: []*models.ProjectRollup{},
+        }
+        nodes[row.ID] = node
+        if row.ID == root.ID {
+            tree = node
+        } else if row.ParentID != nil && nodes[*row.ParentID] != nil {
+            nodes[*row.ParentID].Children = append(nodes[*row.ParentID].Children, node)
+        }
+    }
+    if tree == nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+    sumRollup(tree)
+
+    h.success(c, gin.H{
+        "rollup": tree,
+    }, "Project roll-up retrieved successfully")
+}
+
+// sumRollup считает итоги узла по его поддереву
+func sumRollup(node *models.ProjectRollup) models.ProjectStats {
+    node.Total = node.Own
+    for _, child := range node.Children {
+        node.Total.Add(sumRollup(child))
+    }
+    return node.Total
+}
+
+// projectAncestors - портфель и программа проекта от корня, для навигации
+func (h *Handler) projectAncestors(project *models.Project) ([]models.Project, error) {
+    ancestors := []models.Project{}
+    ids := pathIDs(project.Path)
+    if len(ids) <= 1 {
+        return ancestors, nil
+    }
+    if err := h.DB.Select("id", "key", "name", "level", "phase", "parent_id", "path").
+        Where("id IN ?", ids[:len(ids)-1]).
+        Order("LENGTH(path)").
+        Find(&ancestors).Error; err != nil {
+        return nil, err
+    }
+    return ancestors, nil
+}
@@ -0,0 +1,340 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"project-defect-service/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Поля, изменения которых можно отменить: их значения в истории однозначно
+// переводятся обратно в запрос на изменение дефекта
+var revertibleFields = map[string]bool{
+    "title":       true,
+    "description": true,
+    "status":      true,
+    "priority":    true,
+    "deadline":    true,
+    "assignee":    true,
+    "contractor":  true,
+    "category":    true,
+    "location":    true,
+    "parent":      true,
+}
+
+// historyRecorder собирает изменения одного запроса в набор с общим ChangeSetID.
+// Записи сохраняются методом save в транзакции изменения дефекта.
+type historyRecorder struct {
+    defectID  uint
+    userID    uint
+    changeSet string
+    revertOf  string
+    entries   []models.DefectHistory
+}
+
+func newHistoryRecorder(defectID, userID uint) *historyRecorder {
+    return &historyRecorder{
+        defectID:  defectID,
+        userID:    userID,
+        changeSet: models.NewChangeSetID(),
+    }
+}
+
+func (r *historyRecorder) add(field, oldValue, newValue string) {
+    r.entries = append(r.entries, models.DefectHistory{
+        DefectID:    r.defectID,
+        Field:       field,
+        OldValue:    oldValue,
+        NewValue:    newValue,
+        ChangedBy:   r.userID,
+        ChangeSetID: r.changeSet,
+        RevertOf:    r.revertOf,
+    })
+}
+
+func (r *historyRecorder) save(tx *gorm.DB) error {
+    if len(r.entries) == 0 {
+        return nil
+    }
+    return tx.Create(&r.entries).Error
+}
+
+// recordDefectChange пишет одиночное изменение отдельным набором через
+// переданное соединение, чтобы включить его в транзакцию изменения
+func recordDefectChange(db *gorm.DB, defectID uint, userID uint, field string, oldValue, newValue string) error {
+    history := newHistoryRecorder(defectID, userID)
+    history.add(field, oldValue, newValue)
+    return history.save(db)
+}
+
+// GetDefectHistory - история дефекта по наборам изменений, новые сначала
+func (h *DefectHandler) GetDefectHistory(c *gin.Context) {
+    defect, _, err := h.findDefect(h.scopeDefects(c, h.DB), c.Param("id"))
+    if err != nil {
+        h.notFound(c, "Defect not found")
+        return
+    }
+
+    page, pageSize := h.getPaginationParams(c)
+
+    var total int64
+    if err := h.DB.Model(&models.DefectHistory{}).
+        Where("defect_id = ?", defect.ID).
+        Distinct("change_set_id").
+        Count(&total).Error; err != nil {
+        h.internalError(c, "Failed to fetch history")
+        return
+    }
+
+    var setIDs []string
+    if err := h.DB.Model(&models.DefectHistory{}).
+        Select("change_set_id").
+        Where("defect_id = ?", defect.ID).
+        Group("change_set_id").
+        Order("MIN(id) DESC").
+        Offset((page - 1) * pageSize).
+        Limit(pageSize).
+        Pluck("change_set_id", &setIDs).Error; err != nil {
+        h.internalError(c, "Failed to fetch history")
+        return
+    }
+
+    var entries []models.DefectHistory
+    if len(setIDs) > 0 {
+        if err := h.DB.Where("defect_id = ? AND change_set_id IN ?", defect.ID, setIDs).
+            Order("id").
+            Find(&entries).Error; err != nil {
+            h.internalError(c, "Failed to fetch history")
+            return
+        }
+    }
+
+    changeSets := groupChangeSets(entries, setIDs)
+    h.resolveActorNames(c, changeSets)
+
+    h.success(c, gin.H{
+        "history": changeSets,
+        "pagination": gin.H{
+            "page":        page,
+            "page_size":   pageSize,
+            "total":       total,
+            "total_pages": (int(total) + pageSize - 1) / pageSize,
+        },
+    }, "Defect history retrieved successfully")
+}
+
+// RevertChangeSet отменяет набор изменений, возвращая поля к прежним значениям.
+// Если поле с тех пор меняли, отмена отклоняется с текущим состоянием дефекта.
+// Как и правка дефекта, требует If-Match с текущей версией.
+func (h *DefectHandler) RevertChangeSet(c *gin.Context) {
+    defect, _, err := h.findDefect(h.scopeDefects(c, h.DB), c.Param("id"))
+    if err != nil {
+        h.notFound(c, "Defect not found")
+        return
+    }
+
+    userID, _, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return
+    }
+
+    if !h.checkIfMatch(c, defect.Version, h.currentDefectState(defect.ID)) {
+        return
+    }
+
+    var entries []models.DefectHistory
+    if err := h.DB.Where("defect_id = ? AND change_set_id = ?", defect.ID, c.Param("change_set_id")).
+        Order("id").
+        Find(&entries).Error; err != nil {
+        h.internalError(c, "Failed to fetch history")
+        return
+    }
+    if len(entries) == 0 {
+        h.notFound(c, "Change set not found")
+        return
+    }
+
+    var req models.DefectUpdateRequest
+    var unsupported, changed []string
+    for _, entry := range entries {
+        if !revertibleFields[entry.Field] {
+            unsupported = append(unsupported, entry.Field)
+            continue
+        }
+        if currentHistoryValue(defect, entry.Field) != entry.NewValue {
+            changed = append(changed, entry.Field)
+            continue
+        }
+        if err := setRevertValue(&req, entry.Field, entry.OldValue); err != nil {
+            h.badRequest(c, err.Error())
+            return
+        }
+    }
+    if len(unsupported) > 0 {
+        h.badRequest(c, "Change set contains fields that cannot be reverted: "+strings.Join(unsupported, ", "))
+        return
+    }
+    if len(changed) > 0 {
+        h.attachDefectDetail(defect)
+        c.JSON(http.StatusConflict, Response{
+            Success: false,
+            Error:   "Fields were changed after this change set: " + strings.Join(changed, ", "),
+            Data: gin.H{
+                "defect": defect,
+            },
+        })
+        return
+    }
+
+    history := newHistoryRecorder(defect.ID, userID)
+    history.revertOf = entries[0].ChangeSetID
+    if !h.applyDefectUpdate(c, defect, &req, history) {
+        return
+    }
+
+    h.DB.Preload("History").Preload("Location").Preload("Category").Preload("Labels").First(defect, defect.ID)
+    h.attachDefectDetail(defect)
+
+    setETag(c, defect.Version)
+    h.success(c, gin.H{
+        "defect":        defect,
+        "change_set_id": history.changeSet,
+    }, "Change set reverted successfully")
+}
+
+// resolveActorNames подставляет имена авторов изменений из auth-service.
+// Без ответа auth-service история отдается с ID авторов.
+func (h *DefectHandler) resolveActorNames(c *gin.Context, changeSets []models.DefectChangeSet) {
+    if len(changeSets) == 0 {
+        return
+    }
+
+    users, err := h.fetchUsers(c)
+    if err != nil {
+        log.Printf("Failed to resolve history actors: %v", err)
+    }
+    for i := range changeSets {
+        if changeSets[i].ActorID == models.SystemActorID {
+            changeSets[i].ActorName = "System"
+        } else if user, ok := users[changeSets[i].ActorID]; ok {
+            changeSets[i].ActorName = user.FullName
+        }
+    }
+}
+
+// groupChangeSets собирает записи в наборы в порядке setIDs
+func groupChangeSets(entries []models.DefectHistory, setIDs []string) []models.DefectChangeSet {
+    byID := make(map[string]*models.DefectChangeSet, len(setIDs))
+    for _, entry := range entries {
+        set := byID[entry.ChangeSetID]
+        if set == nil {
+            set = &models.DefectChangeSet{
+                ID:         entry.ChangeSetID,
+                CreatedAt:  entry.CreatedAt,
+                ActorID:    entry.ChangedBy,
+                RevertOf:   entry.RevertOf,
+                Revertible: true,
+            }
+            byID[entry.ChangeSetID] = set
+        }
+        set.Changes = append(set.Changes, models.FieldChange{
+            Field:    entry.Field,
+            OldValue: entry.OldValue,
+            NewValue: entry.NewValue,
+        })
+        if !revertibleFields[entry.Field] {
+            set.Revertible = false
+        }
+    }
+
+    changeSets := make([]models.DefectChangeSet, 0, len(setIDs))
+    for _, id := range setIDs {
+        if set := byID[id]; set != nil {
+            changeSets = append(changeSets, *set)
+        }
+    }
+    return changeSets
+}
+
+// currentHistoryValue - текущее значение поля в том виде, в каком его пишет история
+func currentHistoryValue(defect *models.Defect, field string) string {
+    switch field {
+    case "title":
+        return defect.Title
+    case "description":
+        return defect.Description
+    case "status":
+        return string(defect.Status)
+    case "priority":
+        return string(defect.Priority)
+    case "deadline":
+        return formatDeadline(defect.Deadline)
+    case "assignee":
+        return formatOptionalID(defect.AssigneeID)
+    case "contractor":
+        return formatOptionalID(defect.ContractorID)
+    case "category":
+        return formatOptionalID(defect.CategoryID)
+    case "location":
+        return formatOptionalID(defect.LocationID)
+    case "parent":
+        return formatOptionalID(defect.ParentID)
+    }
+    return ""
+}
+
+// setRevertValue переносит прежнее значение поля из истории в запрос на изменение
+func setRevertValue(req *models.DefectUpdateRequest, field, value string) error {
+    switch field {
+    case "title":
+        req.Title = &value
+    case "description":
+        req.Description = &value
+    case "status":
+        status := models.DefectStatus(value)
+        req.Status = &status
+    case "priority":
+        priority := models.DefectPriority(value)
+        req.Priority = &priority
+    case "deadline":
+        deadline := &models.Date{}
+        if value != "none" {
+            t, err := time.Parse("2006-01-02", value)
+            if err != nil {
+                return fmt.Errorf("invalid deadline in history: %s", value)
+            }
+            deadline.Time = t
+        }
+        req.Deadline = deadline
+    case "assignee", "contractor", "category", "location", "parent":
+        var id uint
+        if value != "none" {
+            parsed, err := strconv.ParseUint(value, 10, 32)
+            if err != nil {
+                return fmt.Errorf("invalid %s in history: %s", field, value)
+            }
+            id = uint(parsed)
+        }
+        switch field {
+        case "assignee":
+            req.AssigneeID = &id
+        case "contractor":
+            req.ContractorID = &id
+        case "category":
+            req.CategoryID = &id
+        case "location":
+            req.LocationID = &id
+        case "parent":
+            req.ParentID = &id
+        }
+    }
+    return nil
+}
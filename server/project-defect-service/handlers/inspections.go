@@ -0,0 +1,574 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"project-defect-service/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type InspectionHandler struct {
+    Handler
+}
+
+func NewInspectionHandler(db *gorm.DB, jwtSecret, authServiceURL string) *InspectionHandler {
+    return &InspectionHandler{
+        Handler: *NewHandler(db, jwtSecret, authServiceURL),
+    }
+}
+
+func (h *InspectionHandler) GetInspectionSchedules(c *gin.Context) {
+    schedules := []models.InspectionSchedule{}
+    if err := h.DB.Where("project_id = ?", c.Param("id")).Order("id").Find(&schedules).Error; err != nil {
+        h.internalError(c, "Failed to fetch inspection schedules")
+        return
+    }
+
+    h.success(c, gin.H{
+        "inspection_schedules": schedules,
+    }, "Inspection schedules retrieved successfully")
+}
+
+func (h *InspectionHandler) CreateInspectionSchedule(c *gin.Context) {
+    var project models.Project
+    if err := h.DB.First(&project, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+
+    if !h.canManageProject(c, &project) {
+        return
+    }
+
+    var req models.InspectionScheduleRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    // По умолчанию правило включено; enabled=false из запроса применяется ниже
+    schedule := models.InspectionSchedule{ProjectID: project.ID, Enabled: true}
+    if !h.applyInspectionSchedule(c, &schedule, &req) {
+        return
+    }
+
+    if err := h.DB.Create(&schedule).Error; err != nil {
+        h.internalError(c, "Failed to create inspection schedule")
+        return
+    }
+
+    h.success(c, gin.H{
+        "inspection_schedule": schedule,
+    }, "Inspection schedule created successfully")
+}
+
+// UpdateInspectionSchedule - изменение правила; уже созданные обходы не меняются
+func (h *InspectionHandler) UpdateInspectionSchedule(c *gin.Context) {
+    schedule, ok := h.findManagedSchedule(c)
+    if !ok {
+        return
+    }
+
+    var req models.InspectionScheduleRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    if !h.applyInspectionSchedule(c, schedule, &req) {
+        return
+    }
+
+    if err := h.DB.Save(schedule).Error; err != nil {
+        h.internalError(c, "Failed to update inspection schedule")
+        return
+    }
+
+    h.success(c, gin.H{
+        "inspection_schedule": schedule,
+    }, "Inspection schedule updated successfully")
+}
+
+func (h *InspectionHandler) DeleteInspectionSchedule(c *gin.Context) {
+    schedule, ok := h.findManagedSchedule(c)
+    if !ok {
+        return
+    }
+
+    if err := h.DB.Delete(schedule).Error; err != nil {
+        h.internalError(c, "Failed to delete inspection schedule")
+        return
+    }
+
+    h.success(c, nil, "Inspection schedule deleted successfully")
+}
+
+// GetInspectionRounds - обходы проекта, фильтры status, from и to (по дате обхода)
+func (h *InspectionHandler) GetInspectionRounds(c *gin.Context) {
+    query := h.DB.Where("project_id = ?", c.Param("id"))
+    if status := c.Query("status"); status != "" {
+        query = query.Where("status = ?", status)
+    }
+    for _, bound := range []struct{ param, op string }{{"from", ">="}, {"to", "<="}} {
+        value := c.Query(bound.param)
+        if value == "" {
+            continue
+        }
+        date, err := time.Parse("2006-01-02", value)
+        if err != nil {
+            h.badRequest(c, bound.param+" must be a date in YYYY-MM-DD format")
+            return
+        }
+        query = query.Where("scheduled_date "+bound.op+" ?", date)
+    }
+
+    rounds := []models.InspectionRound{}
+    if err := query.Preload("Location").Order("scheduled_date DESC, id DESC").Find(&rounds).Error; err != nil {
+        h.internalError(c, "Failed to fetch inspection rounds")
+        return
+    }
+
+    h.success(c, gin.H{
+        "inspection_rounds": rounds,
+    }, "Inspection rounds retrieved successfully")
+}
+
+// CreateInspectionRound - разовый обход, назначенный менеджером проекта
+func (h *InspectionHandler) CreateInspectionRound(c *gin.Context) {
+    var project models.Project
+    if err := h.DB.First(&project, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+
+    if !h.canManageProject(c, &project) {
+        return
+    }
+
+    var req models.InspectionRoundRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+    if req.LocationID != nil && *req.LocationID == 0 {
+        req.LocationID = nil
+    }
+    if err := h.validateDefectLocation(project.ID, req.LocationID); err != nil {
+        h.badRequest(c, err.Error())
+        return
+    }
+    if status, err := h.checkInspectors(c, req.InspectorIDs); err != nil {
+        h.error(c, status, err.Error())
+        return
+    }
+
+    round := models.InspectionRound{
+        ProjectID:     project.ID,
+        Title:         req.Title,
+        LocationID:    req.LocationID,
+        ScheduledDate: *req.ScheduledDate,
+        Status:        models.InspectionPlanned,
+        InspectorIDs:  dedupeIDs(req.InspectorIDs),
+        Items:         models.NewInspectionItems(req.Checklist),
+    }
+    if err := h.DB.Create(&round).Error; err != nil {
+        h.internalError(c, "Failed to create inspection round")
+        return
+    }
+
+    h.respondWithRound(c, round.ID, "Inspection round created successfully")
+}
+
+func (h *InspectionHandler) GetInspectionRound(c *gin.Context) {
+    var round models.InspectionRound
+    if err := h.DB.First(&round, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Inspection round not found")
+        return
+    }
+
+    h.respondWithRound(c, round.ID, "Inspection round retrieved successfully")
+}
+
+// UpdateInspectionRoundStatus - начало, завершение или отмена обхода. Менять статус
+// могут назначенные инспекторы и менеджер проекта; завершить можно обход, у всех
+// пунктов чек-листа которого есть результат
+func (h *InspectionHandler) UpdateInspectionRoundStatus(c *gin.Context) {
+    round, ok := h.findInspectableRound(c)
+    if !ok {
+        return
+    }
+
+    var req models.InspectionStatusRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    if !round.Status.CanTransitionTo(req.Status) {
+        h.error(c, http.StatusConflict, fmt.Sprintf("Cannot change inspection round status from %s to %s", round.Status, req.Status))
+        return
+    }
+    if req.Status == models.InspectionCompleted {
+        var pending int64
+        if err := h.DB.Model(&models.InspectionItem{}).
+            Where("round_id = ? AND result = ?", round.ID, models.ResultPending).
+            Count(&pending).Error; err != nil {
+            h.internalError(c, "Failed to check inspection checklist")
+            return
+        }
+        if pending > 0 {
+            h.error(c, http.StatusConflict, fmt.Sprintf("%d checklist items have no result", pending))
+            return
+        }
+    }
+
+    userID, _, _ := h.GetUserFromContext(c)
+    now := time.Now().UTC()
+    updates := map[string]interface{}{"status": req.Status}
+    switch req.Status {
+    case models.InspectionInProgress:
+        updates["started_at"] = now
+    case models.InspectionCompleted:
+        updates["completed_at"] = now
+        updates["completed_by"] = userID
+        updates["summary"] = req.Summary
+    case models.InspectionCancelled:
+        updates["summary"] = req.Summary
+    }
+
+    // Условие по статусу защищает от одновременной смены статуса двумя инспекторами
+    result := h.DB.Model(&models.InspectionRound{}).
+        Where("id = ? AND status = ?", round.ID, round.Status).
+        Updates(updates)
+    if result.Error != nil {
+        h.internalError(c, "Failed to update inspection round")
+        return
+    }
+    if result.RowsAffected == 0 {
+        h.error(c, http.StatusConflict, "Inspection round status has already changed")
+        return
+    }
+
+    h.respondWithRound(c, round.ID, "Inspection round status updated successfully")
+}
+
+// UpdateInspectionItem - результат пункта чек-листа во время обхода
+func (h *InspectionHandler) UpdateInspectionItem(c *gin.Context) {
+    round, ok := h.findInspectableRound(c)
+    if !ok {
+        return
+    }
+    if round.Status != models.InspectionInProgress {
+        h.error(c, http.StatusConflict, "Results can only be recorded while the inspection is in progress")
+        return
+    }
+
+    var item models.InspectionItem
+    if err := h.DB.Where("round_id = ?", round.ID).First(&item, c.Param("item_id")).Error; err != nil {
+        h.notFound(c, "Checklist item not found")
+        return
+    }
+
+    var req models.InspectionItemRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    userID, _, _ := h.GetUserFromContext(c)
+    item.Result = req.Result
+    item.Note = req.Note
+    if req.Result == models.ResultPending {
+        item.CheckedBy = nil
+        item.CheckedAt = nil
+    } else {
+        now := time.Now().UTC()
+        item.CheckedBy = &userID
+        item.CheckedAt = &now
+    }
+
+    if err := h.DB.Save(&item).Error; err != nil {
+        h.internalError(c, "Failed to update checklist item")
+        return
+    }
+
+    h.success(c, gin.H{
+        "item": item,
+    }, "Checklist item updated successfully")
+}
+
+// DeleteInspectionRound - удалить можно только не начатый обход без дефектов
+func (h *InspectionHandler) DeleteInspectionRound(c *gin.Context) {
+    var round models.InspectionRound
+    if err := h.DB.First(&round, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Inspection round not found")
+        return
+    }
+
+    var project models.Project
+    if err := h.DB.First(&project, round.ProjectID).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+    if !h.canManageProject(c, &project) {
+        return
+    }
+
+    if round.Status != models.InspectionPlanned {
+        h.error(c, http.StatusConflict, "Only planned inspection rounds can be deleted")
+        return
+    }
+
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Where("round_id = ?", round.ID).Delete(&models.InspectionItem{}).Error; err != nil {
+            return err
+        }
+        return tx.Delete(&round).Error
+    })
+    if err != nil {
+        h.internalError(c, "Failed to delete inspection round")
+        return
+    }
+
+    h.success(c, nil, "Inspection round deleted successfully")
+}
+
+// GetInspectionSummary - обходы проекта и вложенных проектов с найденными и
+// закрытыми дефектами, итоги по статусам обходов. Фильтры from и to
+func (h *InspectionHandler) GetInspectionSummary(c *gin.Context) {
+    var project models.Project
+    if err := h.DB.First(&project, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+
+    query := h.DB.Model(&models.InspectionRound{}).
+        Joins("JOIN projects ON projects.id = inspection_rounds.project_id").
+        Joins("LEFT JOIN defects ON defects.inspection_round_id = inspection_rounds.id AND defects.deleted_at IS NULL").
+        Where("projects.path LIKE ?", project.Path+"%")
+    for _, bound := range []struct{ param, op string }{{"from", ">="}, {"to", "<="}} {
+        value := c.Query(bound.param)
+        if value == "" {
+            continue
+        }
+        date, err := time.Parse("2006-01-02", value)
+        if err != nil {
+            h.badRequest(c, bound.param+" must be a date in YYYY-MM-DD format")
+            return
+        }
+        query = query.Where("inspection_rounds.scheduled_date "+bound.op+" ?", date)
+    }
+
+    rows := []models.InspectionSummaryRow{}
+    if err := query.
+        Select(`inspection_rounds.id, inspection_rounds.title, inspection_rounds.scheduled_date, inspection_rounds.status,
+            (SELECT COUNT(*) FROM inspection_items WHERE inspection_items.round_id = inspection_rounds.id
+                AND inspection_items.result = ? AND inspection_items.deleted_at IS NULL) AS items_failed,
+            COUNT(defects.id) AS defects_found,
+            COUNT(defects.id) FILTER (WHERE defects.status = ?) AS defects_closed`,
+            models.ResultFail, models.StatusClosed).
+        Group("inspection_rounds.id").
+        Order("inspection_rounds.scheduled_date DESC, inspection_rounds.id DESC").
+        Scan(&rows).Error; err != nil {
+        h.internalError(c, "Failed to build inspection summary")
+        return
+    }
+
+    var totals struct {
+        Rounds        map[models.InspectionStatus]int64 `json:"rounds"`
+        ItemsFailed   int64                             `json:"items_failed"`
+        DefectsFound  int64                             `json:"defects_found"`
+        DefectsClosed int64                             `json:"defects_closed"`
+    }
+    totals.Rounds = map[models.InspectionStatus]int64{}
+    for _, row := range rows {
+        totals.Rounds[row.Status]++
+        totals.ItemsFailed += row.ItemsFailed
+        totals.DefectsFound += row.DefectsFound
+        totals.DefectsClosed += row.DefectsClosed
+    }
+
+    h.success(c, gin.H{
+        "rounds": rows,
+        "totals": totals,
+    }, "Inspection summary generated successfully")
+}
+
+// validateInspectionRound проверяет обход нового дефекта: обход идет в проекте
+// дефекта, а место дефекта, если указано, входит в зону обхода
+func (h *Handler) validateInspectionRound(projectID uint, roundID, locationID *uint) error {
+    if roundID == nil {
+        return nil
+    }
+
+    var round models.InspectionRound
+    if err := h.DB.Where("project_id = ?", projectID).First(&round, *roundID).Error; err != nil {
+        return fmt.Errorf("inspection round not found in the project")
+    }
+    if round.Status != models.InspectionInProgress {
+        return fmt.Errorf("defects can only be raised during an inspection in progress")
+    }
+    if round.LocationID != nil && locationID != nil {
+        var count int64
+        h.DB.Model(&models.Location{}).
+            Where("id = ? AND id IN (?)", *locationID, h.subtreeIDs(&models.Location{}, *round.LocationID)).
+            Count(&count)
+        if count == 0 {
+            return fmt.Errorf("defect location is outside the inspection area")
+        }
+    }
+    return nil
+}
+
+// checkInspectors проверяет инспекторов: пользователи существуют, не отключены
+// и не являются сотрудниками подрядчиков
+func (h *Handler) checkInspectors(c *gin.Context, inspectorIDs []uint) (int, error) {
+    users, err := h.fetchUsers(c)
+    if err != nil {
+        return http.StatusInternalServerError, fmt.Errorf("failed to check inspectors: %w", err)
+    }
+    for _, id := range inspectorIDs {
+        user, ok := users[id]
+        if !ok {
+            return http.StatusBadRequest, fmt.Errorf("inspector %d not found", id)
+        }
+        if !user.Active {
+            return http.StatusBadRequest, fmt.Errorf("inspector %d account is deactivated", id)
+        }
+        if user.RoleName == models.RoleContractor {
+            return http.StatusBadRequest, fmt.Errorf("contractor members cannot be inspectors")
+        }
+    }
+    return http.StatusOK, nil
+}
+
+// applyInspectionSchedule переносит поля запроса в правило; первая дата обхода -
+// start_date, она не может быть позже end_date
+func (h *InspectionHandler) applyInspectionSchedule(c *gin.Context, schedule *models.InspectionSchedule, req *models.InspectionScheduleRequest) bool {
+    if req.LocationID != nil && *req.LocationID == 0 {
+        req.LocationID = nil
+    }
+    if err := h.validateDefectLocation(schedule.ProjectID, req.LocationID); err != nil {
+        h.badRequest(c, err.Error())
+        return false
+    }
+    if req.EndDate != nil && req.EndDate.IsZero() {
+        req.EndDate = nil
+    }
+    if req.EndDate != nil && req.EndDate.Before(req.StartDate.Time) {
+        h.badRequest(c, "end_date must not be before start_date")
+        return false
+    }
+    if status, err := h.checkInspectors(c, req.InspectorIDs); err != nil {
+        h.error(c, status, err.Error())
+        return false
+    }
+
+    schedule.Name = req.Name
+    schedule.LocationID = req.LocationID
+    schedule.InspectorIDs = dedupeIDs(req.InspectorIDs)
+    schedule.Checklist = req.Checklist
+    schedule.Recurrence = req.Recurrence
+    schedule.Interval = req.Interval
+    if schedule.Interval == 0 {
+        schedule.Interval = 1
+    }
+    schedule.NextDate = *req.StartDate
+    schedule.EndDate = req.EndDate
+    if req.Enabled != nil {
+        schedule.Enabled = *req.Enabled
+    }
+    return true
+}
+
+func (h *InspectionHandler) findManagedSchedule(c *gin.Context) (*models.InspectionSchedule, bool) {
+    var schedule models.InspectionSchedule
+    if err := h.DB.First(&schedule, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Inspection schedule not found")
+        return nil, false
+    }
+
+    var project models.Project
+    if err := h.DB.First(&project, schedule.ProjectID).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return nil, false
+    }
+
+    if !h.canManageProject(c, &project) {
+        return nil, false
+    }
+    return &schedule, true
+}
+
+// findInspectableRound - обход из пути, если текущий пользователь его инспектор
+// или менеджер проекта, а проект доступен для изменения
+func (h *InspectionHandler) findInspectableRound(c *gin.Context) (*models.InspectionRound, bool) {
+    var round models.InspectionRound
+    if err := h.DB.First(&round, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Inspection round not found")
+        return nil, false
+    }
+
+    userID, userRole, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return nil, false
+    }
+    var project models.Project
+    if err := h.DB.First(&project, round.ProjectID).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return nil, false
+    }
+    if !round.HasInspector(userID) && !h.managesProject(userID, userRole, &project) {
+        h.error(c, http.StatusForbidden, "Only assigned inspectors can conduct this inspection")
+        return nil, false
+    }
+    if project.Phase.IsReadOnly() {
+        h.respondProjectReadOnly(c, &project)
+        return nil, false
+    }
+    return &round, true
+}
+
+// respondWithRound отдает обход с чек-листом и найденными на нем дефектами
+func (h *InspectionHandler) respondWithRound(c *gin.Context, roundID uint, message string) {
+    var round models.InspectionRound
+    err := h.DB.Preload("Location").
+        Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("position, id") }).
+        First(&round, roundID).Error
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        h.notFound(c, "Inspection round not found")
+        return
+    }
+    if err != nil {
+        h.internalError(c, "Failed to fetch inspection round")
+        return
+    }
+
+    defects := []models.Defect{}
+    if err := h.scopeDefects(c, h.DB).
+        Where("inspection_round_id = ?", round.ID).
+        Order("id").
+        Find(&defects).Error; err != nil {
+        h.internalError(c, "Failed to fetch inspection defects")
+        return
+    }
+
+    h.success(c, gin.H{
+        "inspection_round": round,
+        "defects":          defects,
+    }, message)
+}
+
+// dedupeIDs убирает повторы, сохраняя порядок
+func dedupeIDs(ids []uint) []uint {
+    seen := make(map[uint]bool, len(ids))
+    result := make([]uint, 0, len(ids))
+    for _, id := range ids {
+        if !seen[id] {
+            seen[id] = true
+            result = append(result, id)
+        }
+    }
+    return result
+}
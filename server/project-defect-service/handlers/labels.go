@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"project-defect-service/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type LabelHandler struct {
+    Handler
+}
+
+func NewLabelHandler(db *gorm.DB, jwtSecret, authServiceURL string) *LabelHandler {
+    return &LabelHandler{
+        Handler: *NewHandler(db, jwtSecret, authServiceURL),
+    }
+}
+
+func (h *LabelHandler) GetLabels(c *gin.Context) {
+    var labels []models.Label
+    if err := h.DB.Where("project_id = ?", c.Param("id")).Order("name").Find(&labels).Error; err != nil {
+        h.internalError(c, "Failed to fetch labels")
+        return
+    }
+
+    h.success(c, gin.H{
+        "labels": labels,
+    }, "Labels retrieved successfully")
+}
+
+func (h *LabelHandler) CreateLabel(c *gin.Context) {
+    var project models.Project
+    if err := h.DB.First(&project, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+
+    if !h.canManageProject(c, &project) {
+        return
+    }
+
+    var req models.LabelRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    label := models.Label{
+        ProjectID: project.ID,
+        Name:      strings.TrimSpace(req.Name),
+        Color:     req.Color,
+    }
+
+    var existing int64
+    if err := h.DB.Model(&models.Label{}).Where("project_id = ? AND name = ?", project.ID, label.Name).Count(&existing).Error; err != nil {
+        h.internalError(c, "Failed to check label name")
+        return
+    }
+    if existing > 0 {
+        h.error(c, http.StatusConflict, "Label with this name already exists in the project")
+        return
+    }
+
+    if err := h.DB.Create(&label).Error; err != nil {
+        if isUniqueViolation(err) {
+            h.error(c, http.StatusConflict, "Label with this name already exists in the project")
+            return
+        }
+        h.internalError(c, "Failed to create label")
+        return
+    }
+
+    h.success(c, gin.H{
+        "label": label,
+    }, "Label created successfully")
+}
+
+func (h *LabelHandler) UpdateLabel(c *gin.Context) {
+    label, ok := h.findManagedLabel(c)
+    if !ok {
+        return
+    }
+
+    var req models.LabelRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    name := strings.TrimSpace(req.Name)
+    if name != label.Name {
+        var existing int64
+        if err := h.DB.Model(&models.Label{}).Where("project_id = ? AND name = ?", label.ProjectID, name).Count(&existing).Error; err != nil {
+            h.internalError(c, "Failed to check label name")
+            return
+        }
+        if existing > 0 {
+            h.error(c, http.StatusConflict, "Label with this name already exists in the project")
+            return
+        }
+    }
+
+    label.Name = name
+    if req.Color != "" {
+        label.Color = req.Color
+    }
+
+    if err := h.DB.Save(label).Error; err != nil {
+        if isUniqueViolation(err) {
+            h.error(c, http.StatusConflict, "Label with this name already exists in the project")
+            return
+        }
+        h.internalError(c, "Failed to update label")
+        return
+    }
+
+    h.success(c, gin.H{
+        "label": label,
+    }, "Label updated successfully")
+}
+
+func (h *LabelHandler) DeleteLabel(c *gin.Context) {
+    label, ok := h.findManagedLabel(c)
+    if !ok {
+        return
+    }
+
+    // Метка удаляется окончательно: иначе удаленная запись держит уникальное имя
+    // в проекте и метку с тем же именем нельзя создать заново
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Exec("DELETE FROM defect_labels WHERE label_id = ?", label.ID).Error; err != nil {
+            return err
+        }
+        return tx.Unscoped().Delete(label).Error
+    })
+    if err != nil {
+        h.internalError(c, "Failed to delete label")
+        return
+    }
+
+    h.success(c, nil, "Label deleted successfully")
+}
+
+// AddDefectLabels - добавление меток проекта к дефекту
+func (h *LabelHandler) AddDefectLabels(c *gin.Context) {
+    defect, ok := h.findWritableDefect(c, h.DB.Preload("Labels"))
+    if !ok {
+        return
+    }
+
+    userID, _, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return
+    }
+
+    var req models.DefectLabelsRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    var labels []models.Label
+    if err := h.DB.Where("id IN ? AND project_id = ?", req.LabelIDs, defect.ProjectID).Find(&labels).Error; err != nil {
+        h.internalError(c, "Failed to fetch labels")
+        return
+    }
+    if len(labels) != len(uniqueUints(req.LabelIDs)) {
+        h.badRequest(c, "Some labels were not found in the defect's project")
+        return
+    }
+
+    current, err := h.changeDefectLabels(defect, userID, func(tx *gorm.DB) error {
+        return tx.Model(defect).Association("Labels").Append(labels)
+    })
+    if err != nil {
+        h.internalError(c, "Failed to add labels")
+        return
+    }
+
+    h.success(c, gin.H{
+        "defect_id": defect.ID,
+        "labels":    current,
+    }, "Labels added successfully")
+}
+
+// RemoveDefectLabel - снятие метки с дефекта
+func (h *LabelHandler) RemoveDefectLabel(c *gin.Context) {
+    defect, ok := h.findWritableDefect(c, h.DB.Preload("Labels"))
+    if !ok {
+        return
+    }
+
+    userID, _, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return
+    }
+
+    var label models.Label
+    if err := h.DB.Where("project_id = ?", defect.ProjectID).First(&label, c.Param("label_id")).Error; err != nil {
+        h.notFound(c, "Label not found")
+        return
+    }
+
+    current, err := h.changeDefectLabels(defect, userID, func(tx *gorm.DB) error {
+        return tx.Model(defect).Association("Labels").Delete(&label)
+    })
+    if err != nil {
+        h.internalError(c, "Failed to remove label")
+        return
+    }
+
+    h.success(c, gin.H{
+        "defect_id": defect.ID,
+        "labels":    current,
+    }, "Label removed successfully")
+}
+
+// changeDefectLabels меняет метки дефекта и пишет историю в одной транзакции.
+// Возвращает метки дефекта после изменения.
+func (h *LabelHandler) changeDefectLabels(defect *models.Defect, userID uint, change func(tx *gorm.DB) error) ([]models.Label, error) {
+    oldLabels := labelNames(defect.Labels)
+
+    var labels []models.Label
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := change(tx); err != nil {
+            return err
+        }
+        if err := tx.Model(defect).Association("Labels").Find(&labels); err != nil {
+            return err
+        }
+        if newLabels := labelNames(labels); newLabels != oldLabels {
+            return recordDefectChange(tx, defect.ID, userID, "labels", oldLabels, newLabels)
+        }
+        return nil
+    })
+    return labels, err
+}
+
+func (h *LabelHandler) findManagedLabel(c *gin.Context) (*models.Label, bool) {
+    var label models.Label
+    if err := h.DB.First(&label, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Label not found")
+        return nil, false
+    }
+
+    var project models.Project
+    if err := h.DB.First(&project, label.ProjectID).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return nil, false
+    }
+
+    if !h.canManageProject(c, &project) {
+        return nil, false
+    }
+    return &label, true
+}
+
+// labelNames - отсортированный список имен меток для записи в историю
+func labelNames(labels []models.Label) string {
+    if len(labels) == 0 {
+        return "none"
+    }
+
+    names := make([]string, 0, len(labels))
+    for _, label := range labels {
+        names = append(names, label.Name)
+    }
+    sort.Strings(names)
+    return strings.Join(names, ", ")
+}
+
+func uniqueUints(values []uint) []uint {
+    seen := make(map[uint]bool, len(values))
+    var result []uint
+    for _, v := range values {
+        if !seen[v] {
+            seen[v] = true
+            result = append(result, v)
+        }
+    }
+    return result
+}
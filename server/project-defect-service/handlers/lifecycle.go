@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"project-defect-service/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// checkProjectWritable - архивный проект и его дефекты доступны только для чтения
+func (h *Handler) checkProjectWritable(c *gin.Context, projectID uint) bool {
+    var project models.Project
+    if err := h.DB.Select("id", "key", "phase").First(&project, projectID).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return false
+    }
+    if project.Phase.IsReadOnly() {
+        h.respondProjectReadOnly(c, &project)
+        return false
+    }
+    return true
+}
+
+func (h *Handler) respondProjectReadOnly(c *gin.Context, project *models.Project) {
+    h.error(c, http.StatusConflict, fmt.Sprintf("Project %s is archived and read-only", project.Key))
+}
+
+// validateProjectDates - окончание работ не раньше начала
+func validateProjectDates(start, end *models.Date) error {
+    if start == nil || end == nil || start.IsZero() || end.IsZero() {
+        return nil
+    }
+    if end.Before(start.Time) {
+        return fmt.Errorf("end_date must not be before start_date")
+    }
+    return nil
+}
+
+// checkHandover - объект не передается заказчику, пока открыты критичные дефекты
+func (h *Handler) checkHandover(project *models.Project) error {
+    var keys []string
+    if err := h.DB.Model(&models.Defect{}).
+        Where("project_id = ? AND priority = ? AND status IN ?", project.ID, models.PriorityCritical,
+            []models.DefectStatus{models.StatusNew, models.StatusInProgress, models.StatusOnReview}).
+        Order("number").
+        Pluck("key", &keys).Error; err != nil {
+        return err
+    }
+    if len(keys) > 0 {
+        return fmt.Errorf("project has %d open critical defects: %s", len(keys), strings.Join(keys, ", "))
+    }
+    return nil
+}
+
+// UpdateProjectPhase - перевод проекта на другой этап жизненного цикла
+func (h *ProjectHandler) UpdateProjectPhase(c *gin.Context) {
+    var project models.Project
+    if err := h.DB.First(&project, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+
+    userID, userRole, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return
+    }
+
+    if !h.managesProject(userID, userRole, &project) {
+        h.error(c, http.StatusForbidden, "You can only change the phase of your own projects")
+        return
+    }
+
+    var req models.ProjectPhaseRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    if !h.checkIfMatch(c, project.Version, func() gin.H { return gin.H{"project": project} }) {
+        return
+    }
+
+    if req.Phase != project.Phase {
+        if !project.Phase.CanTransitionTo(req.Phase) {
+            h.error(c, http.StatusConflict, fmt.Sprintf("Cannot move project from %s to %s", project.Phase, req.Phase))
+            return
+        }
+        if req.Phase == models.PhaseHandover {
+            if err := h.checkHandover(&project); err != nil {
+                h.error(c, http.StatusConflict, "Handover is blocked: "+err.Error())
+                return
+            }
+        }
+    }
+
+    before := project
+    project.Phase = req.Phase
+
+    err = saveVersioned(h.DB, &before, &project, &project.Version)
+    if errors.Is(err, errVersionConflict) {
+        var current models.Project
+        h.DB.First(&current, project.ID)
+        h.respondVersionConflict(c, current.Version, gin.H{"project": current})
+        return
+    }
+    if err != nil {
+        h.internalError(c, "Failed to update project phase")
+        return
+    }
+
+    setETag(c, project.Version)
+    h.success(c, gin.H{
+        "project": project,
+    }, "Project phase updated successfully")
+}
+
+// projectState - этап проекта для проверок в других сервисах
+func projectState(project *models.Project) gin.H {
+    return gin.H{
+        "project_id": project.ID,
+        "phase":      project.Phase,
+        "read_only":  project.Phase.IsReadOnly(),
+    }
+}
+
+// GetProjectState - внутренний маршрут: этап проекта и доступность для изменений
+func (h *ProjectHandler) GetProjectState(c *gin.Context) {
+    var project models.Project
+    if err := h.DB.First(&project, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+
+    h.success(c, projectState(&project), "Project state retrieved successfully")
+}
+
+// GetDefectProjectState - внутренний маршрут: этап проекта, которому принадлежит дефект
+func (h *ProjectHandler) GetDefectProjectState(c *gin.Context) {
+    // content-service передает пользователя запроса: сотруднику подрядчика
+    // дефекты чужих организаций не видны, как и в project-defect-service
+    db := h.DB
+    if userRole := c.Query("user_role"); userRole != "" {
+        userID, _ := strconv.ParseUint(c.Query("user_id"), 10, 32)
+        db = h.scopeDefectsFor(db, uint(userID), userRole)
+    }
+    defect, _, err := h.findDefect(db, c.Param("id"))
+    if err != nil {
+        h.notFound(c, "Defect not found")
+        return
+    }
+
+    var project models.Project
+    if err := h.DB.First(&project, defect.ProjectID).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+
+    state := projectState(&project)
+    state["defect_id"] = defect.ID
+    h.success(c, state, "Project state retrieved successfully")
+}
+
+// findWritableDefect - дефект по ID или ключу из пути, если его проект не в архиве
+func (h *Handler) findWritableDefect(c *gin.Context, db *gorm.DB) (*models.Defect, bool) {
+    defect, _, err := h.findDefect(h.scopeDefects(c, db), c.Param("id"))
+    if err != nil {
+        h.notFound(c, "Defect not found")
+        return nil, false
+    }
+    if !h.checkProjectWritable(c, defect.ProjectID) {
+        return nil, false
+    }
+    return defect, true
+}
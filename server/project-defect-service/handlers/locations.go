@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"project-defect-service/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type LocationHandler struct {
+    Handler
+}
+
+func NewLocationHandler(db *gorm.DB, jwtSecret, authServiceURL string) *LocationHandler {
+    return &LocationHandler{
+        Handler: *NewHandler(db, jwtSecret, authServiceURL),
+    }
+}
+
+// GetLocations - места проекта плоским списком или деревом (?tree=true)
+func (h *LocationHandler) GetLocations(c *gin.Context) {
+    projectID := c.Param("id")
+
+    var project models.Project
+    if err := h.DB.First(&project, projectID).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+
+    query := h.DB.Where("project_id = ?", project.ID)
+    if locationType := c.Query("type"); locationType != "" {
+        query = query.Where("type = ?", locationType)
+    }
+
+    var locations []models.Location
+    if err := query.Order("path").Find(&locations).Error; err != nil {
+        h.internalError(c, "Failed to fetch locations")
+        return
+    }
+
+    if c.Query("tree") == "true" {
+        h.success(c, gin.H{
+            "locations": buildLocationTree(locations),
+        }, "Locations retrieved successfully")
+        return
+    }
+
+    h.success(c, gin.H{
+        "locations": locations,
+    }, "Locations retrieved successfully")
+}
+
+func (h *LocationHandler) CreateLocation(c *gin.Context) {
+    projectID := c.Param("id")
+
+    var project models.Project
+    if err := h.DB.First(&project, projectID).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+
+    if !h.canManageProject(c, &project) {
+        return
+    }
+
+    var req models.LocationCreateRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    location := models.Location{
+        ProjectID: project.ID,
+        Type:      req.Type,
+        Name:      req.Name,
+        Code:      req.Code,
+    }
+
+    parentPath := "/"
+    var parentType models.LocationType
+    if req.ParentID != nil {
+        var parent models.Location
+        if err := h.DB.Where("project_id = ?", project.ID).First(&parent, *req.ParentID).Error; err != nil {
+            h.badRequest(c, "Parent location not found in this project")
+            return
+        }
+        location.ParentID = &parent.ID
+        parentPath = parent.Path
+        parentType = parent.Type
+    }
+
+    if !req.Type.CanBeChildOf(parentType) {
+        h.badRequest(c, fmt.Sprintf("Location of type %s cannot be placed here", req.Type))
+        return
+    }
+
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Create(&location).Error; err != nil {
+            return err
+        }
+        // Путь строится после вставки, так как включает собственный ID
+        location.Path = fmt.Sprintf("%s%d/", parentPath, location.ID)
+        return tx.Model(&location).Update("path", location.Path).Error
+    })
+    if err != nil {
+        h.internalError(c, "Failed to create location")
+        return
+    }
+
+    h.success(c, gin.H{
+        "location": location,
+    }, "Location created successfully")
+}
+
+func (h *LocationHandler) UpdateLocation(c *gin.Context) {
+    locationID := c.Param("id")
+
+    var location models.Location
+    if err := h.DB.First(&location, locationID).Error; err != nil {
+        h.notFound(c, "Location not found")
+        return
+    }
+
+    var project models.Project
+    if err := h.DB.First(&project, location.ProjectID).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+
+    if !h.canManageProject(c, &project) {
+        return
+    }
+
+    var req models.LocationUpdateRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    if req.Name != nil {
+        location.Name = *req.Name
+    }
+    if req.Code != nil {
+        location.Code = *req.Code
+    }
+
+    oldPath := location.Path
+    if req.ParentID != nil {
+        parentPath := "/"
+        var parentType models.LocationType
+        location.ParentID = nil
+
+        // parent_id = 0 переносит место в корень
+        if *req.ParentID != 0 {
+            var parent models.Location
+            if err := h.DB.Where("project_id = ?", location.ProjectID).First(&parent, *req.ParentID).Error; err != nil {
+                h.badRequest(c, "Parent location not found in this project")
+                return
+            }
+            if strings.HasPrefix(parent.Path, location.Path) {
+                h.badRequest(c, "Location cannot be moved inside itself")
+                return
+            }
+            location.ParentID = &parent.ID
+            parentPath = parent.Path
+            parentType = parent.Type
+        }
+
+        if !location.Type.CanBeChildOf(parentType) {
+            h.badRequest(c, fmt.Sprintf("Location of type %s cannot be placed here", location.Type))
+            return
+        }
+        location.Path = fmt.Sprintf("%s%d/", parentPath, location.ID)
+    }
+
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Save(&location).Error; err != nil {
+            return err
+        }
+        if location.Path == oldPath {
+            return nil
+        }
+        // Переписываем пути всего поддерева
+        return tx.Model(&models.Location{}).
+            Where("path LIKE ? AND id <> ?", oldPath+"%", location.ID).
+            Update("path", gorm.Expr("? || SUBSTRING(path FROM ?)", location.Path, len(oldPath)+1)).Error
+    })
+    if err != nil {
+        h.internalError(c, "Failed to update location")
+        return
+    }
+
+    h.success(c, gin.H{
+        "location": location,
+    }, "Location updated successfully")
+}
+
+func (h *LocationHandler) DeleteLocation(c *gin.Context) {
+    locationID := c.Param("id")
+
+    var location models.Location
+    if err := h.DB.First(&location, locationID).Error; err != nil {
+        h.notFound(c, "Location not found")
+        return
+    }
+
+    var project models.Project
+    if err := h.DB.First(&project, location.ProjectID).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+
+    if !h.canManageProject(c, &project) {
+        return
+    }
+
+    var childrenCount int64
+    h.DB.Model(&models.Location{}).Where("parent_id = ?", location.ID).Count(&childrenCount)
+    if childrenCount > 0 {
+        h.badRequest(c, "Cannot delete location with nested locations")
+        return
+    }
+
+    var defectsCount int64
+    h.DB.Model(&models.Defect{}).Where("location_id = ?", location.ID).Count(&defectsCount)
+    if defectsCount > 0 {
+        h.badRequest(c, "Cannot delete location with existing defects")
+        return
+    }
+
+    var inspections int64
+    h.DB.Model(&models.InspectionSchedule{}).Where("location_id = ?", location.ID).Count(&inspections)
+    if inspections == 0 {
+        h.DB.Model(&models.InspectionRound{}).Where("location_id = ?", location.ID).Count(&inspections)
+    }
+    if inspections > 0 {
+        h.badRequest(c, "Cannot delete location used by inspections")
+        return
+    }
+
+    if err := h.DB.Delete(&location).Error; err != nil {
+        h.internalError(c, "Failed to delete location")
+        return
+    }
+
+    h.success(c, nil, "Location deleted successfully")
+}
+
+// canManageProject - настраивать проект могут его менеджер и пользователи с ролью manager
+func (h *Handler) canManageProject(c *gin.Context, project *models.Project) bool {
+    userID, userRole, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return false
+    }
+
+    if !h.managesProject(userID, userRole, project) {
+        h.error(c, http.StatusForbidden, "Only project managers can change project settings")
+        return false
+    }
+    if project.Phase.IsReadOnly() {
+        h.respondProjectReadOnly(c, project)
+        return false
+    }
+    return true
+}
+
+// validateDefectLocation проверяет, что место существует и принадлежит проекту дефекта
+func (h *Handler) validateDefectLocation(projectID uint, locationID *uint) error {
+    if locationID == nil || *locationID == 0 {
+        return nil
+    }
+
+    var count int64
+    h.DB.Model(&models.Location{}).Where("id = ? AND project_id = ?", *locationID, projectID).Count(&count)
+    if count == 0 {
+        return fmt.Errorf("location not found in this project")
+    }
+    return nil
+}
+
+func buildLocationTree(locations []models.Location) []models.Location {
+    children := make(map[uint][]models.Location)
+    var roots []models.Location
+
+    for _, location := range locations {
+        if location.ParentID == nil {
+            continue
+        }
+        children[*location.ParentID] = append(children[*location.ParentID], location)
+    }
+
+    var attach func(location models.Location) models.Location
+    attach = func(location models.Location) models.Location {
+        for _, child := range children[location.ID] {
+            location.Children = append(location.Children, attach(child))
+        }
+        return location
+    }
+
+    for _, location := range locations {
+        if location.ParentID == nil {
+            roots = append(roots, attach(location))
+        }
+    }
+    return roots
+}
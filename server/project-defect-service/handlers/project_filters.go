@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"project-defect-service/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Сортировки списка проектов: параметр sort_by → выражение SQL
+var projectSortColumns = map[string]string{
+    "name":             "projects.name",
+    "key":              "projects.key",
+    "phase":            "projects.phase",
+    "created_at":       "projects.created_at",
+    "start_date":       "projects.start_date",
+    "end_date":         "projects.end_date",
+    "open_defects":     "open_defects",
+    "overdue_defects":  "overdue_defects",
+    "critical_defects": "critical_defects",
+}
+
+// applyProjectFilters применяет к запросу фильтры проектов из query-параметров
+func (h *Handler) applyProjectFilters(c *gin.Context, query *gorm.DB) (*gorm.DB, error) {
+    // Поиск по названию и ключу
+    if search := strings.TrimSpace(c.Query("search")); search != "" {
+        pattern := "%" + escapeLike(search) + "%"
+        query = query.Where("(projects.name ILIKE ? OR projects.key ILIKE ?)", pattern, pattern)
+    }
+
+    // Фильтрация по менеджеру
+    if managerID := c.Query("manager_id"); managerID != "" {
+        query = query.Where("projects.manager_id = ?", managerID)
+    }
+
+    // Фильтрация по иерархии: parent_id=root - верхний уровень; level=portfolio,program
+    if parentID := c.Query("parent_id"); parentID == "root" {
+        query = query.Where("projects.parent_id IS NULL")
+    } else if parentID != "" {
+        query = query.Where("projects.parent_id = ?", parentID)
+    }
+    if levels := c.Query("level"); levels != "" {
+        query = query.Where("projects.level IN ?", strings.Split(levels, ","))
+    }
+
+    // Шаблоны проектов выводятся отдельно: template=true
+    query = query.Where("projects.is_template = ?", c.Query("template") == "true")
+
+    // Фильтрация по этапу (status - синоним phase): phase=construction,handover.
+    // Архивные проекты скрыты, пока их не запросили явно или include_archived=true
+    phases := c.Query("phase")
+    if phases == "" {
+        phases = c.Query("status")
+    }
+    if phases != "" {
+        query = query.Where("projects.phase IN ?", strings.Split(phases, ","))
+    } else if c.Query("include_archived") != "true" {
+        query = query.Where("projects.phase <> ?", models.PhaseArchived)
+    }
+
+    // Фильтрация по плановым датам: start_from, start_to, end_from, end_to (YYYY-MM-DD)
+    dateFilters := []struct {
+        param     string
+        condition string
+    }{
+        {"start_from", "projects.start_date >= ?"},
+        {"start_to", "projects.start_date <= ?"},
+        {"end_from", "projects.end_date >= ?"},
+        {"end_to", "projects.end_date <= ?"},
+    }
+    for _, filter := range dateFilters {
+        value := c.Query(filter.param)
+        if value == "" {
+            continue
+        }
+        date, err := time.Parse("2006-01-02", value)
+        if err != nil {
+            return nil, fmt.Errorf("invalid %s, expected YYYY-MM-DD", filter.param)
+        }
+        query = query.Where(filter.condition, date.Format("2006-01-02"))
+    }
+
+    return query, nil
+}
+
+// projectOrder - ORDER BY по параметрам sort_by и order
+func projectOrder(c *gin.Context) (string, error) {
+    sortBy := c.DefaultQuery("sort_by", "created_at")
+    column, ok := projectSortColumns[sortBy]
+    if !ok {
+        return "", fmt.Errorf("invalid sort_by %s", sortBy)
+    }
+
+    order := strings.ToLower(c.DefaultQuery("order", "desc"))
+    if order != "asc" && order != "desc" {
+        return "", fmt.Errorf("order must be asc or desc")
+    }
+    return column + " " + order + " NULLS LAST, projects.id " + order, nil
+}
+
+// projectSummarySelect - колонки проекта со сводкой из подзапроса projectStatsQuery
+const projectSummarySelect = `projects.*,
+    COALESCE(stats.open_defects, 0) AS open_defects,
+    COALESCE(stats.overdue_defects, 0) AS overdue_defects,
+    COALESCE(stats.critical_defects, 0) AS critical_defects`
+
+// projectStatsQuery - подзапрос сводки по дефектам для всех проектов
+func (h *Handler) projectStatsQuery(now time.Time) *gorm.DB {
+    open := []models.DefectStatus{models.StatusNew, models.StatusInProgress, models.StatusOnReview}
+    return h.DB.Model(&models.Defect{}).
+        Select(`defects.project_id,
+            COUNT(*) FILTER (WHERE defects.status IN ?) AS open_defects,
+            COUNT(*) FILTER (WHERE defects.status IN ? AND `+models.DefectDueAtSQL+` <= ?) AS overdue_defects,
+            COUNT(*) FILTER (WHERE defects.status IN ? AND defects.priority = ?) AS critical_defects`,
+            open, open, now, open, models.PriorityCritical).
+        Group("defects.project_id")
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE
+func escapeLike(value string) string {
+    return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"project-defect-service/models"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -19,16 +23,35 @@ func NewProjectHandler(db *gorm.DB, jwtSecret, authServiceURL string) *ProjectHa
 }
 
 func (h *ProjectHandler) GetProjects(c *gin.Context) {
-    var projects []models.Project
+    query, err := h.applyProjectFilters(c, h.DB.Model(&models.Project{}))
+    if err != nil {
+        h.badRequest(c, err.Error())
+        return
+    }
+    order, err := projectOrder(c)
+    if err != nil {
+        h.badRequest(c, err.Error())
+        return
+    }
+    query = query.Session(&gorm.Session{})
     
-    query := h.DB
     page, pageSize := h.getPaginationParams(c)
     offset := (page - 1) * pageSize
     
     var total int64
-    query.Model(&models.Project{}).Count(&total)
+    if err := query.Count(&total).Error; err != nil {
+        h.internalError(c, "Failed to count projects")
+        return
+    }
     
-    if err := query.Offset(offset).Limit(pageSize).Find(&projects).Error; err != nil {
+    // Сводка по дефектам считается в том же запросе, что и страница проектов
+    projects := []models.ProjectSummary{}
+    if err := query.
+        Select(projectSummarySelect).
+        Joins("LEFT JOIN (?) AS stats ON stats.project_id = projects.id", h.projectStatsQuery(time.Now().UTC())).
+        Order(order).
+        Offset(offset).Limit(pageSize).
+        Scan(&projects).Error; err != nil {
         h.internalError(c, "Failed to fetch projects")
         return
     }
@@ -53,8 +76,16 @@ func (h *ProjectHandler) GetProject(c *gin.Context) {
         return
     }
     
+    ancestors, err := h.projectAncestors(&project)
+    if err != nil {
+        h.internalError(c, "Failed to fetch project ancestors")
+        return
+    }
+    
+    setETag(c, project.Version)
     h.success(c, gin.H{
-        "project": project,
+        "project":   project,
+        "ancestors": ancestors,
     }, "Project retrieved successfully")
 }
 
@@ -76,22 +107,111 @@ func (h *ProjectHandler) CreateProject(c *gin.Context) {
         return
     }
     
+    if err := validateProjectDates(req.StartDate, req.EndDate); err != nil {
+        h.badRequest(c, err.Error())
+        return
+    }
+    
+    level := req.Level
+    if level == "" {
+        level = models.LevelProject
+    }
+    parent, err := h.findProjectParent(req.ParentID, level, "")
+    if err != nil {
+        h.badRequest(c, err.Error())
+        return
+    }
+    
     project := models.Project{
         Name:        req.Name,
         Description: req.Description,
         ManagerID:   userID, // Менеджер - текущий пользователь
+        Phase:       models.PhasePlanning,
+        Level:       level,
+        StartDate:   req.StartDate,
+        EndDate:     req.EndDate,
+        RequireChecklistForReview: req.RequireChecklistForReview,
+        AssignmentMode: req.AssignmentMode,
+    }
+    if project.AssignmentMode == "" {
+        project.AssignmentMode = models.AssignByCategory
     }
     
-    if err := h.DB.Create(&project).Error; err != nil {
-        h.internalError(c, "Failed to create project")
+    key, ok := h.resolveProjectKey(c, req.Key)
+    if !ok {
         return
     }
+    project.Key = key
     
+    err = h.DB.Transaction(func(tx *gorm.DB) error {
+        return createProject(tx, &project, parent)
+    })
+    if err != nil {
+        h.respondProjectSaveError(c, err, "Failed to create project")
+        return
+    }
+    
+    setETag(c, project.Version)
     h.success(c, gin.H{
         "project": project,
     }, "Project created successfully")
 }
 
+// resolveProjectKey проверяет ключ из запроса; пустой ключ назначит createProject
+func (h *Handler) resolveProjectKey(c *gin.Context, requested string) (string, bool) {
+    if requested == "" {
+        return "", true
+    }
+    
+    key, err := normalizeProjectKey(requested)
+    if err != nil {
+        h.badRequest(c, err.Error())
+        return "", false
+    }
+    
+    // Проверка дает понятный ответ заранее; параллельно созданный проект с тем же
+    // ключом отсекает уникальный индекс (respondProjectSaveError)
+    var existing int64
+    if err := h.DB.Unscoped().Model(&models.Project{}).Where("key = ?", key).Count(&existing).Error; err != nil {
+        h.internalError(c, "Failed to check project key")
+        return "", false
+    }
+    if existing > 0 {
+        h.error(c, http.StatusConflict, "Project with this key already exists")
+        return "", false
+    }
+    return key, true
+}
+
+// respondProjectSaveError - 409, если ключ проекта занят, иначе 500 с message
+func (h *Handler) respondProjectSaveError(c *gin.Context, err error, message string) {
+    if isUniqueViolation(err) {
+        h.error(c, http.StatusConflict, "Project with this key already exists")
+        return
+    }
+    h.internalError(c, message)
+}
+
+// createProject создает проект внутри parent (nil - в корне иерархии);
+// ключ не указан - генерируем по ID проекта
+func createProject(tx *gorm.DB, project *models.Project, parent *models.Project) error {
+    if parent != nil {
+        project.ParentID = &parent.ID
+    }
+    if err := tx.Create(project).Error; err != nil {
+        return err
+    }
+    
+    project.Path = projectPath(parent, project.ID)
+    if project.Key == "" {
+        project.Key = fmt.Sprintf("P%d", project.ID)
+    }
+    return tx.Model(project).Updates(map[string]interface{}{
+        "key":  project.Key,
+        "path": project.Path,
+    }).Error
+}
+
 func (h *ProjectHandler) UpdateProject(c *gin.Context) {
     projectID := c.Param("id")
     
@@ -107,8 +227,8 @@ func (h *ProjectHandler) UpdateProject(c *gin.Context) {
         return
     }
     
-    // Проверяем права - только автор проекта (менеджер) может редактировать
-    if project.ManagerID != userID && userRole != "manager" {
+    // Проверяем права - менеджер проекта или вышестоящего портфеля/программы
+    if !h.managesProject(userID, userRole, &project) {
         h.error(c, http.StatusForbidden, "You can only edit your own projects")
         return
     }
@@ -118,14 +238,59 @@ func (h *ProjectHandler) UpdateProject(c *gin.Context) {
         return
     }
     
+    if !h.checkIfMatch(c, project.Version, func() gin.H { return gin.H{"project": project} }) {
+        return
+    }
+    
+    // Архивный проект меняется только возвратом на рабочий этап
+    if project.Phase.IsReadOnly() {
+        h.respondProjectReadOnly(c, &project)
+        return
+    }
+    
+    if err := validateProjectDates(req.StartDate, req.EndDate); err != nil {
+        h.badRequest(c, err.Error())
+        return
+    }
+    
+    // Ключ проекта входит в ключи всех его дефектов, поэтому не меняется
+    if req.Key != "" && !strings.EqualFold(req.Key, project.Key) {
+        h.badRequest(c, "Project key cannot be changed")
+        return
+    }
+    if req.Level != "" && req.Level != project.Level {
+        h.badRequest(c, "Project level cannot be changed")
+        return
+    }
+    // Перенос в иерархии - отдельной операцией, с проверкой прав на новое место
+    if req.ParentID != nil && (project.ParentID == nil || *req.ParentID != *project.ParentID) {
+        h.badRequest(c, "Use the move endpoint to change the parent project")
+        return
+    }
+    
+    before := project
     project.Name = req.Name
     project.Description = req.Description
+    project.RequireChecklistForReview = req.RequireChecklistForReview
+    if req.AssignmentMode != "" {
+        project.AssignmentMode = req.AssignmentMode
+    }
+    project.StartDate = req.StartDate
+    project.EndDate = req.EndDate
     
-    if err := h.DB.Save(&project).Error; err != nil {
+    err = saveVersioned(h.DB, &before, &project, &project.Version)
+    if errors.Is(err, errVersionConflict) {
+        var current models.Project
+        h.DB.First(&current, project.ID)
+        h.respondVersionConflict(c, current.Version, gin.H{"project": current})
+        return
+    }
+    if err != nil {
         h.internalError(c, "Failed to update project")
         return
     }
     
+    setETag(c, project.Version)
     h.success(c, gin.H{
         "project": project,
     }, "Project updated successfully")
@@ -146,8 +311,8 @@ func (h *ProjectHandler) DeleteProject(c *gin.Context) {
         return
     }
     
-    // Проверяем права - только автор проекта (менеджер) может удалять
-    if project.ManagerID != userID && userRole != "manager" {
+    // Проверяем права - менеджер проекта или вышестоящего портфеля/программы
+    if !h.managesProject(userID, userRole, &project) {
         h.error(c, http.StatusForbidden, "You can only delete your own projects")
         return
     }
@@ -160,10 +325,23 @@ func (h *ProjectHandler) DeleteProject(c *gin.Context) {
         return
     }
     
-    if err := h.DB.Delete(&project).Error; err != nil {
+    var childrenCount int64
+    h.DB.Model(&models.Project{}).Where("parent_id = ?", project.ID).Count(&childrenCount)
+    if childrenCount > 0 {
+        h.badRequest(c, "Cannot delete project with child projects")
+        return
+    }
+    
+    err = h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Model(&project).Update("deleted_by", userID).Error; err != nil {
+            return err
+        }
+        return tx.Delete(&project).Error
+    })
+    if err != nil {
         h.internalError(c, "Failed to delete project")
         return
     }
     
-    h.success(c, nil, "Project deleted successfully")
+    h.success(c, nil, "Project moved to trash")
 }
\ No newline at end of file
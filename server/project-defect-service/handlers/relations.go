@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"project-defect-service/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type RelationHandler struct {
+    Handler
+}
+
+func NewRelationHandler(db *gorm.DB, jwtSecret, authServiceURL string) *RelationHandler {
+    return &RelationHandler{
+        Handler: *NewHandler(db, jwtSecret, authServiceURL),
+    }
+}
+
+// GetDefectRelations - связи дефекта в обе стороны, родитель и дочерние дефекты
+func (h *RelationHandler) GetDefectRelations(c *gin.Context) {
+    defect, _, err := h.findDefect(h.scopeDefects(c, h.DB), c.Param("id"))
+    if err != nil {
+        h.notFound(c, "Defect not found")
+        return
+    }
+
+    var outgoing []models.DefectRelation
+    if err := h.DB.
+        Joins("Target").
+        Where("defect_relations.source_id = ?", defect.ID).
+        Find(&outgoing).Error; err != nil {
+        h.internalError(c, "Failed to fetch relations")
+        return
+    }
+
+    var incoming []models.DefectRelation
+    if err := h.DB.
+        Joins("Source").
+        Where("defect_relations.target_id = ?", defect.ID).
+        Find(&incoming).Error; err != nil {
+        h.internalError(c, "Failed to fetch relations")
+        return
+    }
+
+    var children []models.Defect
+    if err := h.DB.Where("parent_id = ?", defect.ID).Order("id").Find(&children).Error; err != nil {
+        h.internalError(c, "Failed to fetch child defects")
+        return
+    }
+
+    var parent *models.Defect
+    if defect.ParentID != nil {
+        var p models.Defect
+        if err := h.DB.First(&p, *defect.ParentID).Error; err == nil {
+            parent = &p
+        }
+    }
+
+    h.success(c, gin.H{
+        "outgoing": outgoing,
+        "incoming": incoming,
+        "parent":   parent,
+        "children": children,
+    }, "Defect relations retrieved successfully")
+}
+
+func (h *RelationHandler) CreateDefectRelation(c *gin.Context) {
+    defect, ok := h.findWritableDefect(c, h.DB)
+    if !ok {
+        return
+    }
+
+    userID, _, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return
+    }
+
+    var req models.DefectRelationRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    targetRef := req.TargetKey
+    if req.TargetID != 0 {
+        targetRef = strconv.FormatUint(uint64(req.TargetID), 10)
+    }
+    if targetRef == "" {
+        h.badRequest(c, "target_id or target_key is required")
+        return
+    }
+    target, _, err := h.findDefect(h.scopeDefects(c, h.DB), targetRef)
+    if err != nil {
+        h.badRequest(c, "Target defect not found")
+        return
+    }
+    if target.ProjectID != defect.ProjectID && !h.checkProjectWritable(c, target.ProjectID) {
+        return
+    }
+
+    relation := models.DefectRelation{
+        SourceID:  defect.ID,
+        TargetID:  target.ID,
+        Type:      req.Type,
+        CreatedBy: userID,
+    }
+    // blocked_by хранится как blocks в обратную сторону
+    if req.Type == models.RelationBlockedBy {
+        relation.SourceID, relation.TargetID = target.ID, defect.ID
+        relation.Type = models.RelationBlocks
+    }
+    if relation.Type == models.RelationDuplicateOf {
+        relation.AutoClose = req.AutoClose
+    } else if req.AutoClose {
+        h.badRequest(c, "auto_close is only supported for duplicate_of relations")
+        return
+    }
+
+    if relation.SourceID == relation.TargetID {
+        h.badRequest(c, "Defect cannot be related to itself")
+        return
+    }
+
+    var existing int64
+    h.DB.Model(&models.DefectRelation{}).
+        Where("source_id = ? AND target_id = ? AND type = ?", relation.SourceID, relation.TargetID, relation.Type).
+        Count(&existing)
+    if existing > 0 {
+        h.badRequest(c, "Relation already exists")
+        return
+    }
+
+    if relation.Type == models.RelationBlocks {
+        cycle, err := h.blockingPathExists(relation.TargetID, relation.SourceID)
+        if err != nil {
+            h.internalError(c, "Failed to check blocking relations")
+            return
+        }
+        if cycle {
+            h.error(c, http.StatusConflict, "Relation would create a blocking cycle")
+            return
+        }
+    }
+
+    err = h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Create(&relation).Error; err != nil {
+            return err
+        }
+        return recordDefectChange(tx, relation.SourceID, userID, "relations", "none",
+            fmt.Sprintf("%s %s", relation.Type, h.defectLabel(relation.TargetID)))
+    })
+    if err != nil {
+        h.internalError(c, "Failed to create relation")
+        return
+    }
+
+    h.success(c, gin.H{
+        "relation": relation,
+    }, "Relation created successfully")
+}
+
+func (h *RelationHandler) DeleteDefectRelation(c *gin.Context) {
+    defect, ok := h.findWritableDefect(c, h.DB)
+    if !ok {
+        return
+    }
+
+    userID, _, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return
+    }
+
+    var relation models.DefectRelation
+    if err := h.DB.
+        Where("source_id = ? OR target_id = ?", defect.ID, defect.ID).
+        First(&relation, c.Param("relation_id")).Error; err != nil {
+        h.notFound(c, "Relation not found")
+        return
+    }
+
+    err = h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Unscoped().Delete(&relation).Error; err != nil {
+            return err
+        }
+        return recordDefectChange(tx, relation.SourceID, userID, "relations",
+            fmt.Sprintf("%s %s", relation.Type, h.defectLabel(relation.TargetID)), "none")
+    })
+    if err != nil {
+        h.internalError(c, "Failed to delete relation")
+        return
+    }
+
+    h.success(c, nil, "Relation deleted successfully")
+}
+
+// blockingPathExists проверяет, достижим ли to из from по связям blocks.
+// Новая связь A blocks B образует цикл, если B уже (транзитивно) блокирует A.
+func (h *Handler) blockingPathExists(from, to uint) (bool, error) {
+    var count int64
+    err := h.DB.Raw(`
+        WITH RECURSIVE chain(id) AS (
+            SELECT ?::bigint
+            UNION
+            SELECT r.target_id FROM defect_relations r
+                JOIN chain ON r.source_id = chain.id
+                WHERE r.type = ? AND r.deleted_at IS NULL
+        )
+        SELECT COUNT(*) FROM chain WHERE id = ?`,
+        from, models.RelationBlocks, to,
+    ).Scan(&count).Error
+    return count > 0, err
+}
+
+// parentCycleExists проверяет, является ли defectID предком parentID
+func (h *Handler) parentCycleExists(defectID, parentID uint) (bool, error) {
+    var count int64
+    err := h.DB.Raw(`
+        WITH RECURSIVE ancestors(id, parent_id) AS (
+            SELECT id, parent_id FROM defects WHERE id = ?
+            UNION
+            SELECT d.id, d.parent_id FROM defects d
+                JOIN ancestors a ON d.id = a.parent_id
+        )
+        SELECT COUNT(*) FROM ancestors WHERE id = ?`,
+        parentID, defectID,
+    ).Scan(&count).Error
+    return count > 0, err
+}
+
+// checkCanClose - дефект нельзя закрыть, пока открыты его дочерние дефекты
+// или дефекты, которые его блокируют
+func (h *Handler) checkCanClose(defect *models.Defect) error {
+    openStatuses := []models.DefectStatus{models.StatusNew, models.StatusInProgress, models.StatusOnReview}
+
+    var openChildren int64
+    h.DB.Model(&models.Defect{}).
+        Where("parent_id = ? AND status IN ?", defect.ID, openStatuses).
+        Count(&openChildren)
+    if openChildren > 0 {
+        return fmt.Errorf("defect has %d open child defects", openChildren)
+    }
+
+    var openBlockers int64
+    h.DB.Model(&models.DefectRelation{}).
+        Joins("JOIN defects ON defects.id = defect_relations.source_id AND defects.deleted_at IS NULL").
+        Where("defect_relations.target_id = ? AND defect_relations.type = ? AND defects.status IN ?",
+            defect.ID, models.RelationBlocks, openStatuses).
+        Count(&openBlockers)
+    if openBlockers > 0 {
+        return fmt.Errorf("defect is blocked by %d open defects", openBlockers)
+    }
+    return nil
+}
+
+// cancelDuplicates отменяет открытые дубликаты с auto_close после закрытия оригинала.
+// Дубликат не закрывается: закрытие требует приемки и проверки подчиненных и блокирующих
+// дефектов, а работа по дубликату выполнена в оригинале
+func cancelDuplicates(tx *gorm.DB, original *models.Defect, userID uint) error {
+    var duplicates []models.Defect
+    if err := tx.
+        Joins("JOIN defect_relations r ON r.source_id = defects.id AND r.deleted_at IS NULL").
+        Where("r.target_id = ? AND r.type = ? AND r.auto_close = ?", original.ID, models.RelationDuplicateOf, true).
+        Where("defects.status NOT IN ?", []models.DefectStatus{models.StatusClosed, models.StatusCancelled}).
+        Find(&duplicates).Error; err != nil {
+        return err
+    }
+
+    for i := range duplicates {
+        duplicate := &duplicates[i]
+        if err := recordDefectChange(tx, duplicate.ID, userID, "status", string(duplicate.Status), string(models.StatusCancelled)); err != nil {
+            return err
+        }
+        before := *duplicate
+        duplicate.Status = models.StatusCancelled
+        markStatusTimestamps(duplicate, before.Status, time.Now().UTC())
+        if err := saveVersioned(tx, &before, duplicate, &duplicate.Version); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// defectLabel - ключ дефекта для истории, либо ID, если ключ недоступен
+func (h *Handler) defectLabel(defectID uint) string {
+    var defect models.Defect
+    if err := h.DB.Unscoped().Select("id", "key").First(&defect, defectID).Error; err != nil || defect.Key == "" {
+        return fmt.Sprintf("#%d", defectID)
+    }
+    return defect.Key
+}
@@ -0,0 +1,479 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"project-defect-service/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SLAHandler struct {
+    Handler
+}
+
+func NewSLAHandler(db *gorm.DB, jwtSecret, authServiceURL string) *SLAHandler {
+    return &SLAHandler{
+        Handler: *NewHandler(db, jwtSecret, authServiceURL),
+    }
+}
+
+func (h *SLAHandler) GetSLAPolicies(c *gin.Context) {
+    var policies []models.SLAPolicy
+    if err := h.DB.Where("project_id = ?", c.Param("id")).Order("priority, category_id NULLS FIRST").Find(&policies).Error; err != nil {
+        h.internalError(c, "Failed to fetch SLA policies")
+        return
+    }
+
+    h.success(c, gin.H{
+        "sla_policies": policies,
+    }, "SLA policies retrieved successfully")
+}
+
+func (h *SLAHandler) CreateSLAPolicy(c *gin.Context) {
+    var project models.Project
+    if err := h.DB.First(&project, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+
+    if !h.canManageProject(c, &project) {
+        return
+    }
+
+    var req models.SLAPolicyRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+    if req.CategoryID != nil && *req.CategoryID == 0 {
+        req.CategoryID = nil
+    }
+    if err := h.validateDefectCategory(project.ID, req.CategoryID); err != nil {
+        h.badRequest(c, err.Error())
+        return
+    }
+
+    policy := models.SLAPolicy{
+        ProjectID:       project.ID,
+        Priority:        req.Priority,
+        CategoryID:      req.CategoryID,
+        ResponseHours:   req.ResponseHours,
+        ResolutionHours: req.ResolutionHours,
+    }
+    if h.slaPolicyExists(&policy) {
+        h.error(c, http.StatusConflict, "SLA policy for this priority and category already exists")
+        return
+    }
+
+    if err := h.DB.Create(&policy).Error; err != nil {
+        if isUniqueViolation(err) {
+            h.error(c, http.StatusConflict, "SLA policy for this priority and category already exists")
+            return
+        }
+        h.internalError(c, "Failed to create SLA policy")
+        return
+    }
+
+    h.success(c, gin.H{
+        "sla_policy": policy,
+    }, "SLA policy created successfully")
+}
+
+// UpdateSLAPolicy - новые сроки применяются к дефектам, созданным или
+// перепланированным после изменения; существующие сроки не пересчитываются
+func (h *SLAHandler) UpdateSLAPolicy(c *gin.Context) {
+    policy, ok := h.findManagedPolicy(c)
+    if !ok {
+        return
+    }
+
+    var req models.SLAPolicyRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+    if req.CategoryID != nil && *req.CategoryID == 0 {
+        req.CategoryID = nil
+    }
+    if err := h.validateDefectCategory(policy.ProjectID, req.CategoryID); err != nil {
+        h.badRequest(c, err.Error())
+        return
+    }
+
+    scopeChanged := req.Priority != policy.Priority || !sameUint(req.CategoryID, policy.CategoryID)
+    policy.Priority = req.Priority
+    policy.CategoryID = req.CategoryID
+    policy.ResponseHours = req.ResponseHours
+    policy.ResolutionHours = req.ResolutionHours
+    if scopeChanged && h.slaPolicyExists(policy) {
+        h.error(c, http.StatusConflict, "SLA policy for this priority and category already exists")
+        return
+    }
+
+    if err := h.DB.Save(policy).Error; err != nil {
+        if isUniqueViolation(err) {
+            h.error(c, http.StatusConflict, "SLA policy for this priority and category already exists")
+            return
+        }
+        h.internalError(c, "Failed to update SLA policy")
+        return
+    }
+
+    h.success(c, gin.H{
+        "sla_policy": policy,
+    }, "SLA policy updated successfully")
+}
+
+func (h *SLAHandler) DeleteSLAPolicy(c *gin.Context) {
+    policy, ok := h.findManagedPolicy(c)
+    if !ok {
+        return
+    }
+
+    if err := h.DB.Unscoped().Delete(policy).Error; err != nil {
+        h.internalError(c, "Failed to delete SLA policy")
+        return
+    }
+
+    h.success(c, nil, "SLA policy deleted successfully")
+}
+
+// GetCalendar - рабочий календарь проекта; null, если сроки считаются в календарном времени
+func (h *SLAHandler) GetCalendar(c *gin.Context) {
+    var project models.Project
+    if err := h.DB.First(&project, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+
+    calendar, err := h.projectCalendar(project.ID)
+    if err != nil {
+        h.internalError(c, "Failed to fetch calendar")
+        return
+    }
+
+    h.success(c, gin.H{
+        "calendar": calendar,
+    }, "Calendar retrieved successfully")
+}
+
+// SetCalendar создает или заменяет рабочие часы календаря проекта
+func (h *SLAHandler) SetCalendar(c *gin.Context) {
+    var project models.Project
+    if err := h.DB.First(&project, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+
+    if !h.canManageProject(c, &project) {
+        return
+    }
+
+    var req models.BusinessCalendarRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    var calendar models.BusinessCalendar
+    if err := h.DB.Where("project_id = ?", project.ID).First(&calendar).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+        h.internalError(c, "Failed to fetch calendar")
+        return
+    }
+
+    workDays := uniqueInts(req.WorkDays)
+    sort.Ints(workDays)
+    calendar.ProjectID = project.ID
+    calendar.Timezone = req.Timezone
+    calendar.WorkDays = workDays
+    calendar.WorkStart = req.WorkStart
+    calendar.WorkEnd = req.WorkEnd
+    if err := calendar.Validate(); err != nil {
+        h.badRequest(c, err.Error())
+        return
+    }
+
+    if err := h.DB.Save(&calendar).Error; err != nil {
+        h.internalError(c, "Failed to save calendar")
+        return
+    }
+
+    h.respondWithCalendar(c, project.ID, "Calendar saved successfully")
+}
+
+func (h *SLAHandler) AddHoliday(c *gin.Context) {
+    calendar, ok := h.findManagedCalendar(c)
+    if !ok {
+        return
+    }
+
+    var req models.BusinessHolidayRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+    if req.Date.IsZero() {
+        h.badRequest(c, "date is required")
+        return
+    }
+
+    var existing int64
+    if err := h.DB.Model(&models.BusinessHoliday{}).Where("calendar_id = ? AND date = ?", calendar.ID, req.Date).Count(&existing).Error; err != nil {
+        h.internalError(c, "Failed to check holiday date")
+        return
+    }
+    if existing > 0 {
+        h.error(c, http.StatusConflict, "Holiday on this date already exists")
+        return
+    }
+
+    holiday := models.BusinessHoliday{
+        CalendarID: calendar.ID,
+        Date:       req.Date,
+        Name:       req.Name,
+    }
+    if err := h.DB.Create(&holiday).Error; err != nil {
+        if isUniqueViolation(err) {
+            h.error(c, http.StatusConflict, "Holiday on this date already exists")
+            return
+        }
+        h.internalError(c, "Failed to add holiday")
+        return
+    }
+
+    h.respondWithCalendar(c, calendar.ProjectID, "Holiday added successfully")
+}
+
+func (h *SLAHandler) DeleteHoliday(c *gin.Context) {
+    calendar, ok := h.findManagedCalendar(c)
+    if !ok {
+        return
+    }
+
+    result := h.DB.Unscoped().Where("calendar_id = ?", calendar.ID).Delete(&models.BusinessHoliday{}, c.Param("holiday_id"))
+    if result.Error != nil {
+        h.internalError(c, "Failed to delete holiday")
+        return
+    }
+    if result.RowsAffected == 0 {
+        h.notFound(c, "Holiday not found")
+        return
+    }
+
+    h.respondWithCalendar(c, calendar.ProjectID, "Holiday deleted successfully")
+}
+
+func (h *SLAHandler) respondWithCalendar(c *gin.Context, projectID uint, message string) {
+    calendar, err := h.projectCalendar(projectID)
+    if err != nil {
+        h.internalError(c, "Failed to fetch calendar")
+        return
+    }
+
+    h.success(c, gin.H{
+        "calendar": calendar,
+    }, message)
+}
+
+func (h *SLAHandler) findManagedPolicy(c *gin.Context) (*models.SLAPolicy, bool) {
+    var policy models.SLAPolicy
+    if err := h.DB.First(&policy, c.Param("id")).Error; err != nil {
+        h.notFound(c, "SLA policy not found")
+        return nil, false
+    }
+
+    var project models.Project
+    if err := h.DB.First(&project, policy.ProjectID).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return nil, false
+    }
+
+    if !h.canManageProject(c, &project) {
+        return nil, false
+    }
+    return &policy, true
+}
+
+func (h *SLAHandler) findManagedCalendar(c *gin.Context) (*models.BusinessCalendar, bool) {
+    var project models.Project
+    if err := h.DB.First(&project, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return nil, false
+    }
+
+    if !h.canManageProject(c, &project) {
+        return nil, false
+    }
+
+    var calendar models.BusinessCalendar
+    if err := h.DB.Where("project_id = ?", project.ID).First(&calendar).Error; err != nil {
+        h.notFound(c, "Calendar not found, set working hours first")
+        return nil, false
+    }
+    return &calendar, true
+}
+
+func (h *SLAHandler) slaPolicyExists(policy *models.SLAPolicy) bool {
+    query := h.DB.Model(&models.SLAPolicy{}).
+        Where("project_id = ? AND priority = ? AND id <> ?", policy.ProjectID, policy.Priority, policy.ID)
+    if policy.CategoryID == nil {
+        query = query.Where("category_id IS NULL")
+    } else {
+        query = query.Where("category_id = ?", *policy.CategoryID)
+    }
+
+    var count int64
+    query.Count(&count)
+    return count > 0
+}
+
+// projectCalendar - календарь проекта с праздниками или nil, если он не задан
+func (h *Handler) projectCalendar(projectID uint) (*models.BusinessCalendar, error) {
+    return loadProjectCalendar(h.DB, projectID)
+}
+
+func loadProjectCalendar(db *gorm.DB, projectID uint) (*models.BusinessCalendar, error) {
+    var calendar models.BusinessCalendar
+    err := db.Preload("Holidays", func(db *gorm.DB) *gorm.DB { return db.Order("date") }).
+        Where("project_id = ?", projectID).
+        First(&calendar).Error
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &calendar, nil
+}
+
+// findSLAPolicy подбирает политику для приоритета: сначала по категории дефекта
+// и ее предкам от ближайшего, затем общую политику приоритета
+func findSLAPolicy(db *gorm.DB, projectID uint, priority models.DefectPriority, categoryID *uint) (*models.SLAPolicy, error) {
+    var policies []models.SLAPolicy
+    if err := db.Where("project_id = ? AND priority = ?", projectID, priority).Find(&policies).Error; err != nil {
+        return nil, err
+    }
+
+    byCategory := make(map[uint]*models.SLAPolicy)
+    var general *models.SLAPolicy
+    for i := range policies {
+        if policies[i].CategoryID == nil {
+            general = &policies[i]
+        } else {
+            byCategory[*policies[i].CategoryID] = &policies[i]
+        }
+    }
+
+    if categoryID != nil && len(byCategory) > 0 {
+        var category models.Category
+        if err := db.First(&category, *categoryID).Error; err != nil {
+            return nil, err
+        }
+        ancestorIDs := pathIDs(category.Path)
+        for i := len(ancestorIDs) - 1; i >= 0; i-- {
+            if policy := byCategory[ancestorIDs[i]]; policy != nil {
+                return policy, nil
+            }
+        }
+    }
+    return general, nil
+}
+
+// applySLA рассчитывает сроки реакции и устранения по политике проекта от момента
+// создания дефекта. Срок устранения становится дедлайном, если keepDeadline не задан.
+// Без подходящей политики сроки SLA снимаются, дедлайн остается прежним.
+func (h *Handler) applySLA(defect *models.Defect, keepDeadline bool) error {
+    return ApplySLA(h.DB, defect, keepDeadline)
+}
+
+// ApplySLA - расчет сроков SLA через db; его же использует планировщик при эскалации
+func ApplySLA(db *gorm.DB, defect *models.Defect, keepDeadline bool) error {
+    policy, err := findSLAPolicy(db, defect.ProjectID, defect.Priority, defect.CategoryID)
+    if err != nil {
+        return err
+    }
+    if policy == nil {
+        defect.SLAPolicyID = nil
+        defect.ResponseDue = nil
+        defect.ResolutionDue = nil
+        return nil
+    }
+
+    calendar, err := loadProjectCalendar(db, defect.ProjectID)
+    if err != nil {
+        return err
+    }
+
+    start := defect.CreatedAt
+    if start.IsZero() {
+        start = time.Now().UTC()
+    }
+
+    defect.SLAPolicyID = &policy.ID
+    defect.ResponseDue = nil
+    if policy.ResponseHours > 0 {
+        responseDue := calendar.AddBusinessTime(start, time.Duration(policy.ResponseHours)*time.Hour)
+        defect.ResponseDue = &responseDue
+    }
+    resolutionDue := calendar.AddBusinessTime(start, time.Duration(policy.ResolutionHours)*time.Hour)
+    defect.ResolutionDue = &resolutionDue
+
+    if !keepDeadline {
+        loc := time.UTC
+        if calendar != nil {
+            if l, err := time.LoadLocation(calendar.Timezone); err == nil {
+                loc = l
+            }
+        }
+        local := resolutionDue.In(loc)
+        defect.Deadline = &models.Date{Time: time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)}
+    }
+    return nil
+}
+
+// slaCondition - SQL-условие для фильтра sla=ok|at_risk|breached|met
+func slaCondition(state string, now time.Time) (string, []interface{}, error) {
+    open := []models.DefectStatus{models.StatusNew, models.StatusInProgress, models.StatusOnReview}
+    // Как в Defect.SLAStatus: закрытый без отметки устранения считается закрытым в срок
+    breached := `((defects.response_due IS NOT NULL AND COALESCE(defects.responded_at, ?) > defects.response_due)
+        OR (defects.status IN ? AND defects.resolution_due < ?)
+        OR (defects.status NOT IN ? AND COALESCE(defects.resolved_at, defects.resolution_due) > defects.resolution_due))`
+    breachedArgs := []interface{}{now, open, now, open}
+    // Отмененные дефекты вне SLA: их состояние - none
+    withSLA := "defects.resolution_due IS NOT NULL AND defects.status <> ?"
+
+    switch state {
+    case models.SLAStatusBreached:
+        args := append([]interface{}{models.StatusCancelled}, breachedArgs...)
+        return withSLA + " AND " + breached, args, nil
+    case models.SLAStatusAtRisk, models.SLAStatusOK:
+        op := "<="
+        if state == models.SLAStatusOK {
+            op = ">"
+        }
+        args := append([]interface{}{models.StatusCancelled, open}, breachedArgs...)
+        args = append(args, now.Add(models.SLAAtRiskWindow))
+        return withSLA + " AND defects.status IN ? AND NOT " + breached +
+            " AND defects.resolution_due " + op + " ?", args, nil
+    case models.SLAStatusMet:
+        args := append([]interface{}{models.StatusCancelled, open}, breachedArgs...)
+        return withSLA + " AND defects.status NOT IN ? AND NOT " + breached, args, nil
+    case "none":
+        return "(defects.resolution_due IS NULL OR defects.status = ?)", []interface{}{models.StatusCancelled}, nil
+    }
+    return "", nil, fmt.Errorf("invalid sla filter %s", state)
+}
+
+func uniqueInts(values []int) []int {
+    seen := make(map[int]bool, len(values))
+    var result []int
+    for _, v := range values {
+        if !seen[v] {
+            seen[v] = true
+            result = append(result, v)
+        }
+    }
+    return result
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"testing"
+	"time"
+
+	"project-defect-service/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func slaRouter(db *gorm.DB) *gin.Engine {
+    r := testRouter(1, "manager")
+    defects := NewDefectHandler(db, "", "", "")
+    sla := NewSLAHandler(db, "", "")
+    r.GET("/api/defects", defects.GetDefects)
+    r.POST("/api/defects", defects.CreateDefect)
+    r.POST("/api/projects/:id/sla-policies", sla.CreateSLAPolicy)
+    r.PUT("/api/projects/:id/calendar", sla.SetCalendar)
+    r.POST("/api/projects/:id/calendar/holidays", sla.AddHoliday)
+    return r
+}
+
+// seedSLAProject - проект с календарем пятидневки и политикой для high: реакция 2 ч, устранение 16 ч
+func seedSLAProject(t *testing.T, db *gorm.DB, r *gin.Engine) *models.Project {
+    t.Helper()
+    project := seedProject(t, db, "TWR")
+    w := doJSON(t, r, http.MethodPut, fmt.Sprintf("/api/projects/%d/calendar", project.ID), gin.H{
+        "timezone":   "Europe/Moscow",
+        "work_days":  []int{1, 2, 3, 4, 5},
+        "work_start": "09:00",
+        "work_end":   "18:00",
+    })
+    if w.Code != http.StatusOK {
+        t.Fatalf("set calendar: status %d, body %s", w.Code, w.Body.String())
+    }
+    w = doJSON(t, r, http.MethodPost, fmt.Sprintf("/api/projects/%d/sla-policies", project.ID), gin.H{
+        "priority":         "high",
+        "response_hours":   2,
+        "resolution_hours": 16,
+    })
+    if w.Code != http.StatusOK {
+        t.Fatalf("create policy: status %d, body %s", w.Code, w.Body.String())
+    }
+    return project
+}
+
+func TestCreateDefectAppliesSLAPolicy(t *testing.T) {
+    db := testDB(t)
+    r := slaRouter(db)
+    project := seedSLAProject(t, db, r)
+    calendar, err := loadProjectCalendar(db, project.ID)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    start := time.Now().UTC()
+    w := doJSON(t, r, http.MethodPost, "/api/defects", gin.H{"title": "Leak", "priority": "high", "project_id": project.ID})
+    end := time.Now().UTC()
+    if w.Code != http.StatusOK {
+        t.Fatalf("create defect: status %d, body %s", w.Code, w.Body.String())
+    }
+    defect := decodeDefect(t, w)
+
+    if defect.ResolutionDue == nil || defect.ResponseDue == nil {
+        t.Fatalf("SLA due dates are not set: response %v, resolution %v", defect.ResponseDue, defect.ResolutionDue)
+    }
+    // Срок считается по рабочим часам календаря от момента создания
+    earliest := calendar.AddBusinessTime(start, 16*time.Hour).Add(-time.Second)
+    latest := calendar.AddBusinessTime(end, 16*time.Hour).Add(time.Second)
+    if defect.ResolutionDue.Before(earliest) || defect.ResolutionDue.After(latest) {
+        t.Errorf("resolution_due = %v, want between %v and %v", defect.ResolutionDue, earliest, latest)
+    }
+    moscow, _ := time.LoadLocation("Europe/Moscow")
+    if defect.Deadline == nil || defect.Deadline.Format("2006-01-02") != defect.ResolutionDue.In(moscow).Format("2006-01-02") {
+        t.Errorf("deadline = %v, want the project-local day of resolution_due %v", defect.Deadline, defect.ResolutionDue)
+    }
+
+    // Дедлайн, указанный вручную, сохраняется; сроки SLA все равно считаются
+    w = doJSON(t, r, http.MethodPost, "/api/defects", gin.H{"title": "Gap", "priority": "high", "project_id": project.ID, "deadline": "2030-01-15"})
+    if w.Code != http.StatusOK {
+        t.Fatalf("create defect with deadline: status %d, body %s", w.Code, w.Body.String())
+    }
+    manual := decodeDefect(t, w)
+    if manual.Deadline == nil || manual.Deadline.Format("2006-01-02") != "2030-01-15" || manual.ResolutionDue == nil {
+        t.Errorf("manual deadline = %v, resolution_due = %v", manual.Deadline, manual.ResolutionDue)
+    }
+
+    // Приоритет без политики - без сроков SLA
+    w = doJSON(t, r, http.MethodPost, "/api/defects", gin.H{"title": "Scratch", "priority": "low", "project_id": project.ID})
+    if w.Code != http.StatusOK {
+        t.Fatalf("create low defect: status %d, body %s", w.Code, w.Body.String())
+    }
+    if low := decodeDefect(t, w); low.ResolutionDue != nil || low.SLAPolicyID != nil {
+        t.Errorf("low defect has SLA: policy %v, resolution_due %v", low.SLAPolicyID, low.ResolutionDue)
+    }
+}
+
+func TestSLAConflictsReturn409(t *testing.T) {
+    db := testDB(t)
+    r := slaRouter(db)
+    project := seedSLAProject(t, db, r)
+
+    w := doJSON(t, r, http.MethodPost, fmt.Sprintf("/api/projects/%d/sla-policies", project.ID), gin.H{
+        "priority":         "high",
+        "resolution_hours": 8,
+    })
+    if w.Code != http.StatusConflict {
+        t.Errorf("second general policy for the priority: status %d, want %d", w.Code, http.StatusConflict)
+    }
+
+    holidays := fmt.Sprintf("/api/projects/%d/calendar/holidays", project.ID)
+    if w := doJSON(t, r, http.MethodPost, holidays, gin.H{"date": "2030-01-01", "name": "New Year"}); w.Code != http.StatusOK {
+        t.Fatalf("add holiday: status %d, body %s", w.Code, w.Body.String())
+    }
+    if w := doJSON(t, r, http.MethodPost, holidays, gin.H{"date": "2030-01-01", "name": "Again"}); w.Code != http.StatusConflict {
+        t.Errorf("same holiday twice: status %d, want %d", w.Code, http.StatusConflict)
+    }
+}
+
+func TestSLAFilterMatchesSLAStatus(t *testing.T) {
+    db := testDB(t)
+    r := slaRouter(db)
+    project := seedProject(t, db, "TWR")
+
+    now := time.Now().UTC()
+    at := func(d time.Duration) *time.Time {
+        value := now.Add(d)
+        return &value
+    }
+    seeds := []struct {
+        status        models.DefectStatus
+        resolutionDue *time.Time
+        resolvedAt    *time.Time
+        want          string
+    }{
+        {models.StatusInProgress, at(72 * time.Hour), nil, models.SLAStatusOK},
+        {models.StatusNew, at(2 * time.Hour), nil, models.SLAStatusAtRisk},
+        {models.StatusOnReview, at(-time.Hour), nil, models.SLAStatusBreached},
+        {models.StatusClosed, at(-time.Hour), at(-2 * time.Hour), models.SLAStatusMet},
+        {models.StatusClosed, at(-time.Hour), nil, models.SLAStatusMet},
+        {models.StatusClosed, at(-2 * time.Hour), at(-time.Hour), models.SLAStatusBreached},
+        {models.StatusCancelled, at(-time.Hour), nil, "none"},
+        {models.StatusInProgress, nil, nil, "none"},
+    }
+
+    want := map[string][]string{}
+    for _, seed := range seeds {
+        defect := seedDefect(t, db, project, seed.status)
+        if err := db.Model(defect).Updates(map[string]interface{}{
+            "resolution_due": seed.resolutionDue,
+            "resolved_at":    seed.resolvedAt,
+        }).Error; err != nil {
+            t.Fatal(err)
+        }
+        want[seed.want] = append(want[seed.want], defect.Key)
+    }
+
+    for _, state := range []string{models.SLAStatusOK, models.SLAStatusAtRisk, models.SLAStatusBreached, models.SLAStatusMet, "none"} {
+        w := doJSON(t, r, http.MethodGet, "/api/defects?sla="+state, nil)
+        if w.Code != http.StatusOK {
+            t.Fatalf("sla=%s: status %d, body %s", state, w.Code, w.Body.String())
+        }
+        var resp struct {
+            Data struct {
+                Defects []models.Defect `json:"defects"`
+            } `json:"data"`
+        }
+        if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+            t.Fatal(err)
+        }
+
+        var got []string
+        for _, defect := range resp.Data.Defects {
+            got = append(got, defect.Key)
+            // Фильтр и вычисленное состояние в ответе должны совпадать
+            if status := defect.SLAState; status != state && !(state == "none" && status == "") {
+                t.Errorf("sla=%s returned %s with sla_status %q", state, defect.Key, status)
+            }
+        }
+        sort.Strings(got)
+        sort.Strings(want[state])
+        if fmt.Sprint(got) != fmt.Sprint(want[state]) {
+            t.Errorf("sla=%s returned %v, want %v", state, got, want[state])
+        }
+    }
+}
@@ -0,0 +1,548 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"project-defect-service/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SaveAsTemplate - сохраняет настройки проекта как шаблон для новых проектов
+func (h *ProjectHandler) SaveAsTemplate(c *gin.Context) {
+    source, userID, ok := h.findCloneSource(c)
+    if !ok {
+        return
+    }
+
+    var req models.ProjectTemplateRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    template := models.Project{
+        Name:        req.Name,
+        Description: req.Description,
+        ManagerID:   userID,
+        Phase:       models.PhasePlanning,
+        IsTemplate:  true,
+        RequireChecklistForReview: source.RequireChecklistForReview,
+        AssignmentMode: source.AssignmentMode,
+    }
+
+    var result *models.ProjectCloneResult
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := createProject(tx, &template, nil); err != nil {
+            return err
+        }
+        var err error
+        result, err = newProjectCloner(tx, source, &template, userID).clone(false)
+        return err
+    })
+    if err != nil {
+        h.respondProjectSaveError(c, err, "Failed to save project template: "+err.Error())
+        return
+    }
+
+    setETag(c, template.Version)
+    h.success(c, gin.H{
+        "project": template,
+        "cloned":  result,
+    }, "Project template saved successfully")
+}
+
+// CloneProject - новый проект из шаблона или копия проекта с его настройками
+// и, по запросу, открытыми дефектами. ID всех скопированных записей новые.
+func (h *ProjectHandler) CloneProject(c *gin.Context) {
+    source, userID, ok := h.findCloneSource(c)
+    if !ok {
+        return
+    }
+
+    var req models.ProjectCloneRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+
+    if req.IncludeOpenDefects && source.IsTemplate {
+        h.badRequest(c, "Templates have no defects to copy")
+        return
+    }
+    if err := validateProjectDates(req.StartDate, req.EndDate); err != nil {
+        h.badRequest(c, err.Error())
+        return
+    }
+
+    key, ok := h.resolveProjectKey(c, req.Key)
+    if !ok {
+        return
+    }
+
+    project := models.Project{
+        Name:        req.Name,
+        Description: req.Description,
+        Key:         key,
+        ManagerID:   userID,
+        Phase:       models.PhasePlanning,
+        StartDate:   req.StartDate,
+        EndDate:     req.EndDate,
+        RequireChecklistForReview: source.RequireChecklistForReview,
+        AssignmentMode: source.AssignmentMode,
+    }
+
+    var result *models.ProjectCloneResult
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := createProject(tx, &project, nil); err != nil {
+            return err
+        }
+        var err error
+        result, err = newProjectCloner(tx, source, &project, userID).clone(req.IncludeOpenDefects)
+        return err
+    })
+    if err != nil {
+        h.respondProjectSaveError(c, err, "Failed to clone project: "+err.Error())
+        return
+    }
+
+    // Номера перенесенных дефектов увеличили счетчик нового проекта
+    h.DB.First(&project, project.ID)
+
+    setETag(c, project.Version)
+    h.success(c, gin.H{
+        "project": project,
+        "cloned":  result,
+    }, "Project cloned successfully")
+}
+
+// findCloneSource - исходный проект или шаблон; создавать проекты могут только менеджеры
+func (h *ProjectHandler) findCloneSource(c *gin.Context) (*models.Project, uint, bool) {
+    var source models.Project
+    if err := h.DB.First(&source, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return nil, 0, false
+    }
+
+    userID, userRole, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return nil, 0, false
+    }
+    if userRole != "manager" {
+        h.error(c, http.StatusForbidden, "Only managers can create projects")
+        return nil, 0, false
+    }
+    if !source.Level.HoldsDefects() {
+        h.badRequest(c, "Only projects can be cloned, not portfolios or programs")
+        return nil, 0, false
+    }
+    return &source, userID, true
+}
+
+// projectCloner копирует настройки проекта в новый проект. Карты переводят
+// ID записей исходного проекта в ID их копий для ссылок между записями.
+type projectCloner struct {
+    tx         *gorm.DB
+    source     *models.Project
+    target     *models.Project
+    userID     uint
+    result     models.ProjectCloneResult
+    locations  map[uint]uint
+    categories map[uint]uint
+    labels     map[uint]uint
+    fields     map[uint]uint
+    policies   map[uint]uint
+}
+
+func newProjectCloner(tx *gorm.DB, source, target *models.Project, userID uint) *projectCloner {
+    return &projectCloner{
+        tx:         tx,
+        source:     source,
+        target:     target,
+        userID:     userID,
+        locations:  map[uint]uint{},
+        categories: map[uint]uint{},
+        labels:     map[uint]uint{},
+        fields:     map[uint]uint{},
+        policies:   map[uint]uint{},
+    }
+}
+
+func (pc *projectCloner) clone(includeOpenDefects bool) (*models.ProjectCloneResult, error) {
+    steps := []func() error{
+        pc.cloneLocations,
+        pc.cloneCategories,
+        pc.cloneCategoryAssignees,
+        pc.cloneLabels,
+        pc.cloneCustomFields,
+        pc.cloneSLAPolicies,
+        pc.cloneCalendar,
+        pc.cloneEscalationRules,
+    }
+    if includeOpenDefects {
+        steps = append(steps, pc.cloneOpenDefects)
+    }
+    for _, step := range steps {
+        if err := step(); err != nil {
+            return nil, err
+        }
+    }
+    return &pc.result, nil
+}
+
+// remap - ID копии; ссылки на общие записи (например, общие категории) не меняются
+func remap(ids map[uint]uint, id *uint) *uint {
+    if id == nil {
+        return nil
+    }
+    if mapped, ok := ids[*id]; ok {
+        return &mapped
+    }
+    return id
+}
+
+func (pc *projectCloner) cloneLocations() error {
+    // Родители идут раньше потомков: путь предка короче
+    var locations []models.Location
+    if err := pc.tx.Where("project_id = ?", pc.source.ID).Order("LENGTH(path), id").Find(&locations).Error; err != nil {
+        return err
+    }
+
+    paths := map[uint]string{}
+    for _, location := range locations {
+        cloned := models.Location{
+            ProjectID: pc.target.ID,
+            ParentID:  remap(pc.locations, location.ParentID),
+            Type:      location.Type,
+            Name:      location.Name,
+            Code:      location.Code,
+        }
+        if err := pc.tx.Create(&cloned).Error; err != nil {
+            return err
+        }
+        parentPath := "/"
+        if cloned.ParentID != nil {
+            parentPath = paths[*cloned.ParentID]
+        }
+        cloned.Path = fmt.Sprintf("%s%d/", parentPath, cloned.ID)
+        if err := pc.tx.Model(&cloned).Update("path", cloned.Path).Error; err != nil {
+            return err
+        }
+        pc.locations[location.ID] = cloned.ID
+        paths[cloned.ID] = cloned.Path
+    }
+    pc.result.Locations = len(locations)
+    return nil
+}
+
+func (pc *projectCloner) cloneCategories() error {
+    var categories []models.Category
+    if err := pc.tx.Where("project_id = ?", pc.source.ID).Order("LENGTH(path), id").Find(&categories).Error; err != nil {
+        return err
+    }
+
+    // Проектные категории могут быть вложены в общие: у тех путь не меняется
+    paths := map[uint]string{}
+    for _, category := range categories {
+        cloned := models.Category{
+            ProjectID:         &pc.target.ID,
+            ParentID:          remap(pc.categories, category.ParentID),
+            Level:             category.Level,
+            Name:              category.Name,
+            Code:              category.Code,
+            DefaultAssigneeID: category.DefaultAssigneeID,
+        }
+        if err := pc.tx.Create(&cloned).Error; err != nil {
+            return err
+        }
+        parentPath := "/"
+        if cloned.ParentID != nil {
+            if path, ok := paths[*cloned.ParentID]; ok {
+                parentPath = path
+            } else {
+                var parent models.Category
+                if err := pc.tx.Select("path").First(&parent, *cloned.ParentID).Error; err != nil {
+                    return err
+                }
+                parentPath = parent.Path
+            }
+        }
+        cloned.Path = fmt.Sprintf("%s%d/", parentPath, cloned.ID)
+        if err := pc.tx.Model(&cloned).Update("path", cloned.Path).Error; err != nil {
+            return err
+        }
+        pc.categories[category.ID] = cloned.ID
+        paths[cloned.ID] = cloned.Path
+    }
+    pc.result.Categories = len(categories)
+    return nil
+}
+
+func (pc *projectCloner) cloneCategoryAssignees() error {
+    var assignees []models.CategoryAssignee
+    if err := pc.tx.Where("project_id = ?", pc.source.ID).Find(&assignees).Error; err != nil {
+        return err
+    }
+    for _, assignee := range assignees {
+        cloned := models.CategoryAssignee{
+            ProjectID:  pc.target.ID,
+            CategoryID: *remap(pc.categories, &assignee.CategoryID),
+            AssigneeID: assignee.AssigneeID,
+        }
+        if err := pc.tx.Create(&cloned).Error; err != nil {
+            return err
+        }
+    }
+    pc.result.CategoryAssignees = len(assignees)
+    return nil
+}
+
+func (pc *projectCloner) cloneLabels() error {
+    var labels []models.Label
+    if err := pc.tx.Where("project_id = ?", pc.source.ID).Order("id").Find(&labels).Error; err != nil {
+        return err
+    }
+    for _, label := range labels {
+        cloned := models.Label{
+            ProjectID: pc.target.ID,
+            Name:      label.Name,
+            Color:     label.Color,
+        }
+        if err := pc.tx.Create(&cloned).Error; err != nil {
+            return err
+        }
+        pc.labels[label.ID] = cloned.ID
+    }
+    pc.result.Labels = len(labels)
+    return nil
+}
+
+func (pc *projectCloner) cloneCustomFields() error {
+    var fields []models.CustomField
+    if err := pc.tx.Where("project_id = ?", pc.source.ID).Order("position, id").Find(&fields).Error; err != nil {
+        return err
+    }
+    for _, field := range fields {
+        cloned := models.CustomField{
+            ProjectID: pc.target.ID,
+            Key:       field.Key,
+            Name:      field.Name,
+            Type:      field.Type,
+            Required:  field.Required,
+            Options:   field.Options,
+            Position:  field.Position,
+        }
+        if err := pc.tx.Create(&cloned).Error; err != nil {
+            return err
+        }
+        pc.fields[field.ID] = cloned.ID
+    }
+    pc.result.CustomFields = len(fields)
+    return nil
+}
+
+func (pc *projectCloner) cloneSLAPolicies() error {
+    var policies []models.SLAPolicy
+    if err := pc.tx.Where("project_id = ?", pc.source.ID).Order("id").Find(&policies).Error; err != nil {
+        return err
+    }
+    for _, policy := range policies {
+        cloned := models.SLAPolicy{
+            ProjectID:       pc.target.ID,
+            Priority:        policy.Priority,
+            CategoryID:      remap(pc.categories, policy.CategoryID),
+            ResponseHours:   policy.ResponseHours,
+            ResolutionHours: policy.ResolutionHours,
+        }
+        if err := pc.tx.Create(&cloned).Error; err != nil {
+            return err
+        }
+        pc.policies[policy.ID] = cloned.ID
+    }
+    pc.result.SLAPolicies = len(policies)
+    return nil
+}
+
+func (pc *projectCloner) cloneCalendar() error {
+    var calendar models.BusinessCalendar
+    err := pc.tx.Preload("Holidays").Where("project_id = ?", pc.source.ID).First(&calendar).Error
+    if err == gorm.ErrRecordNotFound {
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+
+    cloned := models.BusinessCalendar{
+        ProjectID: pc.target.ID,
+        Timezone:  calendar.Timezone,
+        WorkDays:  calendar.WorkDays,
+        WorkStart: calendar.WorkStart,
+        WorkEnd:   calendar.WorkEnd,
+    }
+    if err := pc.tx.Omit("Holidays").Create(&cloned).Error; err != nil {
+        return err
+    }
+    for _, holiday := range calendar.Holidays {
+        if err := pc.tx.Create(&models.BusinessHoliday{
+            CalendarID: cloned.ID,
+            Date:       holiday.Date,
+            Name:       holiday.Name,
+        }).Error; err != nil {
+            return err
+        }
+    }
+    pc.result.Calendar = true
+    return nil
+}
+
+func (pc *projectCloner) cloneEscalationRules() error {
+    var rules []models.EscalationRule
+    if err := pc.tx.Where("project_id = ?", pc.source.ID).Order("id").Find(&rules).Error; err != nil {
+        return err
+    }
+    for _, rule := range rules {
+        cloned := models.EscalationRule{
+            ProjectID:   pc.target.ID,
+            Name:        rule.Name,
+            Trigger:     rule.Trigger,
+            OffsetHours: rule.OffsetHours,
+            Priority:    rule.Priority,
+            Action:      rule.Action,
+            AssigneeID:  rule.AssigneeID,
+            Enabled:     rule.Enabled,
+        }
+        // Enabled=false не попадает в INSERT из-за default:true
+        if err := pc.tx.Select("*").Omit("id").Create(&cloned).Error; err != nil {
+            return err
+        }
+    }
+    pc.result.EscalationRules = len(rules)
+    return nil
+}
+
+// cloneOpenDefects переносит открытые дефекты с метками, значениями полей и
+// чек-листами. Дефекты получают новые номера; метки на чертежах не переносятся,
+// потому что чертежи принадлежат исходному проекту.
+func (pc *projectCloner) cloneOpenDefects() error {
+    var defects []models.Defect
+    if err := pc.tx.Preload("Labels").Preload("Checklist").
+        Where("project_id = ? AND status IN ?", pc.source.ID,
+            []models.DefectStatus{models.StatusNew, models.StatusInProgress, models.StatusOnReview}).
+        Order("number").Find(&defects).Error; err != nil {
+        return err
+    }
+
+    copies := map[uint]uint{}
+    for _, defect := range defects {
+        number, key, err := allocateDefectNumber(pc.tx, pc.target.ID)
+        if err != nil {
+            return err
+        }
+        cloned := models.Defect{
+            Title:         defect.Title,
+            Description:   defect.Description,
+            Status:        defect.Status,
+            Priority:      defect.Priority,
+            Deadline:      defect.Deadline,
+            ProjectID:     pc.target.ID,
+            Number:        number,
+            Key:           key,
+            AuthorID:      defect.AuthorID,
+            AssigneeID:    defect.AssigneeID,
+            CategoryID:    remap(pc.categories, defect.CategoryID),
+            LocationID:    remap(pc.locations, defect.LocationID),
+            Latitude:      defect.Latitude,
+            Longitude:     defect.Longitude,
+            SLAPolicyID:   remap(pc.policies, defect.SLAPolicyID),
+            ResponseDue:   defect.ResponseDue,
+            ResolutionDue: defect.ResolutionDue,
+            RespondedAt:   defect.RespondedAt,
+        }
+        if err := pc.tx.Omit("Labels", "Checklist", "History").Create(&cloned).Error; err != nil {
+            return err
+        }
+        copies[defect.ID] = cloned.ID
+
+        for _, label := range defect.Labels {
+            if err := pc.tx.Exec("INSERT INTO defect_labels (defect_id, label_id) VALUES (?, ?)",
+                cloned.ID, pc.labels[label.ID]).Error; err != nil {
+                return err
+            }
+        }
+
+        for _, item := range defect.Checklist {
+            if err := pc.tx.Create(&models.ChecklistItem{
+                DefectID:   cloned.ID,
+                Title:      item.Title,
+                Position:   item.Position,
+                AssigneeID: item.AssigneeID,
+                Done:       item.Done,
+                DoneBy:     item.DoneBy,
+                DoneAt:     item.DoneAt,
+            }).Error; err != nil {
+                return err
+            }
+        }
+
+        if err := recordDefectChange(pc.tx, cloned.ID, pc.userID, "cloned_from", "none", defect.Key); err != nil {
+            return err
+        }
+    }
+
+    if err := pc.cloneFieldValues(copies); err != nil {
+        return err
+    }
+
+    // Родитель сохраняется, только если он тоже перенесен
+    for _, defect := range defects {
+        if defect.ParentID == nil {
+            continue
+        }
+        parentID, ok := copies[*defect.ParentID]
+        if !ok {
+            continue
+        }
+        if err := pc.tx.Model(&models.Defect{}).Where("id = ?", copies[defect.ID]).Update("parent_id", parentID).Error; err != nil {
+            return err
+        }
+    }
+
+    pc.result.Defects = len(defects)
+    return nil
+}
+
+func (pc *projectCloner) cloneFieldValues(copies map[uint]uint) error {
+    if len(copies) == 0 {
+        return nil
+    }
+    defectIDs := make([]uint, 0, len(copies))
+    for id := range copies {
+        defectIDs = append(defectIDs, id)
+    }
+
+    var values []models.CustomFieldValue
+    if err := pc.tx.Where("defect_id IN ?", defectIDs).Find(&values).Error; err != nil {
+        return err
+    }
+    for _, value := range values {
+        fieldID, ok := pc.fields[value.FieldID]
+        if !ok {
+            continue
+        }
+        cloned := models.CustomFieldValue{
+            DefectID:     copies[value.DefectID],
+            FieldID:      fieldID,
+            ValueText:    value.ValueText,
+            ValueNumber:  value.ValueNumber,
+            ValueDate:    value.ValueDate,
+            ValueUser:    value.ValueUser,
+            ValueOptions: value.ValueOptions,
+        }
+        if err := pc.tx.Omit("Field").Create(&cloned).Error; err != nil {
+            return err
+        }
+    }
+    return nil
+}
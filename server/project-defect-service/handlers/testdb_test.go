@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"project-defect-service/database/dbtest"
+	"project-defect-service/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// testDB - отдельная схема тестовой базы, см. dbtest; без TEST_DATABASE_URL тест пропускается
+func testDB(t *testing.T) *gorm.DB {
+    t.Helper()
+    return dbtest.Open(t)
+}
+
+// testRouter - gin без JWT: пользователь запроса задается напрямую
+func testRouter(userID uint, userRole string) *gin.Engine {
+    gin.SetMode(gin.TestMode)
+    r := gin.New()
+    r.Use(func(c *gin.Context) {
+        c.Set("user_id", userID)
+        c.Set("user_role", userRole)
+        c.Next()
+    })
+    return r
+}
+
+// doJSON выполняет запрос к router; headers - пары имя, значение
+func doJSON(t *testing.T, r http.Handler, method, path string, body interface{}, headers ...string) *httptest.ResponseRecorder {
+    t.Helper()
+    var reader *bytes.Reader
+    if body != nil {
+        data, err := json.Marshal(body)
+        if err != nil {
+            t.Fatalf("failed to encode request: %v", err)
+        }
+        reader = bytes.NewReader(data)
+    } else {
+        reader = bytes.NewReader(nil)
+    }
+
+    req := httptest.NewRequest(method, path, reader)
+    req.Header.Set("Content-Type", "application/json")
+    for i := 0; i+1 < len(headers); i += 2 {
+        req.Header.Set(headers[i], headers[i+1])
+    }
+    w := httptest.NewRecorder()
+    r.ServeHTTP(w, req)
+    return w
+}
+
+// decodeDefect достает дефект из ответа {"data": {"defect": ...}}
+func decodeDefect(t *testing.T, w *httptest.ResponseRecorder) models.Defect {
+    t.Helper()
+    var resp struct {
+        Data struct {
+            Defect models.Defect `json:"defect"`
+        } `json:"data"`
+    }
+    if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+        t.Fatalf("failed to decode response %s: %v", w.Body.String(), err)
+    }
+    return resp.Data.Defect
+}
+
+// seedProject создает проект с ключом key, в который можно заводить дефекты
+func seedProject(t *testing.T, db *gorm.DB, key string) *models.Project {
+    t.Helper()
+    project := models.Project{
+        Name:           "Project " + key,
+        Key:            key,
+        ManagerID:      1,
+        Phase:          models.PhaseConstruction,
+        AssignmentMode: models.AssignManual,
+    }
+    if err := db.Create(&project).Error; err != nil {
+        t.Fatalf("failed to create project: %v", err)
+    }
+    project.Path = fmt.Sprintf("/%d/", project.ID)
+    if err := db.Model(&project).Update("path", project.Path).Error; err != nil {
+        t.Fatalf("failed to set project path: %v", err)
+    }
+    return &project
+}
+
+func ifMatch(version uint) []string {
+    return []string{"If-Match", fmt.Sprintf("%q", fmt.Sprint(version))}
+}
+
+// seedDefect создает дефект проекта в статусе status с очередным ключом проекта
+func seedDefect(t *testing.T, db *gorm.DB, project *models.Project, status models.DefectStatus) *models.Defect {
+    t.Helper()
+    defect := models.Defect{
+        Title:     "Defect",
+        Status:    status,
+        Priority:  models.PriorityMedium,
+        ProjectID: project.ID,
+        AuthorID:  1,
+    }
+    err := db.Transaction(func(tx *gorm.DB) error {
+        number, key, err := allocateDefectNumber(tx, project.ID)
+        if err != nil {
+            return err
+        }
+        defect.Number, defect.Key = number, key
+        return tx.Create(&defect).Error
+    })
+    if err != nil {
+        t.Fatalf("failed to create defect: %v", err)
+    }
+    if err := db.First(&defect, defect.ID).Error; err != nil {
+        t.Fatalf("failed to reload defect: %v", err)
+    }
+    return &defect
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"project-defect-service/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// TrashHandler - корзина удаленных проектов и дефектов
+type TrashHandler struct {
+    Handler
+    // Сколько удаленное хранится в корзине до окончательного удаления
+    Retention time.Duration
+}
+
+func NewTrashHandler(db *gorm.DB, jwtSecret, authServiceURL string, retention time.Duration) *TrashHandler {
+    return &TrashHandler{
+        Handler:   *NewHandler(db, jwtSecret, authServiceURL),
+        Retention: retention,
+    }
+}
+
+// requireManager - корзина доступна только менеджерам
+func (h *TrashHandler) requireManager(c *gin.Context) (uint, bool) {
+    userID, userRole, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return 0, false
+    }
+    if userRole != "manager" {
+        h.error(c, http.StatusForbidden, "Only managers can access the trash")
+        return 0, false
+    }
+    return userID, true
+}
+
+// GetTrash - удаленные проекты и дефекты (?type=defect|project, ?project_id=)
+func (h *TrashHandler) GetTrash(c *gin.Context) {
+    if _, ok := h.requireManager(c); !ok {
+        return
+    }
+
+    itemType := c.Query("type")
+    if itemType != "" && itemType != models.TrashTypeDefect && itemType != models.TrashTypeProject {
+        h.badRequest(c, "type must be defect or project")
+        return
+    }
+    projectID := c.Query("project_id")
+
+    items := []models.TrashItem{}
+
+    if itemType == "" || itemType == models.TrashTypeProject {
+        query := h.DB.Unscoped().Where("deleted_at IS NOT NULL")
+        if projectID != "" {
+            query = query.Where("id = ?", projectID)
+        }
+        var projects []models.Project
+        if err := query.Find(&projects).Error; err != nil {
+            h.internalError(c, "Failed to fetch deleted projects")
+            return
+        }
+        for _, project := range projects {
+            items = append(items, models.TrashItem{
+                Type:      models.TrashTypeProject,
+                ID:        project.ID,
+                Key:       project.Key,
+                Title:     project.Name,
+                ProjectID: project.ID,
+                DeletedAt: project.DeletedAt.Time,
+                DeletedBy: project.DeletedBy,
+                PurgeAt:   project.DeletedAt.Time.Add(h.Retention),
+            })
+        }
+    }
+
+    if itemType == "" || itemType == models.TrashTypeDefect {
+        query := h.DB.Unscoped().Where("deleted_at IS NOT NULL")
+        if projectID != "" {
+            query = query.Where("project_id = ?", projectID)
+        }
+        var defects []models.Defect
+        if err := query.Find(&defects).Error; err != nil {
+            h.internalError(c, "Failed to fetch deleted defects")
+            return
+        }
+        for _, defect := range defects {
+            items = append(items, models.TrashItem{
+                Type:      models.TrashTypeDefect,
+                ID:        defect.ID,
+                Key:       defect.Key,
+                Title:     defect.Title,
+                ProjectID: defect.ProjectID,
+                DeletedAt: defect.DeletedAt.Time,
+                DeletedBy: defect.DeletedBy,
+                PurgeAt:   defect.DeletedAt.Time.Add(h.Retention),
+            })
+        }
+    }
+
+    sort.SliceStable(items, func(i, j int) bool {
+        return items[i].DeletedAt.After(items[j].DeletedAt)
+    })
+
+    if len(items) > 0 {
+        users, err := h.fetchUsers(c)
+        if err != nil {
+            log.Printf("Failed to resolve trash actors: %v", err)
+        }
+        for i := range items {
+            if items[i].DeletedBy == nil {
+                continue
+            }
+            if user, ok := users[*items[i].DeletedBy]; ok {
+                items[i].DeletedByName = user.FullName
+            }
+        }
+    }
+
+    h.success(c, gin.H{
+        "items":          items,
+        "retention_days": int(h.Retention.Hours() / 24),
+    }, "Trash retrieved successfully")
+}
+
+// RestoreDefect - возвращает дефект из корзины вместе с историей
+func (h *TrashHandler) RestoreDefect(c *gin.Context) {
+    userID, ok := h.requireManager(c)
+    if !ok {
+        return
+    }
+
+    defect, _, err := h.findDefect(h.DB.Unscoped(), c.Param("id"))
+    if err != nil {
+        h.notFound(c, "Defect not found")
+        return
+    }
+    if !defect.DeletedAt.Valid {
+        h.error(c, http.StatusConflict, "Defect is not in the trash")
+        return
+    }
+
+    var project models.Project
+    if err := h.DB.Unscoped().First(&project, defect.ProjectID).Error; err != nil {
+        h.internalError(c, "Failed to fetch defect project")
+        return
+    }
+    if project.DeletedAt.Valid {
+        h.error(c, http.StatusConflict, fmt.Sprintf("Restore project %s first", project.Key))
+        return
+    }
+    if project.Phase.IsReadOnly() {
+        h.respondProjectReadOnly(c, &project)
+        return
+    }
+
+    err = h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Unscoped().Model(&models.Defect{}).Where("id = ?", defect.ID).Updates(map[string]interface{}{
+            "deleted_at": nil,
+            "deleted_by": nil,
+            "version":    gorm.Expr("version + 1"),
+        }).Error; err != nil {
+            return err
+        }
+        // История дефектов, удаленных до появления корзины, была удалена вместе с ними
+        if err := tx.Unscoped().Model(&models.DefectHistory{}).
+            Where("defect_id = ? AND deleted_at IS NOT NULL", defect.ID).
+            Update("deleted_at", nil).Error; err != nil {
+            return err
+        }
+        return recordDefectChange(tx, defect.ID, userID, "deleted", "true", "false")
+    })
+    if err != nil {
+        h.internalError(c, "Failed to restore defect")
+        return
+    }
+
+    var restored models.Defect
+    if err := h.DB.Preload("Location").Preload("Category").Preload("Labels").First(&restored, defect.ID).Error; err != nil {
+        h.internalError(c, "Failed to fetch restored defect")
+        return
+    }
+    h.attachDefectDetail(&restored)
+
+    setETag(c, restored.Version)
+    h.success(c, gin.H{
+        "defect": restored,
+    }, "Defect restored successfully")
+}
+
+// RestoreProject - возвращает проект из корзины; его дефекты восстанавливаются отдельно
+func (h *TrashHandler) RestoreProject(c *gin.Context) {
+    if _, ok := h.requireManager(c); !ok {
+        return
+    }
+
+    var project models.Project
+    if err := h.DB.Unscoped().First(&project, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+    if !project.DeletedAt.Valid {
+        h.error(c, http.StatusConflict, "Project is not in the trash")
+        return
+    }
+    if project.ParentID != nil {
+        var parents int64
+        h.DB.Model(&models.Project{}).Where("id = ?", *project.ParentID).Count(&parents)
+        if parents == 0 {
+            h.error(c, http.StatusConflict, "Parent project is in the trash, restore it first")
+            return
+        }
+    }
+
+    if err := h.DB.Unscoped().Model(&models.Project{}).Where("id = ?", project.ID).Updates(map[string]interface{}{
+        "deleted_at": nil,
+        "deleted_by": nil,
+        "version":    gorm.Expr("version + 1"),
+    }).Error; err != nil {
+        h.internalError(c, "Failed to restore project")
+        return
+    }
+
+    if err := h.DB.First(&project, project.ID).Error; err != nil {
+        h.internalError(c, "Failed to fetch restored project")
+        return
+    }
+
+    setETag(c, project.Version)
+    h.success(c, gin.H{
+        "project": project,
+    }, "Project restored successfully")
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// userInfo - пользователь из auth-service
+type userInfo struct {
+    ID       uint   `json:"id"`
+    Email    string `json:"email"`
+    FullName string `json:"full_name"`
+    RoleName string `json:"role_name"`
+    Active   bool   `json:"is_active"`
+}
+
+// fetchUsers запрашивает пользователей в auth-service от имени текущего пользователя
+func (h *Handler) fetchUsers(c *gin.Context) (map[uint]userInfo, error) {
+    var result struct {
+        Data struct {
+            Users []userInfo `json:"users"`
+        } `json:"data"`
+    }
+
+    resp, err := h.Client.R().
+        SetHeader("Authorization", c.GetHeader("Authorization")).
+        SetResult(&result).
+        Get(h.AuthServiceURL + "/api/users")
+    if err != nil {
+        return nil, fmt.Errorf("failed to fetch users from auth-service: %w", err)
+    }
+    if resp.StatusCode() != http.StatusOK {
+        return nil, fmt.Errorf("auth-service returned status: %d", resp.StatusCode())
+    }
+
+    users := make(map[uint]userInfo, len(result.Data.Users))
+    for _, user := range result.Data.Users {
+        users[user.ID] = user
+    }
+    return users, nil
+}
@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"sort"
+	"strconv"
+
+	"project-defect-service/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// watchDefect подписывает пользователя на дефект; существующая подписка сохраняет свою причину
+func watchDefect(tx *gorm.DB, defectID uint, userID *uint, reason string) error {
+    if userID == nil || *userID == 0 {
+        return nil
+    }
+    watcher := models.DefectWatcher{DefectID: defectID, UserID: *userID, Reason: reason}
+    return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&watcher).Error
+}
+
+// GetDefectWatchers - подписчики дефекта и подписан ли текущий пользователь
+func (h *DefectHandler) GetDefectWatchers(c *gin.Context) {
+    defect, _, err := h.findDefect(h.scopeDefects(c, h.DB), c.Param("id"))
+    if err != nil {
+        h.notFound(c, "Defect not found")
+        return
+    }
+
+    userID, _, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return
+    }
+
+    watchers := []models.DefectWatcher{}
+    if err := h.DB.Where("defect_id = ?", defect.ID).Order("id").Find(&watchers).Error; err != nil {
+        h.internalError(c, "Failed to fetch watchers")
+        return
+    }
+
+    watching := false
+    for _, watcher := range watchers {
+        if watcher.UserID == userID {
+            watching = true
+        }
+    }
+
+    h.success(c, gin.H{
+        "watchers": watchers,
+        "watching": watching,
+    }, "Watchers retrieved successfully")
+}
+
+// WatchDefect - подписка текущего пользователя на дефект
+func (h *DefectHandler) WatchDefect(c *gin.Context) {
+    defect, _, err := h.findDefect(h.scopeDefects(c, h.DB), c.Param("id"))
+    if err != nil {
+        h.notFound(c, "Defect not found")
+        return
+    }
+
+    userID, _, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return
+    }
+
+    if err := watchDefect(h.DB, defect.ID, &userID, models.WatchManual); err != nil {
+        h.internalError(c, "Failed to watch defect")
+        return
+    }
+
+    h.success(c, gin.H{
+        "watching": true,
+    }, "Defect watched successfully")
+}
+
+// UnwatchDefect - отписка текущего пользователя от дефекта
+func (h *DefectHandler) UnwatchDefect(c *gin.Context) {
+    defect, _, err := h.findDefect(h.scopeDefects(c, h.DB), c.Param("id"))
+    if err != nil {
+        h.notFound(c, "Defect not found")
+        return
+    }
+
+    userID, _, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return
+    }
+
+    if err := h.DB.Unscoped().Where("defect_id = ? AND user_id = ?", defect.ID, userID).
+        Delete(&models.DefectWatcher{}).Error; err != nil {
+        h.internalError(c, "Failed to unwatch defect")
+        return
+    }
+
+    h.success(c, gin.H{
+        "watching": false,
+    }, "Defect unwatched successfully")
+}
+
+// GetProjectWatchers - подписчики проекта и подписан ли текущий пользователь
+func (h *ProjectHandler) GetProjectWatchers(c *gin.Context) {
+    var project models.Project
+    if err := h.DB.First(&project, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+
+    userID, _, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return
+    }
+
+    watchers := []models.ProjectWatcher{}
+    if err := h.DB.Where("project_id = ?", project.ID).Order("id").Find(&watchers).Error; err != nil {
+        h.internalError(c, "Failed to fetch watchers")
+        return
+    }
+
+    watching := false
+    for _, watcher := range watchers {
+        if watcher.UserID == userID {
+            watching = true
+        }
+    }
+
+    h.success(c, gin.H{
+        "watchers": watchers,
+        "watching": watching,
+    }, "Watchers retrieved successfully")
+}
+
+// WatchProject - подписка текущего пользователя на все дефекты проекта
+func (h *ProjectHandler) WatchProject(c *gin.Context) {
+    var project models.Project
+    if err := h.DB.First(&project, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+
+    userID, _, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return
+    }
+
+    watcher := models.ProjectWatcher{ProjectID: project.ID, UserID: userID}
+    if err := h.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&watcher).Error; err != nil {
+        h.internalError(c, "Failed to watch project")
+        return
+    }
+
+    h.success(c, gin.H{
+        "watching": true,
+    }, "Project watched successfully")
+}
+
+// UnwatchProject - отписка текущего пользователя от проекта
+func (h *ProjectHandler) UnwatchProject(c *gin.Context) {
+    var project models.Project
+    if err := h.DB.First(&project, c.Param("id")).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+
+    userID, _, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return
+    }
+
+    if err := h.DB.Unscoped().Where("project_id = ? AND user_id = ?", project.ID, userID).
+        Delete(&models.ProjectWatcher{}).Error; err != nil {
+        h.internalError(c, "Failed to unwatch project")
+        return
+    }
+
+    h.success(c, gin.H{
+        "watching": false,
+    }, "Project unwatched successfully")
+}
+
+// AddDefectWatcher - подписка пользователя другим сервисом, например автора комментария
+func (h *DefectHandler) AddDefectWatcher(c *gin.Context) {
+    defect, _, err := h.findDefect(h.DB, c.Param("id"))
+    if err != nil {
+        h.notFound(c, "Defect not found")
+        return
+    }
+
+    var req models.WatcherRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+    if req.Reason == "" {
+        req.Reason = models.WatchManual
+    }
+
+    if err := watchDefect(h.DB, defect.ID, &req.UserID, req.Reason); err != nil {
+        h.internalError(c, "Failed to watch defect")
+        return
+    }
+
+    h.success(c, gin.H{
+        "defect_id": defect.ID,
+        "user_id":   req.UserID,
+    }, "Defect watched successfully")
+}
+
+// GetEventRecipients - получатели уведомления о событии дефекта: автор, исполнитель,
+// подписчики дефекта и его проекта (с портфелем и программой), для эскалаций - менеджер
+// проекта. Инициатор события (actor_id) уведомление не получает, сотрудники подрядчиков -
+// только по дефектам своей организации
+func (h *DefectHandler) GetEventRecipients(c *gin.Context) {
+    defect, _, err := h.findDefect(h.DB, c.Param("id"))
+    if err != nil {
+        h.notFound(c, "Defect not found")
+        return
+    }
+
+    var actorID uint64
+    if actor := c.Query("actor_id"); actor != "" {
+        actorID, err = strconv.ParseUint(actor, 10, 32)
+        if err != nil {
+            h.badRequest(c, "Invalid actor ID")
+            return
+        }
+    }
+    event := c.Query("event")
+
+    var project models.Project
+    if err := h.DB.First(&project, defect.ProjectID).Error; err != nil {
+        h.notFound(c, "Project not found")
+        return
+    }
+
+    reasons := map[uint][]string{}
+    add := func(userID *uint, reason string) {
+        if userID != nil && *userID != 0 {
+            reasons[*userID] = append(reasons[*userID], reason)
+        }
+    }
+    add(&defect.AuthorID, "author")
+    add(defect.AssigneeID, "assignee")
+    if event == models.EventEscalated {
+        add(&project.ManagerID, "manager")
+    }
+
+    var defectWatchers []models.DefectWatcher
+    if err := h.DB.Where("defect_id = ?", defect.ID).Find(&defectWatchers).Error; err != nil {
+        h.internalError(c, "Failed to fetch watchers")
+        return
+    }
+    for i := range defectWatchers {
+        add(&defectWatchers[i].UserID, "watcher")
+    }
+
+    projectIDs := pathIDs(project.Path)
+    if len(projectIDs) == 0 {
+        projectIDs = []uint{project.ID}
+    }
+    var projectWatchers []models.ProjectWatcher
+    if err := h.DB.Where("project_id IN ?", projectIDs).Find(&projectWatchers).Error; err != nil {
+        h.internalError(c, "Failed to fetch watchers")
+        return
+    }
+    for i := range projectWatchers {
+        add(&projectWatchers[i].UserID, "project_watcher")
+    }
+    delete(reasons, uint(actorID))
+
+    // Сотрудники подрядчиков видят только дефекты своей организации
+    userIDs := make([]uint, 0, len(reasons))
+    for userID := range reasons {
+        userIDs = append(userIDs, userID)
+    }
+    var members []models.ContractorMember
+    if len(userIDs) > 0 {
+        if err := h.DB.Where("user_id IN ?", userIDs).Find(&members).Error; err != nil {
+            h.internalError(c, "Failed to fetch contractor members")
+            return
+        }
+    }
+    for _, member := range members {
+        if !sameUint(defect.ContractorID, &member.ContractorID) {
+            delete(reasons, member.UserID)
+        }
+    }
+
+    recipients := make([]models.EventRecipient, 0, len(reasons))
+    for userID, userReasons := range reasons {
+        recipients = append(recipients, models.EventRecipient{UserID: userID, Reasons: dedupeStrings(userReasons)})
+    }
+    sort.Slice(recipients, func(i, j int) bool { return recipients[i].UserID < recipients[j].UserID })
+
+    h.success(c, gin.H{
+        "defect_id":  defect.ID,
+        "project_id": defect.ProjectID,
+        "event":      event,
+        "recipients": recipients,
+    }, "Event recipients retrieved successfully")
+}
+
+// dedupeStrings убирает повторы, сохраняя порядок
+func dedupeStrings(values []string) []string {
+    seen := make(map[string]bool, len(values))
+    result := make([]string, 0, len(values))
+    for _, value := range values {
+        if !seen[value] {
+            seen[value] = true
+            result = append(result, value)
+        }
+    }
+    return result
+}
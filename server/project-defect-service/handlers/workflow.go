@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"project-defect-service/models"
+
+	"gorm.io/gorm"
+)
+
+// checkStatusTransition проверяет ограничения рабочего процесса перед сменой статуса
+func (h *Handler) checkStatusTransition(defect *models.Defect, newStatus models.DefectStatus) error {
+    if newStatus == defect.Status {
+        return nil
+    }
+
+    if newStatus == models.StatusOnReview {
+        if err := h.checkChecklistComplete(defect); err != nil {
+            return err
+        }
+    }
+    if newStatus == models.StatusClosed {
+        if defect.AcceptanceStatus != models.AcceptanceAccepted {
+            return fmt.Errorf("defect must be accepted by technical supervision before closing")
+        }
+        if err := h.checkCanClose(defect); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// afterStatusChange выполняет побочные действия смены статуса в той же транзакции
+func afterStatusChange(tx *gorm.DB, defect *models.Defect, oldStatus models.DefectStatus, userID uint) error {
+    if defect.Status == oldStatus {
+        return nil
+    }
+
+    if defect.Status == models.StatusClosed {
+        if err := cancelDuplicates(tx, defect, userID); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// markStatusTimestamps отмечает время первой реакции (выход из new) и время
+// устранения для SLA; при переоткрытии отметка устранения снимается
+func markStatusTimestamps(defect *models.Defect, oldStatus models.DefectStatus, now time.Time) {
+    if defect.Status == oldStatus {
+        return
+    }
+
+    if oldStatus == models.StatusNew && defect.RespondedAt == nil {
+        defect.RespondedAt = &now
+    }
+    if defect.Status.IsOpen() {
+        defect.ResolvedAt = nil
+    } else if oldStatus.IsOpen() {
+        defect.ResolvedAt = &now
+    }
+}
+
+// resetAcceptance снимает запрос и результат приемки, когда дефект возвращается
+// в работу или переоткрывается после закрытия: исправление принимается заново
+func resetAcceptance(defect *models.Defect, oldStatus models.DefectStatus) {
+    if defect.Status == oldStatus || defect.AcceptanceStatus == "" || defect.AcceptanceStatus == models.AcceptanceRejected {
+        return
+    }
+
+    reopened := oldStatus == models.StatusClosed && defect.Status.IsOpen()
+    if reopened || defect.Status == models.StatusNew || defect.Status == models.StatusInProgress {
+        defect.AcceptanceStatus = ""
+        defect.AcceptedBy = nil
+        defect.AcceptedAt = nil
+    }
+}
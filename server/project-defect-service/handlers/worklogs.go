@@ -0,0 +1,345 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"project-defect-service/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type WorkLogHandler struct {
+    Handler
+}
+
+func NewWorkLogHandler(db *gorm.DB, jwtSecret, authServiceURL string) *WorkLogHandler {
+    return &WorkLogHandler{
+        Handler: *NewHandler(db, jwtSecret, authServiceURL),
+    }
+}
+
+// Стоимость записи в SQL: минуты по ставке за час, без ставки - ноль
+const workLogCostSQL = "COALESCE(SUM(work_logs.minutes * work_logs.hourly_rate / 60), 0)"
+
+func (h *WorkLogHandler) GetWorkLogs(c *gin.Context) {
+    defect, _, err := h.findDefect(h.scopeDefects(c, h.DB), c.Param("id"))
+    if err != nil {
+        h.notFound(c, "Defect not found")
+        return
+    }
+
+    h.respondWithWorkLogs(c, defect, "Work logs retrieved successfully")
+}
+
+// CreateWorkLog - запись затраченного времени. Время пишут исполнитель дефекта,
+// участники команды проекта и сотрудники подрядчика дефекта
+func (h *WorkLogHandler) CreateWorkLog(c *gin.Context) {
+    defect, ok := h.findWritableDefect(c, h.DB)
+    if !ok {
+        return
+    }
+
+    userID, _, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return
+    }
+
+    var req models.WorkLogRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+    if err := validateWorkLogDate(req.Date); err != nil {
+        h.badRequest(c, err.Error())
+        return
+    }
+
+    member, err := h.canLogTime(defect, userID)
+    if err != nil {
+        h.internalError(c, "Failed to check project membership")
+        return
+    }
+    if !member {
+        h.error(c, http.StatusForbidden, "Only project members can log time on this defect")
+        return
+    }
+
+    entry := models.WorkLog{
+        DefectID:   defect.ID,
+        UserID:     userID,
+        Date:       *req.Date,
+        Minutes:    req.Minutes,
+        Note:       req.Note,
+        HourlyRate: req.HourlyRate,
+    }
+    var membership models.ContractorMember
+    if err := h.DB.Where("user_id = ?", userID).First(&membership).Error; err == nil {
+        entry.ContractorID = &membership.ContractorID
+    }
+
+    err = h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Create(&entry).Error; err != nil {
+            return err
+        }
+        return recordDefectChange(tx, defect.ID, userID, "worklog", "none", formatWorkLog(&entry))
+    })
+    if err != nil {
+        h.internalError(c, "Failed to log time")
+        return
+    }
+
+    h.respondWithWorkLogs(c, defect, "Time logged successfully")
+}
+
+// UpdateWorkLog - исправление записи ее автором или менеджером проекта
+func (h *WorkLogHandler) UpdateWorkLog(c *gin.Context) {
+    defect, entry, ok := h.findOwnWorkLog(c)
+    if !ok {
+        return
+    }
+
+    var req models.WorkLogRequest
+    if !h.validateRequest(c, &req) {
+        return
+    }
+    if err := validateWorkLogDate(req.Date); err != nil {
+        h.badRequest(c, err.Error())
+        return
+    }
+
+    userID, _, _ := h.GetUserFromContext(c)
+    old := formatWorkLog(entry)
+    entry.Date = *req.Date
+    entry.Minutes = req.Minutes
+    entry.Note = req.Note
+    entry.HourlyRate = req.HourlyRate
+
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Save(entry).Error; err != nil {
+            return err
+        }
+        if updated := formatWorkLog(entry); updated != old {
+            return recordDefectChange(tx, defect.ID, userID, "worklog", old, updated)
+        }
+        return nil
+    })
+    if err != nil {
+        h.internalError(c, "Failed to update work log")
+        return
+    }
+
+    h.respondWithWorkLogs(c, defect, "Work log updated successfully")
+}
+
+func (h *WorkLogHandler) DeleteWorkLog(c *gin.Context) {
+    defect, entry, ok := h.findOwnWorkLog(c)
+    if !ok {
+        return
+    }
+
+    userID, _, _ := h.GetUserFromContext(c)
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Delete(entry).Error; err != nil {
+            return err
+        }
+        return recordDefectChange(tx, defect.ID, userID, "worklog", formatWorkLog(entry), "none")
+    })
+    if err != nil {
+        h.internalError(c, "Failed to delete work log")
+        return
+    }
+
+    h.respondWithWorkLogs(c, defect, "Work log deleted successfully")
+}
+
+// GetTimeReport - затраты времени и стоимость по проектам, подрядчикам или инженерам.
+// Фильтры: project_id (вместе с вложенными проектами), contractor_id, user_id, from и to
+func (h *WorkLogHandler) GetTimeReport(c *gin.Context) {
+    groups := map[string]struct{ key, name string }{
+        "project":    {"defects.project_id", "COALESCE(MAX(projects.name), '')"},
+        "contractor": {"work_logs.contractor_id", "COALESCE(MAX(contractors.name), '')"},
+        "engineer":   {"work_logs.user_id", "''"},
+    }
+    groupBy := c.DefaultQuery("group_by", "project")
+    group, ok := groups[groupBy]
+    if !ok {
+        h.badRequest(c, "group_by must be project, contractor or engineer")
+        return
+    }
+
+    // Списанное время остается в отчетах и после удаления дефекта в корзину
+    query := h.DB.Model(&models.WorkLog{}).
+        Joins("JOIN defects ON defects.id = work_logs.defect_id").
+        Joins("JOIN projects ON projects.id = defects.project_id").
+        Joins("LEFT JOIN contractors ON contractors.id = work_logs.contractor_id")
+
+    for _, filter := range []struct{ param, column string }{
+        {"contractor_id", "work_logs.contractor_id"},
+        {"user_id", "work_logs.user_id"},
+    } {
+        value := c.Query(filter.param)
+        if value == "" {
+            continue
+        }
+        id, err := strconv.ParseUint(value, 10, 32)
+        if err != nil {
+            h.badRequest(c, "Invalid "+filter.param)
+            return
+        }
+        query = query.Where(filter.column+" = ?", id)
+    }
+    if projectID := c.Query("project_id"); projectID != "" {
+        var project models.Project
+        if err := h.DB.First(&project, projectID).Error; err != nil {
+            h.notFound(c, "Project not found")
+            return
+        }
+        query = query.Where("projects.path LIKE ?", project.Path+"%")
+    }
+    for _, bound := range []struct{ param, op string }{{"from", ">="}, {"to", "<="}} {
+        value := c.Query(bound.param)
+        if value == "" {
+            continue
+        }
+        date, err := time.Parse("2006-01-02", value)
+        if err != nil {
+            h.badRequest(c, bound.param+" must be a date in YYYY-MM-DD format")
+            return
+        }
+        query = query.Where("work_logs.date "+bound.op+" ?", date)
+    }
+
+    rows := []models.TimeReportRow{}
+    if err := query.
+        Select(group.key + " AS id, " + group.name + " AS name, " +
+            "COUNT(*) AS entries, SUM(work_logs.minutes) AS minutes, " + workLogCostSQL + " AS cost").
+        Group(group.key).
+        Order("minutes DESC").
+        Scan(&rows).Error; err != nil {
+        h.internalError(c, "Failed to build time report")
+        return
+    }
+    for i := range rows {
+        rows[i].Hours = minutesToHours(rows[i].Minutes)
+        rows[i].Cost = roundMoney(rows[i].Cost)
+    }
+
+    h.success(c, gin.H{
+        "group_by": groupBy,
+        "rows":     rows,
+    }, "Time report generated successfully")
+}
+
+// canLogTime - исполнитель дефекта, сотрудник его подрядчика или участник команды проекта
+func (h *Handler) canLogTime(defect *models.Defect, userID uint) (bool, error) {
+    if defect.AssigneeID != nil && *defect.AssigneeID == userID {
+        return true, nil
+    }
+    if defect.ContractorID != nil {
+        var count int64
+        if err := h.DB.Model(&models.ContractorMember{}).
+            Where("contractor_id = ? AND user_id = ?", *defect.ContractorID, userID).
+            Count(&count).Error; err != nil {
+            return false, err
+        }
+        if count > 0 {
+            return true, nil
+        }
+    }
+
+    var project models.Project
+    if err := h.DB.First(&project, defect.ProjectID).Error; err != nil {
+        return false, err
+    }
+    team, err := h.projectTeam(&project)
+    if err != nil {
+        return false, err
+    }
+    for _, member := range team {
+        if member.UserID == userID {
+            return true, nil
+        }
+    }
+    return false, nil
+}
+
+// findOwnWorkLog - запись дефекта из пути, если ее может менять текущий пользователь
+func (h *WorkLogHandler) findOwnWorkLog(c *gin.Context) (*models.Defect, *models.WorkLog, bool) {
+    defect, ok := h.findWritableDefect(c, h.DB)
+    if !ok {
+        return nil, nil, false
+    }
+
+    var entry models.WorkLog
+    if err := h.DB.Where("defect_id = ?", defect.ID).First(&entry, c.Param("log_id")).Error; err != nil {
+        h.notFound(c, "Work log not found")
+        return nil, nil, false
+    }
+
+    userID, userRole, err := h.GetUserFromContext(c)
+    if err != nil {
+        h.unauthorized(c, "User not authenticated")
+        return nil, nil, false
+    }
+    if entry.UserID != userID {
+        var project models.Project
+        if err := h.DB.First(&project, defect.ProjectID).Error; err != nil || !h.managesProject(userID, userRole, &project) {
+            h.error(c, http.StatusForbidden, "You can only change your own work logs")
+            return nil, nil, false
+        }
+    }
+    return defect, &entry, true
+}
+
+func (h *WorkLogHandler) respondWithWorkLogs(c *gin.Context, defect *models.Defect, message string) {
+    entries := []models.WorkLog{}
+    if err := h.DB.Where("defect_id = ?", defect.ID).Order("date DESC, id DESC").Find(&entries).Error; err != nil {
+        h.internalError(c, "Failed to fetch work logs")
+        return
+    }
+
+    var totals models.WorkLogTotals
+    if err := h.DB.Model(&models.WorkLog{}).
+        Select("COUNT(*) AS entries, COALESCE(SUM(work_logs.minutes), 0) AS minutes, "+workLogCostSQL+" AS cost").
+        Where("defect_id = ?", defect.ID).
+        Scan(&totals).Error; err != nil {
+        h.internalError(c, "Failed to calculate work log totals")
+        return
+    }
+    totals.Hours = minutesToHours(totals.Minutes)
+    totals.Cost = roundMoney(totals.Cost)
+
+    h.success(c, gin.H{
+        "work_logs": entries,
+        "totals":    totals,
+    }, message)
+}
+
+// validateWorkLogDate - время не списывается на будущие дни
+func validateWorkLogDate(date *models.Date) error {
+    if date == nil || date.IsZero() {
+        return fmt.Errorf("date is required")
+    }
+    if date.After(time.Now().UTC()) {
+        return fmt.Errorf("time cannot be logged for future dates")
+    }
+    return nil
+}
+
+// formatWorkLog - запись в истории дефекта: "2026-10-01 90m by 5"
+func formatWorkLog(entry *models.WorkLog) string {
+    return fmt.Sprintf("%s %dm by %d", entry.Date.Format("2006-01-02"), entry.Minutes, entry.UserID)
+}
+
+func minutesToHours(minutes int64) float64 {
+    return math.Round(float64(minutes)/60*100) / 100
+}
+
+func roundMoney(value float64) float64 {
+    return math.Round(value*100) / 100
+}
@@ -1,12 +1,14 @@
 package main
 
 import (
+	"context"
 	"log"
 
 	"project-defect-service/config"
 	"project-defect-service/database"
 	"project-defect-service/handlers"
 	"project-defect-service/middleware"
+	"project-defect-service/scheduler"
 
 	"github.com/gin-gonic/gin"
 )
@@ -25,10 +27,26 @@ func main() {
         log.Fatal("Failed to connect to database:", err)
     }
     
+    // Напоминания о сроках, эскалация просроченных дефектов и очистка корзины
+    scheduler.New(db, cfg.SchedulerInterval, cfg.ReminderLead, cfg.TrashRetention, cfg.AuthServiceURL, cfg.ContentServiceURL, cfg.ServiceToken).Start(context.Background())
+    
     r := gin.Default()
     
     projectHandler := handlers.NewProjectHandler(db, cfg.JWTSecret, cfg.AuthServiceURL)
-    defectHandler := handlers.NewDefectHandler(db, cfg.JWTSecret, cfg.AuthServiceURL)
+    defectHandler := handlers.NewDefectHandler(db, cfg.JWTSecret, cfg.AuthServiceURL, cfg.ContentServiceURL)
+    locationHandler := handlers.NewLocationHandler(db, cfg.JWTSecret, cfg.AuthServiceURL)
+    categoryHandler := handlers.NewCategoryHandler(db, cfg.JWTSecret, cfg.AuthServiceURL)
+    labelHandler := handlers.NewLabelHandler(db, cfg.JWTSecret, cfg.AuthServiceURL)
+    customFieldHandler := handlers.NewCustomFieldHandler(db, cfg.JWTSecret, cfg.AuthServiceURL)
+    relationHandler := handlers.NewRelationHandler(db, cfg.JWTSecret, cfg.AuthServiceURL)
+    checklistHandler := handlers.NewChecklistHandler(db, cfg.JWTSecret, cfg.AuthServiceURL)
+    workLogHandler := handlers.NewWorkLogHandler(db, cfg.JWTSecret, cfg.AuthServiceURL)
+    backChargeHandler := handlers.NewBackChargeHandler(db, cfg.JWTSecret, cfg.AuthServiceURL)
+    inspectionHandler := handlers.NewInspectionHandler(db, cfg.JWTSecret, cfg.AuthServiceURL)
+    slaHandler := handlers.NewSLAHandler(db, cfg.JWTSecret, cfg.AuthServiceURL)
+    escalationHandler := handlers.NewEscalationHandler(db, cfg.JWTSecret, cfg.AuthServiceURL)
+    contractorHandler := handlers.NewContractorHandler(db, cfg.JWTSecret, cfg.AuthServiceURL)
+    trashHandler := handlers.NewTrashHandler(db, cfg.JWTSecret, cfg.AuthServiceURL, cfg.TrashRetention)
     
     // Protected routes
     api := r.Group("/api")
@@ -43,21 +61,191 @@ func main() {
             projects.POST("", projectHandler.CreateProject)
             projects.PUT("/:id", projectHandler.UpdateProject)
             projects.DELETE("/:id", projectHandler.DeleteProject)
+            projects.PATCH("/:id/phase", projectHandler.UpdateProjectPhase)
+            projects.POST("/:id/template", projectHandler.SaveAsTemplate)
+            projects.POST("/:id/clone", projectHandler.CloneProject)
+            projects.PATCH("/:id/parent", projectHandler.MoveProject)
+            projects.GET("/:id/rollup", projectHandler.GetProjectRollup)
+            projects.GET("/:id/members", projectHandler.GetProjectMembers)
+            projects.POST("/:id/members", projectHandler.AddProjectMember)
+            projects.DELETE("/:id/members/:user_id", projectHandler.DeleteProjectMember)
+            projects.GET("/:id/watchers", projectHandler.GetProjectWatchers)
+            projects.POST("/:id/watch", projectHandler.WatchProject)
+            projects.DELETE("/:id/watch", projectHandler.UnwatchProject)
+            projects.GET("/:id/defects", defectHandler.GetProjectDefects)
+            projects.GET("/:id/locations", locationHandler.GetLocations)
+            projects.POST("/:id/locations", locationHandler.CreateLocation)
+            projects.GET("/:id/category-assignees", categoryHandler.GetCategoryAssignees)
+            projects.PUT("/:id/category-assignees/:category_id", categoryHandler.SetCategoryAssignee)
+            projects.DELETE("/:id/category-assignees/:category_id", categoryHandler.DeleteCategoryAssignee)
+            projects.GET("/:id/contractors", contractorHandler.GetProjectContractors)
+            projects.PUT("/:id/contractors/:contractor_id", contractorHandler.SetProjectContractor)
+            projects.DELETE("/:id/contractors/:contractor_id", contractorHandler.DeleteProjectContractor)
+            projects.GET("/:id/labels", labelHandler.GetLabels)
+            projects.POST("/:id/labels", labelHandler.CreateLabel)
+            projects.GET("/:id/custom-fields", customFieldHandler.GetCustomFields)
+            projects.POST("/:id/custom-fields", customFieldHandler.CreateCustomField)
+            projects.GET("/:id/sla-policies", slaHandler.GetSLAPolicies)
+            projects.POST("/:id/sla-policies", slaHandler.CreateSLAPolicy)
+            projects.GET("/:id/calendar", slaHandler.GetCalendar)
+            projects.PUT("/:id/calendar", slaHandler.SetCalendar)
+            projects.POST("/:id/calendar/holidays", slaHandler.AddHoliday)
+            projects.DELETE("/:id/calendar/holidays/:holiday_id", slaHandler.DeleteHoliday)
+            projects.GET("/:id/escalation-rules", escalationHandler.GetEscalationRules)
+            projects.POST("/:id/escalation-rules", escalationHandler.CreateEscalationRule)
+            projects.GET("/:id/inspection-schedules", inspectionHandler.GetInspectionSchedules)
+            projects.POST("/:id/inspection-schedules", inspectionHandler.CreateInspectionSchedule)
+            projects.GET("/:id/inspection-rounds", inspectionHandler.GetInspectionRounds)
+            projects.POST("/:id/inspection-rounds", inspectionHandler.CreateInspectionRound)
+            projects.GET("/:id/inspection-summary", inspectionHandler.GetInspectionSummary)
+        }
+        
+        // Правила эскалации и лента событий
+        escalationRules := api.Group("/escalation-rules")
+        {
+            escalationRules.PUT("/:id", escalationHandler.UpdateEscalationRule)
+            escalationRules.DELETE("/:id", escalationHandler.DeleteEscalationRule)
+        }
+        api.GET("/defect-events", escalationHandler.GetDefectEvents)
+        api.GET("/workload", defectHandler.GetWorkload)
+        
+        // Обходы и правила регулярных обходов
+        inspectionSchedules := api.Group("/inspection-schedules")
+        {
+            inspectionSchedules.PUT("/:id", inspectionHandler.UpdateInspectionSchedule)
+            inspectionSchedules.DELETE("/:id", inspectionHandler.DeleteInspectionSchedule)
+        }
+        inspectionRounds := api.Group("/inspection-rounds")
+        {
+            inspectionRounds.GET("/:id", inspectionHandler.GetInspectionRound)
+            inspectionRounds.DELETE("/:id", inspectionHandler.DeleteInspectionRound)
+            inspectionRounds.PATCH("/:id/status", inspectionHandler.UpdateInspectionRoundStatus)
+            inspectionRounds.PUT("/:id/items/:item_id", inspectionHandler.UpdateInspectionItem)
         }
         
+        // Корзина удаленных проектов и дефектов
+        trash := api.Group("/trash")
+        {
+            trash.GET("", trashHandler.GetTrash)
+            trash.POST("/defects/:id/restore", trashHandler.RestoreDefect)
+            trash.POST("/projects/:id/restore", trashHandler.RestoreProject)
+        }
+        
+        // SLA-политики проектов
+        slaPolicies := api.Group("/sla-policies")
+        {
+            slaPolicies.PUT("/:id", slaHandler.UpdateSLAPolicy)
+            slaPolicies.DELETE("/:id", slaHandler.DeleteSLAPolicy)
+        }
+        
+        // Дополнительные поля дефектов
+        customFields := api.Group("/custom-fields")
+        {
+            customFields.PUT("/:id", customFieldHandler.UpdateCustomField)
+            customFields.DELETE("/:id", customFieldHandler.DeleteCustomField)
+        }
+        
+        // Метки проектов
+        labels := api.Group("/labels")
+        {
+            labels.PUT("/:id", labelHandler.UpdateLabel)
+            labels.DELETE("/:id", labelHandler.DeleteLabel)
+        }
+        
+        // Места: объект → корпус → этаж → секция/помещение
+        locations := api.Group("/locations")
+        {
+            locations.PUT("/:id", locationHandler.UpdateLocation)
+            locations.DELETE("/:id", locationHandler.DeleteLocation)
+        }
+        
+        // Классификатор дефектов: вид работ → категория → подкатегория
+        categories := api.Group("/categories")
+        {
+            categories.GET("", categoryHandler.GetCategories)
+            categories.POST("", categoryHandler.CreateCategory)
+            categories.PUT("/:id", categoryHandler.UpdateCategory)
+            categories.DELETE("/:id", categoryHandler.DeleteCategory)
+        }
+        
+        // Подрядные организации и их сотрудники
+        contractors := api.Group("/contractors")
+        {
+            contractors.GET("", contractorHandler.GetContractors)
+            contractors.GET("/ranking", contractorHandler.GetContractorRanking)
+            contractors.GET("/:id", contractorHandler.GetContractor)
+            contractors.POST("", contractorHandler.CreateContractor)
+            contractors.PUT("/:id", contractorHandler.UpdateContractor)
+            contractors.DELETE("/:id", contractorHandler.DeleteContractor)
+            contractors.POST("/:id/members", contractorHandler.AddContractorMember)
+            contractors.DELETE("/:id/members/:user_id", contractorHandler.RemoveContractorMember)
+        }
+        
+        // Обратные начисления подрядчикам
+        backCharges := api.Group("/back-charges")
+        {
+            backCharges.PATCH("/:id/status", backChargeHandler.DecideBackCharge)
+            backCharges.DELETE("/:id", backChargeHandler.DeleteBackCharge)
+        }
+        
+        // Метки дефектов на чертежах (сами чертежи хранит content-service)
+        api.GET("/floor-plans/:id/pins", defectHandler.GetPlanPins)
+        
         // Дефекты
         defects := api.Group("/defects")
         {
             defects.GET("", defectHandler.GetDefects)
             defects.GET("/my", defectHandler.GetMyDefects)
+            defects.GET("/by-location", defectHandler.GetDefectsByLocation)
+            defects.GET("/by-category", defectHandler.GetDefectsByCategory)
+            defects.GET("/duplicates", defectHandler.CheckDuplicates)
             defects.GET("/:id", defectHandler.GetDefect)
             defects.POST("", defectHandler.CreateDefect)
             defects.PUT("/:id", defectHandler.UpdateDefect)
             defects.PATCH("/:id/status", defectHandler.UpdateDefectStatus)
+            defects.GET("/:id/history", defectHandler.GetDefectHistory)
+            defects.POST("/:id/history/:change_set_id/revert", defectHandler.RevertChangeSet)
             defects.DELETE("/:id", defectHandler.DeleteDefect)
+            defects.POST("/:id/labels", labelHandler.AddDefectLabels)
+            defects.DELETE("/:id/labels/:label_id", labelHandler.RemoveDefectLabel)
+            defects.GET("/:id/relations", relationHandler.GetDefectRelations)
+            defects.POST("/:id/relations", relationHandler.CreateDefectRelation)
+            defects.DELETE("/:id/relations/:relation_id", relationHandler.DeleteDefectRelation)
+            defects.GET("/:id/checklist", checklistHandler.GetChecklist)
+            defects.POST("/:id/checklist", checklistHandler.CreateChecklistItem)
+            defects.PUT("/:id/checklist/order", checklistHandler.ReorderChecklist)
+            defects.PUT("/:id/checklist/:item_id", checklistHandler.UpdateChecklistItem)
+            defects.DELETE("/:id/checklist/:item_id", checklistHandler.DeleteChecklistItem)
+            defects.GET("/:id/worklogs", workLogHandler.GetWorkLogs)
+            defects.POST("/:id/worklogs", workLogHandler.CreateWorkLog)
+            defects.PUT("/:id/worklogs/:log_id", workLogHandler.UpdateWorkLog)
+            defects.DELETE("/:id/worklogs/:log_id", workLogHandler.DeleteWorkLog)
+            defects.GET("/:id/acceptance", defectHandler.GetDefectAcceptance)
+            defects.POST("/:id/acceptance/request", defectHandler.RequestAcceptance)
+            defects.POST("/:id/acceptance/accept", defectHandler.AcceptDefect)
+            defects.POST("/:id/acceptance/reject", defectHandler.RejectDefect)
+            defects.GET("/:id/back-charges", backChargeHandler.GetBackCharges)
+            defects.POST("/:id/back-charges", backChargeHandler.CreateBackCharge)
+            defects.GET("/:id/watchers", defectHandler.GetDefectWatchers)
+            defects.POST("/:id/watch", defectHandler.WatchDefect)
+            defects.DELETE("/:id/watch", defectHandler.UnwatchDefect)
         }
     }
     
+    // Внутренние маршруты для других сервисов (через gateway не проксируются)
+    internal := r.Group("/internal")
+    internal.Use(middleware.ServiceTokenMiddleware(cfg.ServiceToken))
+    {
+        internal.GET("/projects/:id/state", projectHandler.GetProjectState)
+        internal.GET("/defects/:id/state", projectHandler.GetDefectProjectState)
+        internal.GET("/reports/contractors", contractorHandler.GetContractorRanking)
+        internal.POST("/defects/:id/watchers", defectHandler.AddDefectWatcher)
+        internal.GET("/reports/time", workLogHandler.GetTimeReport)
+        internal.GET("/reports/costs", backChargeHandler.GetCostRollup)
+        internal.GET("/defects/:id/recipients", defectHandler.GetEventRecipients)
+        internal.GET("/categories/labels", categoryHandler.GetCategoryLabels)
+    }
+    
     // Health check
     r.GET("/health", func(c *gin.Context) {
         c.JSON(200, gin.H{
@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"crypto/subtle"
+	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -20,4 +22,21 @@ func ServiceAuthMiddleware() gin.HandlerFunc {
         // Если нет сервисного токена, используем обычную JWT аутентификацию
         c.Next()
     }
-}
\ No newline at end of file
+}
+
+// ServiceTokenMiddleware пропускает только запросы других сервисов с общим токеном
+func ServiceTokenMiddleware(serviceToken string) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        token := c.GetHeader("X-Service-Token")
+        if serviceToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(serviceToken)) != 1 {
+            c.JSON(http.StatusUnauthorized, gin.H{
+                "success": false,
+                "error":   "Invalid service token",
+            })
+            c.Abort()
+            return
+        }
+        
+        c.Next()
+    }
+}
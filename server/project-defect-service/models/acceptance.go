@@ -0,0 +1,27 @@
+package models
+
+// Состояние приемки устранения дефекта; пустое - приемка не запрашивалась
+type AcceptanceStatus string
+
+const (
+    AcceptanceRequested AcceptanceStatus = "requested"
+    AcceptanceAccepted  AcceptanceStatus = "accepted"
+    AcceptanceRejected  AcceptanceStatus = "rejected"
+)
+
+// DefectAcceptance - шаг приемки: запрос, приемка или отказ с причиной и фото.
+// PhotoIDs - вложения дефекта в content-service
+type DefectAcceptance struct {
+    BaseModel
+    DefectID uint             `gorm:"not null;index" json:"defect_id"`
+    Decision AcceptanceStatus `gorm:"size:16;not null" json:"decision"`
+    UserID   uint             `gorm:"not null" json:"user_id"`
+    Reason   string           `json:"reason,omitempty"`
+    PhotoIDs []uint           `gorm:"type:jsonb;serializer:json" json:"photo_ids,omitempty"`
+}
+
+// AcceptanceRequest - запрос приемки, решение надзора или отказ (reason обязателен)
+type AcceptanceRequest struct {
+    Reason   string `json:"reason"`
+    PhotoIDs []uint `json:"photo_ids" binding:"omitempty,max=20,dive,min=1"`
+}
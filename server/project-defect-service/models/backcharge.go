@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// DefaultCurrency - валюта стоимости, если она не указана
+const DefaultCurrency = "RUB"
+
+// BackChargeStatus - согласование выставления затрат подрядчику
+type BackChargeStatus string
+
+const (
+    BackChargePending  BackChargeStatus = "pending"
+    BackChargeApproved BackChargeStatus = "approved"
+    BackChargeRejected BackChargeStatus = "rejected"
+)
+
+// BackCharge - затраты на устранение дефекта, выставляемые ответственному
+// подрядчику, когда дефект устранил кто-то другой
+type BackCharge struct {
+    BaseModel
+    DefectID     uint             `gorm:"not null;index" json:"defect_id"`
+    ContractorID uint             `gorm:"not null;index" json:"contractor_id"`
+    Amount       float64          `gorm:"type:numeric(14,2);not null" json:"amount"`
+    Currency     string           `gorm:"size:3;not null" json:"currency"`
+    Reason       string           `gorm:"not null" json:"reason"`
+    Status       BackChargeStatus `gorm:"not null;default:'pending';index" json:"status"`
+    CreatedBy    uint             `gorm:"not null" json:"created_by"`
+    // Кто и когда согласовал или отклонил
+    DecidedBy    *uint            `json:"decided_by,omitempty"`
+    DecidedAt    *time.Time       `json:"decided_at,omitempty"`
+    DecisionNote string           `json:"decision_note,omitempty"`
+
+    Contractor   *Contractor      `json:"contractor,omitempty"`
+}
+
+// BackChargeRequest - без contractor_id выставляется подрядчику дефекта,
+// без amount и currency - фактическая стоимость устранения дефекта
+type BackChargeRequest struct {
+    ContractorID *uint    `json:"contractor_id"`
+    Amount       *float64 `json:"amount" binding:"omitempty,gt=0"`
+    Currency     string   `json:"currency" binding:"omitempty,iso4217"`
+    Reason       string   `json:"reason" binding:"required"`
+}
+
+type BackChargeDecisionRequest struct {
+    Status BackChargeStatus `json:"status" binding:"required,oneof=approved rejected"`
+    Note   string           `json:"note"`
+}
+
+// CostRollup - стоимость устранения и выставленные затраты проекта или подрядчика в одной валюте
+type CostRollup struct {
+    ID                 uint    `json:"id"`
+    Name               string  `json:"name"`
+    Currency           string  `json:"currency"`
+    Defects            int64   `json:"defects"`
+    EstimatedCost      float64 `json:"estimated_cost"`
+    ActualCost         float64 `json:"actual_cost"`
+    BackChargePending  float64 `json:"back_charge_pending"`
+    BackChargeApproved float64 `json:"back_charge_approved"`
+    BackChargeRejected float64 `json:"back_charge_rejected"`
+}
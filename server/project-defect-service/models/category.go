@@ -0,0 +1,71 @@
+package models
+
+type CategoryLevel string
+
+const (
+    CategoryTrade       CategoryLevel = "trade"
+    CategoryCategory    CategoryLevel = "category"
+    CategorySubcategory CategoryLevel = "subcategory"
+)
+
+// Родительский уровень для каждого уровня классификатора: вид работ → категория → подкатегория
+var categoryParents = map[CategoryLevel]CategoryLevel{
+    CategoryTrade:       "",
+    CategoryCategory:    CategoryTrade,
+    CategorySubcategory: CategoryCategory,
+}
+
+// Category - элемент классификатора дефектов (например, Электрика → Проводка → Открытый кабель).
+// Общие категории (ProjectID = nil) доступны во всех проектах, проектные - только в своем.
+type Category struct {
+    BaseModel
+    ProjectID         *uint         `gorm:"index" json:"project_id,omitempty"`
+    ParentID          *uint         `gorm:"index" json:"parent_id,omitempty"`
+    Level             CategoryLevel `gorm:"not null" json:"level"`
+    Name              string        `gorm:"not null" json:"name"`
+    Code              string        `json:"code,omitempty"`
+    Path              string        `gorm:"index" json:"path"`
+    DefaultAssigneeID *uint         `json:"default_assignee_id,omitempty"`
+
+    Children          []Category    `gorm:"-" json:"children,omitempty"`
+}
+
+// CategoryAssignee переопределяет исполнителя по умолчанию для категории в конкретном проекте
+type CategoryAssignee struct {
+    BaseModel
+    ProjectID  uint `gorm:"not null;uniqueIndex:idx_category_assignees_project_category" json:"project_id"`
+    CategoryID uint `gorm:"not null;uniqueIndex:idx_category_assignees_project_category" json:"category_id"`
+    AssigneeID uint `gorm:"not null" json:"assignee_id"`
+}
+
+type CategoryCreateRequest struct {
+    ProjectID         *uint         `json:"project_id,omitempty"`
+    ParentID          *uint         `json:"parent_id,omitempty"`
+    Level             CategoryLevel `json:"level" binding:"required,oneof=trade category subcategory"`
+    Name              string        `json:"name" binding:"required"`
+    Code              string        `json:"code"`
+    DefaultAssigneeID *uint         `json:"default_assignee_id,omitempty"`
+}
+
+type CategoryUpdateRequest struct {
+    Name              *string `json:"name,omitempty"`
+    Code              *string `json:"code,omitempty"`
+    DefaultAssigneeID *uint   `json:"default_assignee_id,omitempty"`
+}
+
+type CategoryAssigneeRequest struct {
+    AssigneeID uint `json:"assignee_id" binding:"required"`
+}
+
+func (l CategoryLevel) ParentLevel() CategoryLevel {
+    return categoryParents[l]
+}
+
+// Статистика дефектов по категории
+type DefectsByCategory struct {
+    CategoryID uint          `json:"category_id"`
+    Name       string        `json:"name"`
+    Level      CategoryLevel `json:"level"`
+    Path       string        `json:"path"`
+    Count      int64         `json:"count"`
+}
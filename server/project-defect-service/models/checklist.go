@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// ChecklistItem - шаг устранения дефекта ("снять штукатурку", "заменить трубу")
+type ChecklistItem struct {
+    BaseModel
+    DefectID   uint       `gorm:"not null;index" json:"defect_id"`
+    Title      string     `gorm:"not null" json:"title"`
+    Position   int        `gorm:"not null;default:0" json:"position"`
+    AssigneeID *uint      `json:"assignee_id,omitempty"`
+    Done       bool       `gorm:"not null;default:false" json:"done"`
+    DoneBy     *uint      `json:"done_by,omitempty"`
+    DoneAt     *time.Time `json:"done_at,omitempty"`
+}
+
+// ChecklistProgress - сводка чек-листа для отображения на дефекте
+type ChecklistProgress struct {
+    Total   int `json:"total"`
+    Done    int `json:"done"`
+    Percent int `json:"percent"`
+}
+
+type ChecklistItemCreateRequest struct {
+    Title      string `json:"title" binding:"required,max=255"`
+    AssigneeID *uint  `json:"assignee_id,omitempty"`
+    Position   *int   `json:"position,omitempty"`
+}
+
+type ChecklistItemUpdateRequest struct {
+    Title      *string `json:"title,omitempty" binding:"omitempty,max=255"`
+    AssigneeID *uint   `json:"assignee_id,omitempty"`
+    Done       *bool   `json:"done,omitempty"`
+}
+
+// ChecklistOrderRequest - новый порядок пунктов: все ID пунктов дефекта
+type ChecklistOrderRequest struct {
+    ItemIDs []uint `json:"item_ids" binding:"required,min=1"`
+}
@@ -0,0 +1,59 @@
+package models
+
+// RoleContractor - роль сотрудников подрядных организаций в auth-service
+const RoleContractor = "contractor"
+
+// Contractor - подрядная организация, устраняющая дефекты
+type Contractor struct {
+    BaseModel
+    Name         string `gorm:"not null" json:"name"`
+    TaxID        string `gorm:"size:12" json:"tax_id,omitempty"`
+    ContactEmail string `json:"contact_email,omitempty"`
+    ContactPhone string `json:"contact_phone,omitempty"`
+
+    Members []ContractorMember `json:"members,omitempty"`
+}
+
+// ContractorMember - сотрудник подрядчика; пользователь состоит не больше чем в одной организации
+type ContractorMember struct {
+    BaseModel
+    ContractorID uint `gorm:"not null;index" json:"contractor_id"`
+    UserID       uint `gorm:"not null;uniqueIndex" json:"user_id"`
+}
+
+// ProjectContractor - договор подрядчика на проекте. Объем работ задается
+// видами работ и категориями классификатора; пустой объем - без ограничений
+type ProjectContractor struct {
+    BaseModel
+    ProjectID      uint       `gorm:"not null;uniqueIndex:idx_project_contractors_project_contractor" json:"project_id"`
+    ContractorID   uint       `gorm:"not null;uniqueIndex:idx_project_contractors_project_contractor" json:"contractor_id"`
+    ContractNumber string     `json:"contract_number,omitempty"`
+    Scope          []Category `gorm:"many2many:project_contractor_categories" json:"scope"`
+
+    Contractor     *Contractor `json:"contractor,omitempty"`
+}
+
+type ContractorRequest struct {
+    Name         string `json:"name" binding:"required"`
+    TaxID        string `json:"tax_id" binding:"omitempty,numeric,min=10,max=12"`
+    ContactEmail string `json:"contact_email" binding:"omitempty,email"`
+    ContactPhone string `json:"contact_phone"`
+}
+
+type ContractorMemberRequest struct {
+    UserID uint `json:"user_id" binding:"required"`
+}
+
+type ProjectContractorRequest struct {
+    ContractNumber string `json:"contract_number"`
+    CategoryIDs    []uint `json:"category_ids"`
+}
+
+// ContractorRanking - строка рейтинга подрядчиков по дефектам
+type ContractorRanking struct {
+    ContractorID   uint   `json:"contractor_id"`
+    Name           string `json:"name"`
+    OpenDefects    int64  `json:"open_defects"`
+    OverdueDefects int64  `json:"overdue_defects"`
+    ClosedDefects  int64  `json:"closed_defects"`
+}
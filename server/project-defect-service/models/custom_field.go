@@ -0,0 +1,194 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CustomFieldType string
+
+const (
+    FieldText        CustomFieldType = "text"
+    FieldNumber      CustomFieldType = "number"
+    FieldDate        CustomFieldType = "date"
+    FieldSelect      CustomFieldType = "select"
+    FieldMultiSelect CustomFieldType = "multiselect"
+    FieldUser        CustomFieldType = "user"
+)
+
+// CustomField - дополнительное поле дефектов проекта (пункт гарантии, номер лота, номер акта)
+type CustomField struct {
+    BaseModel
+    ProjectID uint            `gorm:"not null;uniqueIndex:idx_custom_fields_project_key" json:"project_id"`
+    Key       string          `gorm:"not null;uniqueIndex:idx_custom_fields_project_key;size:50" json:"key"`
+    Name      string          `gorm:"not null" json:"name"`
+    Type      CustomFieldType `gorm:"not null" json:"type"`
+    Required  bool            `gorm:"not null;default:false" json:"required"`
+    Options   []string        `gorm:"type:jsonb;serializer:json" json:"options,omitempty"`
+    Position  int             `gorm:"not null;default:0" json:"position"`
+}
+
+// CustomFieldValue - значение поля дефекта. Каждому типу соответствует своя
+// колонка, чтобы фильтры работали по типизированным данным.
+type CustomFieldValue struct {
+    BaseModel
+    DefectID     uint     `gorm:"not null;uniqueIndex:idx_custom_field_values_defect_field" json:"defect_id"`
+    FieldID      uint     `gorm:"not null;uniqueIndex:idx_custom_field_values_defect_field;index" json:"field_id"`
+    ValueText    *string  `json:"value_text,omitempty"`
+    ValueNumber  *float64 `json:"value_number,omitempty"`
+    ValueDate    *Date    `gorm:"type:date" json:"value_date,omitempty"`
+    ValueUser    *uint    `json:"value_user,omitempty"`
+    ValueOptions []string `gorm:"type:jsonb;serializer:json" json:"value_options,omitempty"`
+
+    Field        *CustomField `json:"-"`
+}
+
+type CustomFieldCreateRequest struct {
+    Key      string          `json:"key" binding:"required,max=50"`
+    Name     string          `json:"name" binding:"required"`
+    Type     CustomFieldType `json:"type" binding:"required,oneof=text number date select multiselect user"`
+    Required bool            `json:"required"`
+    Options  []string        `json:"options"`
+    Position int             `json:"position"`
+}
+
+type CustomFieldUpdateRequest struct {
+    Name     *string  `json:"name,omitempty"`
+    Required *bool    `json:"required,omitempty"`
+    Options  []string `json:"options,omitempty"`
+    Position *int     `json:"position,omitempty"`
+}
+
+// HasOptions - типы полей со списком допустимых значений
+func (t CustomFieldType) HasOptions() bool {
+    return t == FieldSelect || t == FieldMultiSelect
+}
+
+// ParseValue проверяет значение из JSON и раскладывает его по типизированным колонкам.
+// nil означает отсутствие значения.
+func (f *CustomField) ParseValue(raw interface{}) (*CustomFieldValue, error) {
+    if raw == nil {
+        return nil, nil
+    }
+
+    value := &CustomFieldValue{FieldID: f.ID}
+    switch f.Type {
+    case FieldText:
+        text, ok := raw.(string)
+        if !ok {
+            return nil, fmt.Errorf("field %s must be a string", f.Key)
+        }
+        if strings.TrimSpace(text) == "" {
+            return nil, nil
+        }
+        value.ValueText = &text
+    case FieldNumber:
+        number, ok := raw.(float64)
+        if !ok {
+            return nil, fmt.Errorf("field %s must be a number", f.Key)
+        }
+        value.ValueNumber = &number
+    case FieldDate:
+        text, ok := raw.(string)
+        if !ok {
+            return nil, fmt.Errorf("field %s must be a date in YYYY-MM-DD format", f.Key)
+        }
+        if text == "" {
+            return nil, nil
+        }
+        t, err := time.Parse("2006-01-02", text)
+        if err != nil {
+            return nil, fmt.Errorf("field %s must be a date in YYYY-MM-DD format", f.Key)
+        }
+        value.ValueDate = &Date{Time: t}
+    case FieldSelect:
+        option, ok := raw.(string)
+        if !ok {
+            return nil, fmt.Errorf("field %s must be one of the options", f.Key)
+        }
+        if option == "" {
+            return nil, nil
+        }
+        if !f.hasOption(option) {
+            return nil, fmt.Errorf("field %s: unknown option %q", f.Key, option)
+        }
+        value.ValueText = &option
+    case FieldMultiSelect:
+        items, ok := raw.([]interface{})
+        if !ok {
+            return nil, fmt.Errorf("field %s must be a list of options", f.Key)
+        }
+        if len(items) == 0 {
+            return nil, nil
+        }
+        for _, item := range items {
+            option, ok := item.(string)
+            if !ok || !f.hasOption(option) {
+                return nil, fmt.Errorf("field %s: unknown option %v", f.Key, item)
+            }
+            value.ValueOptions = append(value.ValueOptions, option)
+        }
+        sort.Strings(value.ValueOptions)
+    case FieldUser:
+        number, ok := raw.(float64)
+        if !ok || number < 1 || number != math.Trunc(number) {
+            return nil, fmt.Errorf("field %s must be a user ID", f.Key)
+        }
+        userID := uint(number)
+        value.ValueUser = &userID
+    default:
+        return nil, fmt.Errorf("field %s has unsupported type %s", f.Key, f.Type)
+    }
+    return value, nil
+}
+
+// Interface возвращает значение в том виде, в котором оно отдается в JSON
+func (v *CustomFieldValue) Interface() interface{} {
+    switch {
+    case v == nil:
+        return nil
+    case v.ValueText != nil:
+        return *v.ValueText
+    case v.ValueNumber != nil:
+        return *v.ValueNumber
+    case v.ValueDate != nil:
+        return v.ValueDate
+    case v.ValueUser != nil:
+        return *v.ValueUser
+    case len(v.ValueOptions) > 0:
+        return v.ValueOptions
+    }
+    return nil
+}
+
+// String - текстовое представление значения для истории изменений
+func (v *CustomFieldValue) String() string {
+    switch {
+    case v == nil:
+        return "none"
+    case v.ValueText != nil:
+        return *v.ValueText
+    case v.ValueNumber != nil:
+        return strconv.FormatFloat(*v.ValueNumber, 'f', -1, 64)
+    case v.ValueDate != nil:
+        return v.ValueDate.Format("2006-01-02")
+    case v.ValueUser != nil:
+        return strconv.FormatUint(uint64(*v.ValueUser), 10)
+    case len(v.ValueOptions) > 0:
+        return strings.Join(v.ValueOptions, ", ")
+    }
+    return "none"
+}
+
+func (f *CustomField) hasOption(option string) bool {
+    for _, o := range f.Options {
+        if o == option {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,53 @@
+package models
+
+import "testing"
+
+func TestCustomFieldParseValue(t *testing.T) {
+    options := []string{"A", "B", "C"}
+
+    tests := []struct {
+        name    string
+        field   CustomField
+        raw     interface{}
+        want    string // CustomFieldValue.String(); "none" - значения нет
+        wantErr bool
+    }{
+        {"nil value", CustomField{Type: FieldText}, nil, "none", false},
+        {"text", CustomField{Type: FieldText}, "warranty 5.2", "warranty 5.2", false},
+        {"blank text", CustomField{Type: FieldText}, "  ", "none", false},
+        {"text of wrong type", CustomField{Type: FieldText}, 5.0, "", true},
+        {"number", CustomField{Type: FieldNumber}, 12.5, "12.5", false},
+        {"number as string", CustomField{Type: FieldNumber}, "12.5", "", true},
+        {"date", CustomField{Type: FieldDate}, "2026-03-01", "2026-03-01", false},
+        {"empty date", CustomField{Type: FieldDate}, "", "none", false},
+        {"invalid date", CustomField{Type: FieldDate}, "01.03.2026", "", true},
+        {"select", CustomField{Type: FieldSelect, Options: options}, "B", "B", false},
+        {"empty select", CustomField{Type: FieldSelect, Options: options}, "", "none", false},
+        {"unknown option", CustomField{Type: FieldSelect, Options: options}, "D", "", true},
+        {"multiselect is sorted", CustomField{Type: FieldMultiSelect, Options: options}, []interface{}{"C", "A"}, "A, C", false},
+        {"empty multiselect", CustomField{Type: FieldMultiSelect, Options: options}, []interface{}{}, "none", false},
+        {"multiselect unknown option", CustomField{Type: FieldMultiSelect, Options: options}, []interface{}{"A", "D"}, "", true},
+        {"multiselect not a list", CustomField{Type: FieldMultiSelect, Options: options}, "A", "", true},
+        {"user", CustomField{Type: FieldUser}, 7.0, "7", false},
+        {"user zero", CustomField{Type: FieldUser}, 0.0, "", true},
+        {"user fractional", CustomField{Type: FieldUser}, 7.5, "", true},
+        {"user as string", CustomField{Type: FieldUser}, "7", "", true},
+        {"unsupported type", CustomField{Type: "formula"}, "x", "", true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            tt.field.Key = "field"
+            value, err := tt.field.ParseValue(tt.raw)
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("ParseValue(%v) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+            }
+            if tt.wantErr {
+                return
+            }
+            if got := value.String(); got != tt.want {
+                t.Errorf("ParseValue(%v) = %q, want %q", tt.raw, got, tt.want)
+            }
+        })
+    }
+}
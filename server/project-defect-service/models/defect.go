@@ -1,7 +1,9 @@
 package models
 
 import (
+	"crypto/rand"
 	"database/sql/driver"
+	"encoding/hex"
 	"fmt"
 	"strings"
 	"time"
@@ -23,6 +25,11 @@ const (
     PriorityCritical DefectPriority = "critical"
 )
 
+// IsOpen - дефект еще не закрыт и не отменен
+func (s DefectStatus) IsOpen() bool {
+    return s != StatusClosed && s != StatusCancelled
+}
+
 type Defect struct {
     BaseModel
     Title       string         `gorm:"not null" json:"title"`
@@ -31,9 +38,69 @@ type Defect struct {
     Priority    DefectPriority `gorm:"not null;default:'medium'" json:"priority"`
     Deadline    *Date          `json:"deadline,omitempty"`
     
-    ProjectID   uint    `gorm:"not null" json:"project_id"`
+    // Версия для оптимистичной блокировки, отдается клиенту как ETag
+    Version     uint           `gorm:"not null;default:1" json:"version"`
+    
+    ProjectID   uint    `gorm:"not null;uniqueIndex:idx_defects_project_number" json:"project_id"`
+    Number      uint    `gorm:"uniqueIndex:idx_defects_project_number" json:"number"`
+    Key         string  `gorm:"uniqueIndex;size:32" json:"key"`
     AuthorID    uint    `gorm:"not null" json:"author_id"`
+    // Кто переместил дефект в корзину (DeletedAt - когда)
+    DeletedBy   *uint   `json:"deleted_by,omitempty"`
     AssigneeID  *uint   `json:"assignee_id,omitempty"`
+    // Подрядчик, отвечающий за устранение; исполнитель - его сотрудник
+    ContractorID *uint  `gorm:"index" json:"contractor_id,omitempty"`
+    
+    // Стоимость устранения - оценка и фактическая - в валюте Currency (ISO 4217)
+    EstimatedCost *float64 `gorm:"type:numeric(14,2)" json:"estimated_cost,omitempty"`
+    ActualCost    *float64 `gorm:"type:numeric(14,2)" json:"actual_cost,omitempty"`
+    Currency      string   `gorm:"size:3" json:"currency,omitempty"`
+    
+    // Родительский дефект (например, протечка для дефектов потолка под ней)
+    ParentID    *uint   `gorm:"index" json:"parent_id,omitempty"`
+    
+    // Обход, на котором обнаружен дефект
+    InspectionRoundID *uint `gorm:"index" json:"inspection_round_id,omitempty"`
+    
+    // Классификатор: вид работ → категория → подкатегория
+    CategoryID  *uint     `gorm:"index" json:"category_id,omitempty"`
+    Category    *Category `json:"category,omitempty"`
+    
+    // Местоположение: элемент иерархии проекта и координаты WGS84
+    LocationID  *uint     `gorm:"index" json:"location_id,omitempty"`
+    Location    *Location `json:"location,omitempty"`
+    Latitude    *float64  `json:"latitude,omitempty"`
+    Longitude   *float64  `json:"longitude,omitempty"`
+    
+    // Метка на чертеже этажа: нормализованные координаты 0..1 от левого верхнего угла
+    PlanID      *uint     `gorm:"index" json:"plan_id,omitempty"`
+    PlanX       *float64  `json:"plan_x,omitempty"`
+    PlanY       *float64  `json:"plan_y,omitempty"`
+    
+    // Метки проекта
+    Labels      []Label   `gorm:"many2many:defect_labels" json:"labels,omitempty"`
+    
+    // Значения дополнительных полей проекта по ключу поля (хранятся в CustomFieldValue)
+    CustomFields map[string]interface{} `gorm:"-" json:"custom_fields,omitempty"`
+    
+    // SLA: нормативные сроки реакции и устранения и фактические отметки
+    SLAPolicyID   *uint      `json:"sla_policy_id,omitempty"`
+    ResponseDue   *time.Time `gorm:"index" json:"response_due,omitempty"`
+    ResolutionDue *time.Time `gorm:"index" json:"resolution_due,omitempty"`
+    RespondedAt   *time.Time `json:"responded_at,omitempty"`
+    ResolvedAt    *time.Time `json:"resolved_at,omitempty"`
+    SLAState      string     `gorm:"-" json:"sla_status,omitempty"`
+    
+    // Приемка устранения техническим надзором заказчика (роль observer): закрыть
+    // можно только принятый дефект. ReopenCount - сколько раз приемка отклонялась
+    AcceptanceStatus AcceptanceStatus `gorm:"size:16" json:"acceptance_status,omitempty"`
+    AcceptedBy       *uint            `json:"accepted_by,omitempty"`
+    AcceptedAt       *time.Time       `json:"accepted_at,omitempty"`
+    ReopenCount      int              `gorm:"not null;default:0" json:"reopen_count"`
+    
+    // Чек-лист устранения и его прогресс
+    Checklist         []ChecklistItem    `gorm:"foreignKey:DefectID" json:"checklist,omitempty"`
+    ChecklistProgress *ChecklistProgress `gorm:"-" json:"checklist_progress,omitempty"`
     
     // История изменений
     History     []DefectHistory `json:"history,omitempty"`
@@ -46,6 +113,36 @@ type DefectHistory struct {
     OldValue  string `json:"old_value"`
     NewValue  string `json:"new_value"`
     ChangedBy uint   `gorm:"not null" json:"changed_by"`
+    // Изменения одного запроса объединяются в набор с общим ID
+    ChangeSetID string `gorm:"index;size:32" json:"change_set_id"`
+    // Набор изменений, который был отменен этим набором
+    RevertOf    string `gorm:"size:32" json:"revert_of,omitempty"`
+}
+
+// DefectChangeSet - изменения дефекта, выполненные одним запросом
+type DefectChangeSet struct {
+    ID         string        `json:"id"`
+    CreatedAt  time.Time     `json:"created_at"`
+    ActorID    uint          `json:"actor_id"`
+    ActorName  string        `json:"actor_name"`
+    RevertOf   string        `json:"revert_of,omitempty"`
+    Revertible bool          `json:"revertible"`
+    Changes    []FieldChange `json:"changes"`
+}
+
+type FieldChange struct {
+    Field    string `json:"field"`
+    OldValue string `json:"old_value"`
+    NewValue string `json:"new_value"`
+}
+
+// NewChangeSetID - случайный идентификатор набора изменений
+func NewChangeSetID() string {
+    b := make([]byte, 16)
+    if _, err := rand.Read(b); err != nil {
+        return fmt.Sprintf("%x", time.Now().UnixNano())
+    }
+    return hex.EncodeToString(b)
 }
 
 type Date struct {
@@ -117,6 +214,28 @@ type DefectCreateRequest struct {
     Deadline    *Date          `json:"deadline,omitempty"`
     ProjectID   uint           `json:"project_id" binding:"required"`
     AssigneeID  *uint          `json:"assignee_id,omitempty"`
+    ContractorID *uint         `json:"contractor_id,omitempty"`
+    EstimatedCost *float64     `json:"estimated_cost,omitempty" binding:"omitempty,min=0"`
+    ActualCost  *float64       `json:"actual_cost,omitempty" binding:"omitempty,min=0"`
+    Currency    string         `json:"currency,omitempty" binding:"omitempty,iso4217"`
+    ParentID    *uint          `json:"parent_id,omitempty"`
+    // Обход, на котором обнаружен дефект; обход должен идти в проекте дефекта
+    InspectionRoundID *uint    `json:"inspection_round_id,omitempty"`
+    CategoryID  *uint          `json:"category_id,omitempty"`
+    LocationID  *uint          `json:"location_id,omitempty"`
+    Latitude    *float64       `json:"latitude,omitempty" binding:"omitempty,min=-90,max=90"`
+    Longitude   *float64       `json:"longitude,omitempty" binding:"omitempty,min=-180,max=180"`
+    PlanID      *uint          `json:"plan_id,omitempty"`
+    PlanX       *float64       `json:"plan_x,omitempty" binding:"omitempty,min=0,max=1"`
+    PlanY       *float64       `json:"plan_y,omitempty" binding:"omitempty,min=0,max=1"`
+    CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
+    // Проверка на дубликаты по запросу клиента: с check_duplicates дефект,
+    // похожий на открытый, не создается без решения duplicate_action
+    CheckDuplicates bool   `json:"check_duplicates,omitempty"`
+    // Решение по найденным похожим дефектам: create - создать как есть,
+    // link - создать и связать с DuplicateOfID как дубликат
+    DuplicateAction string `json:"duplicate_action,omitempty" binding:"omitempty,oneof=create link"`
+    DuplicateOfID   *uint  `json:"duplicate_of_id,omitempty"`
 }
 
 type DefectUpdateRequest struct {
@@ -126,4 +245,29 @@ type DefectUpdateRequest struct {
     Priority    *DefectPriority `json:"priority,omitempty" binding:"omitempty,oneof=low medium high critical"`
     Deadline    *Date           `json:"deadline,omitempty"`
     AssigneeID  *uint           `json:"assignee_id,omitempty"`
-}
\ No newline at end of file
+    ContractorID *uint          `json:"contractor_id,omitempty"`
+    EstimatedCost *float64      `json:"estimated_cost,omitempty" binding:"omitempty,min=0"`
+    ActualCost  *float64        `json:"actual_cost,omitempty" binding:"omitempty,min=0"`
+    Currency    *string         `json:"currency,omitempty" binding:"omitempty,iso4217"`
+    ProjectID   *uint           `json:"project_id,omitempty"`
+    ParentID    *uint           `json:"parent_id,omitempty"`
+    CategoryID  *uint           `json:"category_id,omitempty"`
+    LocationID  *uint           `json:"location_id,omitempty"`
+    Latitude    *float64        `json:"latitude,omitempty" binding:"omitempty,min=-90,max=90"`
+    Longitude   *float64        `json:"longitude,omitempty" binding:"omitempty,min=-180,max=180"`
+    PlanID      *uint           `json:"plan_id,omitempty"`
+    PlanX       *float64        `json:"plan_x,omitempty" binding:"omitempty,min=0,max=1"`
+    PlanY       *float64        `json:"plan_y,omitempty" binding:"omitempty,min=0,max=1"`
+    CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
+}
+
+// Метка дефекта на чертеже
+type PlanPin struct {
+    DefectID uint           `json:"defect_id"`
+    Key      string         `json:"key"`
+    Title    string         `json:"title"`
+    Status   DefectStatus   `json:"status"`
+    Priority DefectPriority `json:"priority"`
+    X        float64        `json:"x"`
+    Y        float64        `json:"y"`
+}
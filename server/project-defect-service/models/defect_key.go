@@ -0,0 +1,39 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefectKeyRedirect хранит прежние ключи дефектов, перенесенных в другой проект,
+// чтобы старые ссылки вида TWR-57 продолжали работать
+type DefectKeyRedirect struct {
+    BaseModel
+    OldKey   string `gorm:"uniqueIndex;size:32;not null" json:"old_key"`
+    DefectID uint   `gorm:"not null;index" json:"defect_id"`
+}
+
+func FormatDefectKey(projectKey string, number uint) string {
+    return fmt.Sprintf("%s-%d", projectKey, number)
+}
+
+// ParseDefectKey разбирает ключ вида TWR-57 на ключ проекта и номер
+func ParseDefectKey(key string) (string, uint, bool) {
+    idx := strings.LastIndex(key, "-")
+    if idx <= 0 || idx == len(key)-1 {
+        return "", 0, false
+    }
+    
+    projectKey := strings.ToUpper(key[:idx])
+    if !IsValidProjectKey(projectKey) {
+        return "", 0, false
+    }
+    
+    number, err := strconv.ParseUint(key[idx+1:], 10, 32)
+    if err != nil || number == 0 {
+        return "", 0, false
+    }
+    
+    return projectKey, uint(number), true
+}
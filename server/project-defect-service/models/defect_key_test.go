@@ -0,0 +1,37 @@
+package models
+
+import "testing"
+
+func TestParseDefectKey(t *testing.T) {
+    tests := []struct {
+        key        string
+        projectKey string
+        number     uint
+        ok         bool
+    }{
+        {"TWR-57", "TWR", 57, true},
+        {"twr-57", "TWR", 57, true},
+        {"BLD2-1", "BLD2", 1, true},
+        {"P12-3", "P12", 3, true},
+        {"TWR-0", "", 0, false},
+        {"TWR-", "", 0, false},
+        {"-57", "", 0, false},
+        {"TWR57", "", 0, false},
+        {"T-57", "", 0, false},
+        {"2TWR-57", "", 0, false},
+        {"TWR-5a", "", 0, false},
+        {"TWR--57", "", 0, false},
+        {"TWR-4294967296", "", 0, false},
+        {"", "", 0, false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.key, func(t *testing.T) {
+            projectKey, number, ok := ParseDefectKey(tt.key)
+            if projectKey != tt.projectKey || number != tt.number || ok != tt.ok {
+                t.Errorf("ParseDefectKey(%q) = %q, %d, %v; want %q, %d, %v",
+                    tt.key, projectKey, number, ok, tt.projectKey, tt.number, tt.ok)
+            }
+        })
+    }
+}
@@ -0,0 +1,23 @@
+package models
+
+// Варианты действия при найденных похожих дефектах
+const (
+    DuplicateActionCreate = "create" // создать несмотря на совпадения
+    DuplicateActionLink   = "link"   // создать и связать как duplicate_of
+)
+
+// DuplicateCandidate - похожий открытый дефект с оценкой сходства от 0 до 1
+type DuplicateCandidate struct {
+    DefectID              uint           `json:"defect_id"`
+    Key                   string         `json:"key"`
+    Title                 string         `json:"title"`
+    Status                DefectStatus   `json:"status"`
+    Priority              DefectPriority `json:"priority"`
+    LocationID            *uint          `json:"location_id,omitempty"`
+    CategoryID            *uint          `json:"category_id,omitempty"`
+    TitleSimilarity       float64        `json:"title_similarity"`
+    DescriptionSimilarity float64        `json:"description_similarity"`
+    SameLocation          bool           `json:"same_location"`
+    SameCategory          bool           `json:"same_category"`
+    Score                 float64        `json:"score"`
+}
@@ -0,0 +1,78 @@
+package models
+
+import "time"
+
+// SystemActorID - автор изменений, выполненных планировщиком, в DefectHistory.ChangedBy
+const SystemActorID uint = 0
+
+type EscalationTrigger string
+type EscalationAction string
+
+const (
+    TriggerBeforeDeadline EscalationTrigger = "before_deadline" // за OffsetHours до срока
+    TriggerOverdue        EscalationTrigger = "overdue"         // через OffsetHours после срока
+
+    ActionRemind        EscalationAction = "remind"
+    ActionRaisePriority EscalationAction = "raise_priority"
+    ActionReassign      EscalationAction = "reassign"
+)
+
+// EscalationRule - правило проекта, которое планировщик применяет к открытым
+// дефектам со сроком. Каждое правило срабатывает один раз на значение срока.
+type EscalationRule struct {
+    BaseModel
+    ProjectID   uint              `gorm:"not null;index" json:"project_id"`
+    Name        string            `gorm:"not null" json:"name"`
+    Trigger     EscalationTrigger `gorm:"not null" json:"trigger"`
+    OffsetHours int               `gorm:"not null;default:0" json:"offset_hours"`
+    // Только для дефектов этого приоритета; nil - для всех
+    Priority    *DefectPriority   `json:"priority,omitempty"`
+    Action      EscalationAction  `gorm:"not null" json:"action"`
+    // Новый исполнитель для действия reassign
+    AssigneeID  *uint             `json:"assignee_id,omitempty"`
+    Enabled     bool              `gorm:"not null" json:"enabled"`
+}
+
+type EscalationRuleRequest struct {
+    Name        string            `json:"name" binding:"required"`
+    Trigger     EscalationTrigger `json:"trigger" binding:"required,oneof=before_deadline overdue"`
+    OffsetHours int               `json:"offset_hours" binding:"min=0"`
+    Priority    *DefectPriority   `json:"priority,omitempty" binding:"omitempty,oneof=low medium high critical"`
+    Action      EscalationAction  `json:"action" binding:"required,oneof=remind raise_priority reassign"`
+    AssigneeID  *uint             `json:"assignee_id,omitempty"`
+    Enabled     *bool             `json:"enabled,omitempty"`
+}
+
+// Типы событий дефектов
+const (
+    EventDeadlineApproaching = "deadline_approaching"
+    EventOverdue             = "overdue"
+    EventEscalated           = "escalated"
+    EventEscalationSkipped   = "escalation_skipped"
+)
+
+// DefectEvent - событие для рассылки уведомлений. Потребители читают события
+// по возрастанию ID; DedupKey не дает повторить событие при нескольких репликах.
+type DefectEvent struct {
+    ID          uint      `gorm:"primarykey" json:"id"`
+    CreatedAt   time.Time `json:"created_at"`
+    DefectID    uint      `gorm:"not null;index" json:"defect_id"`
+    ProjectID   uint      `gorm:"not null;index" json:"project_id"`
+    RuleID      *uint     `json:"rule_id,omitempty"`
+    Type        string    `gorm:"not null" json:"type"`
+    RecipientID *uint     `json:"recipient_id,omitempty"`
+    Message     string    `json:"message"`
+    DedupKey    string    `gorm:"not null;uniqueIndex;size:128" json:"-"`
+}
+
+// NextPriority - приоритет на ступень выше; critical остается critical
+func (p DefectPriority) NextPriority() DefectPriority {
+    switch p {
+    case PriorityLow:
+        return PriorityMedium
+    case PriorityMedium:
+        return PriorityHigh
+    default:
+        return PriorityCritical
+    }
+}
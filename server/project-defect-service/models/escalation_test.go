@@ -0,0 +1,23 @@
+package models
+
+import "testing"
+
+func TestNextPriority(t *testing.T) {
+    tests := []struct {
+        priority DefectPriority
+        want     DefectPriority
+    }{
+        {PriorityLow, PriorityMedium},
+        {PriorityMedium, PriorityHigh},
+        {PriorityHigh, PriorityCritical},
+        {PriorityCritical, PriorityCritical},
+    }
+
+    for _, tt := range tests {
+        t.Run(string(tt.priority), func(t *testing.T) {
+            if got := tt.priority.NextPriority(); got != tt.want {
+                t.Errorf("%s.NextPriority() = %s, want %s", tt.priority, got, tt.want)
+            }
+        })
+    }
+}
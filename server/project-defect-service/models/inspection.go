@@ -0,0 +1,175 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+type InspectionRecurrence string
+type InspectionStatus string
+type InspectionResult string
+
+const (
+    RecurDaily   InspectionRecurrence = "daily"
+    RecurWeekly  InspectionRecurrence = "weekly"
+    RecurMonthly InspectionRecurrence = "monthly"
+
+    InspectionPlanned    InspectionStatus = "planned"
+    InspectionInProgress InspectionStatus = "in_progress"
+    InspectionCompleted  InspectionStatus = "completed"
+    InspectionCancelled  InspectionStatus = "cancelled"
+
+    ResultPending       InspectionResult = "pending"
+    ResultPass          InspectionResult = "pass"
+    ResultFail          InspectionResult = "fail"
+    ResultNotApplicable InspectionResult = "n_a"
+)
+
+// inspectionTransitions - допустимые переходы статуса обхода
+var inspectionTransitions = map[InspectionStatus][]InspectionStatus{
+    InspectionPlanned:    {InspectionInProgress, InspectionCancelled},
+    InspectionInProgress: {InspectionCompleted, InspectionCancelled},
+}
+
+// CanTransitionTo - можно ли перевести обход в статус next
+func (s InspectionStatus) CanTransitionTo(next InspectionStatus) bool {
+    for _, allowed := range inspectionTransitions[s] {
+        if allowed == next {
+            return true
+        }
+    }
+    return false
+}
+
+// InspectionSchedule - правило регулярных обходов: планировщик создает обход
+// на дату NextDate и сдвигает ее на Interval дней, недель или месяцев
+type InspectionSchedule struct {
+    BaseModel
+    ProjectID    uint                 `gorm:"not null;index" json:"project_id"`
+    Name         string               `gorm:"not null" json:"name"`
+    // Зона обхода - элемент иерархии мест проекта; nil - весь проект
+    LocationID   *uint                `json:"location_id,omitempty"`
+    InspectorIDs []uint               `gorm:"type:jsonb;serializer:json" json:"inspector_ids"`
+    // Шаблон чек-листа: пункты копируются в каждый созданный обход
+    Checklist    []string             `gorm:"type:jsonb;serializer:json" json:"checklist"`
+    Recurrence   InspectionRecurrence `gorm:"not null" json:"recurrence"`
+    Interval     int                  `gorm:"not null;default:1" json:"interval"`
+    NextDate     Date                 `gorm:"type:date;not null;index" json:"next_date"`
+    EndDate      *Date                `gorm:"type:date" json:"end_date,omitempty"`
+    Enabled      bool                 `gorm:"not null" json:"enabled"`
+}
+
+// Advance - следующая дата обхода после date
+func (s *InspectionSchedule) Advance(date time.Time) time.Time {
+    interval := s.Interval
+    if interval < 1 {
+        interval = 1
+    }
+    switch s.Recurrence {
+    case RecurDaily:
+        return date.AddDate(0, 0, interval)
+    case RecurWeekly:
+        return date.AddDate(0, 0, 7*interval)
+    default:
+        return date.AddDate(0, interval, 0)
+    }
+}
+
+// RoundTitle - название обхода, созданного по правилу
+func (s *InspectionSchedule) RoundTitle(date time.Time) string {
+    return fmt.Sprintf("%s %s", s.Name, date.Format("2006-01-02"))
+}
+
+type InspectionScheduleRequest struct {
+    Name         string               `json:"name" binding:"required,max=255"`
+    LocationID   *uint                `json:"location_id,omitempty"`
+    InspectorIDs []uint               `json:"inspector_ids" binding:"required,min=1,dive,min=1"`
+    Checklist    []string             `json:"checklist" binding:"omitempty,max=100,dive,required,max=255"`
+    Recurrence   InspectionRecurrence `json:"recurrence" binding:"required,oneof=daily weekly monthly"`
+    Interval     int                  `json:"interval" binding:"omitempty,min=1,max=365"`
+    StartDate    *Date                `json:"start_date" binding:"required"`
+    EndDate      *Date                `json:"end_date,omitempty"`
+    Enabled      *bool                `json:"enabled,omitempty"`
+}
+
+// InspectionRound - обход: назначенные инспекторы проверяют зону по чек-листу,
+// найденные дефекты ссылаются на обход (Defect.InspectionRoundID)
+type InspectionRound struct {
+    BaseModel
+    ProjectID     uint             `gorm:"not null;index" json:"project_id"`
+    // Правило, по которому создан обход; у разовых обходов пустое
+    ScheduleID    *uint            `gorm:"uniqueIndex:idx_inspection_rounds_schedule_date" json:"schedule_id,omitempty"`
+    Title         string           `gorm:"not null" json:"title"`
+    LocationID    *uint            `json:"location_id,omitempty"`
+    Location      *Location        `json:"location,omitempty"`
+    ScheduledDate Date             `gorm:"type:date;not null;index;uniqueIndex:idx_inspection_rounds_schedule_date" json:"scheduled_date"`
+    Status        InspectionStatus `gorm:"not null;default:'planned';index" json:"status"`
+    InspectorIDs  []uint           `gorm:"type:jsonb;serializer:json" json:"inspector_ids"`
+    StartedAt     *time.Time       `json:"started_at,omitempty"`
+    CompletedAt   *time.Time       `json:"completed_at,omitempty"`
+    CompletedBy   *uint            `json:"completed_by,omitempty"`
+    // Итог обхода, который записывает инспектор при завершении
+    Summary       string           `json:"summary,omitempty"`
+
+    Items         []InspectionItem `gorm:"foreignKey:RoundID" json:"items,omitempty"`
+}
+
+// HasInspector - назначен ли пользователь на обход
+func (r *InspectionRound) HasInspector(userID uint) bool {
+    for _, id := range r.InspectorIDs {
+        if id == userID {
+            return true
+        }
+    }
+    return false
+}
+
+// InspectionItem - пункт чек-листа обхода и его результат
+type InspectionItem struct {
+    BaseModel
+    RoundID   uint             `gorm:"not null;index" json:"round_id"`
+    Title     string           `gorm:"not null" json:"title"`
+    Position  int              `gorm:"not null;default:0" json:"position"`
+    Result    InspectionResult `gorm:"not null;default:'pending'" json:"result"`
+    Note      string           `json:"note,omitempty"`
+    CheckedBy *uint            `json:"checked_by,omitempty"`
+    CheckedAt *time.Time       `json:"checked_at,omitempty"`
+}
+
+type InspectionRoundRequest struct {
+    Title         string   `json:"title" binding:"required,max=255"`
+    LocationID    *uint    `json:"location_id,omitempty"`
+    ScheduledDate *Date    `json:"scheduled_date" binding:"required"`
+    InspectorIDs  []uint   `json:"inspector_ids" binding:"required,min=1,dive,min=1"`
+    Checklist     []string `json:"checklist" binding:"omitempty,max=100,dive,required,max=255"`
+}
+
+type InspectionStatusRequest struct {
+    Status  InspectionStatus `json:"status" binding:"required,oneof=in_progress completed cancelled"`
+    Summary string           `json:"summary"`
+}
+
+type InspectionItemRequest struct {
+    Result InspectionResult `json:"result" binding:"required,oneof=pending pass fail n_a"`
+    Note   string           `json:"note"`
+}
+
+// InspectionSummaryRow - обход с найденными и закрытыми на нем дефектами
+type InspectionSummaryRow struct {
+    ID            uint             `json:"id"`
+    Title         string           `json:"title"`
+    ScheduledDate Date             `json:"scheduled_date"`
+    Status        InspectionStatus `json:"status"`
+    ItemsFailed   int64            `json:"items_failed"`
+    DefectsFound  int64            `json:"defects_found"`
+    DefectsClosed int64            `json:"defects_closed"`
+}
+
+// NewInspectionItems - пункты чек-листа обхода по шаблону, в порядке шаблона
+func NewInspectionItems(checklist []string) []InspectionItem {
+    items := make([]InspectionItem, 0, len(checklist))
+    for i, title := range checklist {
+        items = append(items, InspectionItem{Title: title, Position: i, Result: ResultPending})
+    }
+    return items
+}
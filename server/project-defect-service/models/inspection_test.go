@@ -0,0 +1,35 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInspectionScheduleAdvance(t *testing.T) {
+    date := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+
+    tests := []struct {
+        name       string
+        recurrence InspectionRecurrence
+        interval   int
+        want       time.Time
+    }{
+        {"daily", RecurDaily, 1, time.Date(2026, 1, 16, 0, 0, 0, 0, time.UTC)},
+        {"every 3 days", RecurDaily, 3, time.Date(2026, 1, 18, 0, 0, 0, 0, time.UTC)},
+        {"weekly", RecurWeekly, 1, time.Date(2026, 1, 22, 0, 0, 0, 0, time.UTC)},
+        {"every 3 weeks across month", RecurWeekly, 3, time.Date(2026, 2, 5, 0, 0, 0, 0, time.UTC)},
+        {"monthly", RecurMonthly, 1, time.Date(2026, 2, 15, 0, 0, 0, 0, time.UTC)},
+        {"yearly across year end", RecurMonthly, 12, time.Date(2027, 1, 15, 0, 0, 0, 0, time.UTC)},
+        {"zero interval counts as one", RecurDaily, 0, time.Date(2026, 1, 16, 0, 0, 0, 0, time.UTC)},
+        {"negative interval counts as one", RecurWeekly, -2, time.Date(2026, 1, 22, 0, 0, 0, 0, time.UTC)},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            schedule := InspectionSchedule{Recurrence: tt.recurrence, Interval: tt.interval}
+            if got := schedule.Advance(date); !got.Equal(tt.want) {
+                t.Errorf("Advance(%s) = %s, want %s", date.Format("2006-01-02"), got.Format("2006-01-02"), tt.want.Format("2006-01-02"))
+            }
+        })
+    }
+}
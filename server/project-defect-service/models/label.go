@@ -0,0 +1,19 @@
+package models
+
+// Label - метка проекта для произвольной группировки дефектов
+// ("до сдачи", "гарантия", "от заказчика")
+type Label struct {
+    BaseModel
+    ProjectID uint   `gorm:"not null;uniqueIndex:idx_labels_project_name" json:"project_id"`
+    Name      string `gorm:"not null;uniqueIndex:idx_labels_project_name" json:"name"`
+    Color     string `gorm:"not null;default:'#808080'" json:"color"`
+}
+
+type LabelRequest struct {
+    Name  string `json:"name" binding:"required,max=50"`
+    Color string `json:"color" binding:"omitempty,hexcolor"`
+}
+
+type DefectLabelsRequest struct {
+    LabelIDs []uint `json:"label_ids" binding:"required,min=1"`
+}
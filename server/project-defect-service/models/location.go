@@ -0,0 +1,68 @@
+package models
+
+type LocationType string
+
+const (
+    LocationSite     LocationType = "site"
+    LocationBuilding LocationType = "building"
+    LocationFloor    LocationType = "floor"
+    LocationSection  LocationType = "section"
+    LocationRoom     LocationType = "room"
+)
+
+// Допустимые родители для каждого уровня иерархии: объект → корпус → этаж → секция/помещение.
+// Пустой тип означает корень: небольшие проекты могут начинаться сразу с корпуса.
+var locationParents = map[LocationType][]LocationType{
+    LocationSite:     {""},
+    LocationBuilding: {"", LocationSite},
+    LocationFloor:    {LocationBuilding},
+    LocationSection:  {LocationFloor},
+    LocationRoom:     {LocationFloor, LocationSection},
+}
+
+// Location - элемент иерархии мест проекта. Path хранит цепочку ID предков
+// вида /1/5/12/, что позволяет выбирать поддерево одним LIKE-запросом.
+type Location struct {
+    BaseModel
+    ProjectID uint         `gorm:"not null;index" json:"project_id"`
+    ParentID  *uint        `gorm:"index" json:"parent_id,omitempty"`
+    Type      LocationType `gorm:"not null" json:"type"`
+    Name      string       `gorm:"not null" json:"name"`
+    Code      string       `json:"code,omitempty"`
+    Path      string       `gorm:"index" json:"path"`
+
+    Children  []Location   `gorm:"-" json:"children,omitempty"`
+}
+
+type LocationCreateRequest struct {
+    ParentID *uint        `json:"parent_id,omitempty"`
+    Type     LocationType `json:"type" binding:"required,oneof=site building floor section room"`
+    Name     string       `json:"name" binding:"required"`
+    Code     string       `json:"code"`
+}
+
+type LocationUpdateRequest struct {
+    ParentID *uint   `json:"parent_id,omitempty"`
+    Name     *string `json:"name,omitempty"`
+    Code     *string `json:"code,omitempty"`
+}
+
+// CanBeChildOf проверяет, может ли место данного типа располагаться внутри parent.
+// parent == "" означает корень иерархии.
+func (t LocationType) CanBeChildOf(parent LocationType) bool {
+    for _, p := range locationParents[t] {
+        if p == parent {
+            return true
+        }
+    }
+    return false
+}
+
+// Статистика дефектов по месту
+type DefectsByLocation struct {
+    LocationID uint         `json:"location_id"`
+    Name       string       `json:"name"`
+    Type       LocationType `json:"type"`
+    Path       string       `json:"path"`
+    Count      int64        `json:"count"`
+}
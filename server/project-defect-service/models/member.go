@@ -0,0 +1,39 @@
+package models
+
+// AssignmentMode - как выбирается исполнитель нового дефекта, если он не указан
+type AssignmentMode string
+
+const (
+    // AssignByCategory - исполнитель по умолчанию для категории дефекта
+    AssignByCategory    AssignmentMode = "category"
+    // AssignRoundRobin - инженеры команды проекта по очереди
+    AssignRoundRobin    AssignmentMode = "round_robin"
+    // AssignLeastLoaded - инженер команды с наименьшим числом открытых дефектов
+    AssignLeastLoaded   AssignmentMode = "least_loaded"
+    // AssignManual - дефект остается без исполнителя
+    AssignManual        AssignmentMode = "manual"
+)
+
+// ProjectMember - участник команды проекта. Участники портфеля или программы
+// входят в команды всех вложенных проектов
+type ProjectMember struct {
+    BaseModel
+    ProjectID uint `gorm:"not null;uniqueIndex:idx_project_members_project_user" json:"project_id"`
+    UserID    uint `gorm:"not null;uniqueIndex:idx_project_members_project_user;index" json:"user_id"`
+    // Участник унаследован от вышестоящего портфеля или программы
+    Inherited bool `gorm:"-" json:"inherited"`
+}
+
+type ProjectMemberRequest struct {
+    UserID uint `json:"user_id" binding:"required"`
+}
+
+// EngineerWorkload - нагрузка инженера по открытым дефектам
+type EngineerWorkload struct {
+    UserID          uint   `json:"user_id"`
+    Email           string `json:"email"`
+    FullName        string `json:"full_name"`
+    OpenDefects     int64  `json:"open_defects"`
+    OverdueDefects  int64  `json:"overdue_defects"`
+    CriticalDefects int64  `json:"critical_defects"`
+}
@@ -1,15 +1,162 @@
 package models
 
+import "regexp"
+
+// ProjectPhase - этап жизненного цикла объекта строительства
+type ProjectPhase string
+
+const (
+	PhasePlanning     ProjectPhase = "planning"
+	PhaseConstruction ProjectPhase = "construction"
+	PhaseHandover     ProjectPhase = "handover"
+	PhaseWarranty     ProjectPhase = "warranty"
+	PhaseArchived     ProjectPhase = "archived"
+)
+
+// Допустимые переходы между этапами; из архива проект можно вернуть на любой этап
+var projectPhaseTransitions = map[ProjectPhase][]ProjectPhase{
+	PhasePlanning:     {PhaseConstruction, PhaseArchived},
+	PhaseConstruction: {PhasePlanning, PhaseHandover, PhaseArchived},
+	PhaseHandover:     {PhaseConstruction, PhaseWarranty, PhaseArchived},
+	PhaseWarranty:     {PhaseArchived},
+	PhaseArchived:     {PhasePlanning, PhaseConstruction, PhaseHandover, PhaseWarranty},
+}
+
+func (p ProjectPhase) CanTransitionTo(next ProjectPhase) bool {
+	for _, allowed := range projectPhaseTransitions[p] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsReadOnly - архивный проект и его дефекты доступны только для чтения
+func (p ProjectPhase) IsReadOnly() bool {
+	return p == PhaseArchived
+}
+
+// ProjectLevel - уровень в иерархии: портфель объединяет программы,
+// программа - проекты отдельных площадок. Дефекты заводятся только в проектах
+type ProjectLevel string
+
+const (
+	LevelPortfolio ProjectLevel = "portfolio"
+	LevelProgram   ProjectLevel = "program"
+	LevelProject   ProjectLevel = "project"
+)
+
+// Допустимые родители для каждого уровня ("" - корень иерархии)
+var projectLevelParents = map[ProjectLevel][]ProjectLevel{
+	LevelPortfolio: {""},
+	LevelProgram:   {"", LevelPortfolio},
+	LevelProject:   {"", LevelPortfolio, LevelProgram},
+}
+
+// CanBeChildOf проверяет, может ли уровень располагаться внутри parent.
+// parent == "" означает корень иерархии.
+func (l ProjectLevel) CanBeChildOf(parent ProjectLevel) bool {
+	for _, p := range projectLevelParents[l] {
+		if p == parent {
+			return true
+		}
+	}
+	return false
+}
+
+// HoldsDefects - портфели и программы только группируют проекты
+func (l ProjectLevel) HoldsDefects() bool {
+	return l == LevelProject
+}
+
 type Project struct {
 	BaseModel
 	Name        string   `gorm:"not null" json:"name"`
 	Description string   `json:"description"`
+	Key         string   `gorm:"uniqueIndex;size:10" json:"key"`
+	DefectSeq   uint     `gorm:"not null;default:0" json:"-"`
+	// Версия для оптимистичной блокировки, отдается клиенту как ETag
+	Version     uint     `gorm:"not null;default:1" json:"version"`
 	ManagerID   uint     `gorm:"not null" json:"manager_id"`
+	// Этап жизненного цикла и плановые даты начала и окончания работ
+	Phase       ProjectPhase `gorm:"not null;default:'planning';index" json:"phase"`
+	// Иерархия портфель -> программа -> проект; путь вида /1/5/12/ для выборки поддерева
+	Level       ProjectLevel `gorm:"not null;default:'project';index" json:"level"`
+	ParentID    *uint    `gorm:"index" json:"parent_id,omitempty"`
+	Path        string   `gorm:"index" json:"path"`
+	StartDate   *Date    `gorm:"type:date" json:"start_date,omitempty"`
+	EndDate     *Date    `gorm:"type:date" json:"end_date,omitempty"`
+	// Кто переместил проект в корзину (DeletedAt - когда)
+	DeletedBy   *uint    `json:"deleted_by,omitempty"`
+	// Шаблон: только настройки для новых проектов, без дефектов
+	IsTemplate  bool     `gorm:"not null;default:false;index" json:"is_template"`
+	// Перевод дефекта на проверку только после выполнения всего чек-листа
+	RequireChecklistForReview bool `gorm:"not null;default:false" json:"require_checklist_for_review"`
+	// Автоназначение исполнителя; AssignCursor - последний назначенный по очереди
+	AssignmentMode AssignmentMode `gorm:"not null;default:'category'" json:"assignment_mode"`
+	AssignCursor   *uint    `json:"-"`
 	Defects     []Defect `json:"defects,omitempty"`
 }
 
+// ProjectStats - сводка по дефектам проекта в списке проектов
+type ProjectStats struct {
+	OpenDefects     int64 `json:"open_defects"`
+	OverdueDefects  int64 `json:"overdue_defects"`
+	CriticalDefects int64 `json:"critical_defects"`
+}
+
+// ProjectSummary - проект вместе со сводкой, считается одним запросом
+type ProjectSummary struct {
+	Project
+	ProjectStats
+}
+
+// ProjectRollup - узел дерева проектов: собственная сводка и итог по поддереву
+type ProjectRollup struct {
+	ID       uint             `json:"id"`
+	Key      string           `json:"key"`
+	Name     string           `json:"name"`
+	Level    ProjectLevel     `json:"level"`
+	Phase    ProjectPhase     `json:"phase"`
+	ParentID *uint            `json:"parent_id,omitempty"`
+	Own      ProjectStats     `json:"own"`
+	Total    ProjectStats     `json:"total"`
+	Children []*ProjectRollup `json:"children"`
+}
+
+// Add прибавляет сводку другого узла
+func (s *ProjectStats) Add(other ProjectStats) {
+	s.OpenDefects += other.OpenDefects
+	s.OverdueDefects += other.OverdueDefects
+	s.CriticalDefects += other.CriticalDefects
+}
+
 type ProjectCreateRequest struct {
 	Name        string `json:"name" binding:"required"`
 	Description string `json:"description"`
+	Key         string `json:"key"`
 	ManagerID   uint   `json:"manager_id" binding:"required"`
-}
\ No newline at end of file
+	RequireChecklistForReview bool `json:"require_checklist_for_review"`
+	AssignmentMode AssignmentMode `json:"assignment_mode" binding:"omitempty,oneof=category round_robin least_loaded manual"`
+	StartDate   *Date  `json:"start_date"`
+	EndDate     *Date  `json:"end_date"`
+	// Уровень задается при создании и дальше не меняется
+	Level       ProjectLevel `json:"level" binding:"omitempty,oneof=portfolio program project"`
+	ParentID    *uint  `json:"parent_id"`
+}
+
+// ProjectMoveRequest - перенос в другой портфель или программу; null - в корень
+type ProjectMoveRequest struct {
+	ParentID *uint `json:"parent_id"`
+}
+
+type ProjectPhaseRequest struct {
+	Phase ProjectPhase `json:"phase" binding:"required,oneof=planning construction handover warranty archived"`
+}
+
+// Ключ проекта: латинская буква, затем 1-9 латинских букв или цифр (TWR, BLD2)
+var projectKeyPattern = regexp.MustCompile(`^[A-Z][A-Z0-9]{1,9}$`)
+
+func IsValidProjectKey(key string) bool {
+	return projectKeyPattern.MatchString(key)
+}
@@ -0,0 +1,35 @@
+package models
+
+type RelationType string
+
+const (
+    RelationDuplicateOf RelationType = "duplicate_of"
+    RelationBlocks      RelationType = "blocks"
+    RelationCausedBy    RelationType = "caused_by"
+    RelationRelatesTo   RelationType = "relates_to"
+
+    // blocked_by принимается в запросах и хранится как обратная связь blocks
+    RelationBlockedBy RelationType = "blocked_by"
+)
+
+// DefectRelation - направленная связь: Source <Type> Target
+// (например, TWR-12 duplicate_of TWR-7, TWR-3 blocks TWR-9)
+type DefectRelation struct {
+    BaseModel
+    SourceID  uint         `gorm:"not null;uniqueIndex:idx_defect_relations_unique" json:"source_id"`
+    TargetID  uint         `gorm:"not null;uniqueIndex:idx_defect_relations_unique;index" json:"target_id"`
+    Type      RelationType `gorm:"not null;uniqueIndex:idx_defect_relations_unique" json:"type"`
+    // Для duplicate_of: отменить дубликат при закрытии оригинала
+    AutoClose bool         `gorm:"not null;default:false" json:"auto_close"`
+    CreatedBy uint         `gorm:"not null" json:"created_by"`
+
+    Source    *Defect      `gorm:"foreignKey:SourceID" json:"source,omitempty"`
+    Target    *Defect      `gorm:"foreignKey:TargetID" json:"target,omitempty"`
+}
+
+type DefectRelationRequest struct {
+    Type      RelationType `json:"type" binding:"required,oneof=duplicate_of blocks blocked_by caused_by relates_to"`
+    TargetID  uint         `json:"target_id"`
+    TargetKey string       `json:"target_key"`
+    AutoClose bool         `json:"auto_close"`
+}
@@ -0,0 +1,182 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// SLAPolicy - нормативные сроки реакции и устранения для приоритета проекта.
+// Политика с категорией точнее политики без категории.
+type SLAPolicy struct {
+    BaseModel
+    ProjectID       uint           `gorm:"not null;uniqueIndex:idx_sla_policies_scope" json:"project_id"`
+    Priority        DefectPriority `gorm:"not null;uniqueIndex:idx_sla_policies_scope" json:"priority"`
+    CategoryID      *uint          `gorm:"uniqueIndex:idx_sla_policies_scope" json:"category_id,omitempty"`
+    // Время в рабочих часах календаря проекта; 0 - срок реакции не контролируется
+    ResponseHours   int            `gorm:"not null;default:0" json:"response_hours"`
+    ResolutionHours int            `gorm:"not null" json:"resolution_hours"`
+}
+
+type SLAPolicyRequest struct {
+    Priority        DefectPriority `json:"priority" binding:"required,oneof=low medium high critical"`
+    CategoryID      *uint          `json:"category_id,omitempty"`
+    ResponseHours   int            `json:"response_hours" binding:"min=0"`
+    ResolutionHours int            `json:"resolution_hours" binding:"required,min=1"`
+}
+
+// DefectDueAtSQL - момент срока дефекта: срок устранения по SLA или конец дня дедлайна
+const DefectDueAtSQL = "COALESCE(defects.resolution_due, CAST(defects.deadline AS date) + INTERVAL '1 day')"
+
+// SLA-состояние дефекта
+const (
+    SLAStatusOK       = "ok"
+    SLAStatusAtRisk   = "at_risk"  // до срока устранения меньше SLAAtRiskWindow
+    SLAStatusBreached = "breached" // просрочена реакция или устранение
+    SLAStatusMet      = "met"      // закрыт в срок
+)
+
+const SLAAtRiskWindow = 24 * time.Hour
+
+// BusinessCalendar - рабочее время проекта, по которому считаются сроки SLA.
+// Проект без календаря считает сроки в календарном времени.
+type BusinessCalendar struct {
+    BaseModel
+    ProjectID uint              `gorm:"not null;uniqueIndex" json:"project_id"`
+    Timezone  string            `gorm:"not null;default:'UTC'" json:"timezone"`
+    // Рабочие дни недели: 1 - понедельник ... 7 - воскресенье
+    WorkDays  []int             `gorm:"type:jsonb;serializer:json" json:"work_days"`
+    WorkStart string            `gorm:"not null;default:'09:00';size:5" json:"work_start"`
+    WorkEnd   string            `gorm:"not null;default:'18:00';size:5" json:"work_end"`
+    Holidays  []BusinessHoliday `gorm:"foreignKey:CalendarID" json:"holidays,omitempty"`
+}
+
+type BusinessHoliday struct {
+    BaseModel
+    CalendarID uint   `gorm:"not null;uniqueIndex:idx_business_holidays_date" json:"calendar_id"`
+    Date       Date   `gorm:"type:date;not null;uniqueIndex:idx_business_holidays_date" json:"date"`
+    Name       string `json:"name"`
+}
+
+type BusinessCalendarRequest struct {
+    Timezone  string `json:"timezone" binding:"required"`
+    WorkDays  []int  `json:"work_days" binding:"required,min=1,dive,min=1,max=7"`
+    WorkStart string `json:"work_start" binding:"required"`
+    WorkEnd   string `json:"work_end" binding:"required"`
+}
+
+type BusinessHolidayRequest struct {
+    Date Date   `json:"date"`
+    Name string `json:"name"`
+}
+
+// Validate проверяет часовой пояс и рабочие часы календаря
+func (cal *BusinessCalendar) Validate() error {
+    if _, err := time.LoadLocation(cal.Timezone); err != nil {
+        return fmt.Errorf("unknown timezone %s", cal.Timezone)
+    }
+    start, err := parseClock(cal.WorkStart)
+    if err != nil {
+        return err
+    }
+    end, err := parseClock(cal.WorkEnd)
+    if err != nil {
+        return err
+    }
+    if start >= end {
+        return fmt.Errorf("work_start must be before work_end")
+    }
+    return nil
+}
+
+// AddBusinessTime прибавляет к start длительность d, отсчитывая только рабочие часы
+// рабочих дней без праздников. nil-календарь считает календарное время.
+func (cal *BusinessCalendar) AddBusinessTime(start time.Time, d time.Duration) time.Time {
+    if cal == nil || len(cal.WorkDays) == 0 {
+        return start.Add(d)
+    }
+
+    loc, err := time.LoadLocation(cal.Timezone)
+    if err != nil {
+        loc = time.UTC
+    }
+    workStart, _ := parseClock(cal.WorkStart)
+    workEnd, _ := parseClock(cal.WorkEnd)
+
+    workDays := make(map[time.Weekday]bool, len(cal.WorkDays))
+    for _, day := range cal.WorkDays {
+        workDays[time.Weekday(day%7)] = true
+    }
+    holidays := make(map[string]bool, len(cal.Holidays))
+    for _, holiday := range cal.Holidays {
+        holidays[holiday.Date.Format("2006-01-02")] = true
+    }
+
+    t := start.In(loc)
+    remaining := d
+    // Ограничение на случай календаря без единого рабочего дня в году
+    for i := 0; i < 3660; i++ {
+        midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+        if workDays[t.Weekday()] && !holidays[midnight.Format("2006-01-02")] {
+            dayStart := midnight.Add(workStart)
+            dayEnd := midnight.Add(workEnd)
+            if t.Before(dayStart) {
+                t = dayStart
+            }
+            if t.Before(dayEnd) {
+                available := dayEnd.Sub(t)
+                if remaining <= available {
+                    return t.Add(remaining).UTC()
+                }
+                remaining -= available
+            }
+        }
+        t = midnight.AddDate(0, 0, 1)
+    }
+    return start.Add(d)
+}
+
+// SLAStatus вычисляет SLA-состояние дефекта на момент now.
+// Пустая строка - дефект без сроков SLA или отмененный: отмена не устраняет дефект.
+// Закрытый без отметки устранения считается закрытым в срок. Фильтр sla
+// (handlers.slaCondition) повторяет эти правила в SQL
+func (d *Defect) SLAStatus(now time.Time) string {
+    if d.ResolutionDue == nil || d.Status == StatusCancelled {
+        return ""
+    }
+
+    if d.ResponseDue != nil {
+        respondedAt := now
+        if d.RespondedAt != nil {
+            respondedAt = *d.RespondedAt
+        }
+        if respondedAt.After(*d.ResponseDue) {
+            return SLAStatusBreached
+        }
+    }
+
+    if !d.Status.IsOpen() {
+        if d.ResolvedAt != nil && d.ResolvedAt.After(*d.ResolutionDue) {
+            return SLAStatusBreached
+        }
+        return SLAStatusMet
+    }
+    if now.After(*d.ResolutionDue) {
+        return SLAStatusBreached
+    }
+    if d.ResolutionDue.Sub(now) <= SLAAtRiskWindow {
+        return SLAStatusAtRisk
+    }
+    return SLAStatusOK
+}
+
+// parseClock разбирает время суток HH:MM; допускается 24:00 как конец дня
+func parseClock(value string) (time.Duration, error) {
+    var hours, minutes int
+    if _, err := fmt.Sscanf(value, "%d:%d", &hours, &minutes); err != nil || len(value) != 5 {
+        return 0, fmt.Errorf("time %q must be in HH:MM format", value)
+    }
+    if hours < 0 || minutes < 0 || minutes > 59 || hours > 24 || (hours == 24 && minutes > 0) {
+        return 0, fmt.Errorf("time %q must be in HH:MM format", value)
+    }
+    return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute, nil
+}
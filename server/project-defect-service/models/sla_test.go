@@ -0,0 +1,99 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+// testCalendar - пятидневка 09:00-18:00 UTC, 7 января 2026 (среда) - праздник
+func testCalendar() *BusinessCalendar {
+    return &BusinessCalendar{
+        Timezone:  "UTC",
+        WorkDays:  []int{1, 2, 3, 4, 5},
+        WorkStart: "09:00",
+        WorkEnd:   "18:00",
+        Holidays: []BusinessHoliday{
+            {Date: Date{Time: time.Date(2026, 1, 7, 0, 0, 0, 0, time.UTC)}},
+        },
+    }
+}
+
+func utc(day, hour, minute int) time.Time {
+    return time.Date(2026, 1, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestAddBusinessTime(t *testing.T) {
+    moscow := testCalendar()
+    moscow.Timezone = "Europe/Moscow"
+
+    tests := []struct {
+        name     string
+        calendar *BusinessCalendar
+        start    time.Time
+        duration time.Duration
+        want     time.Time
+    }{
+        {"no calendar counts calendar time", nil, utc(9, 16, 0), 4 * time.Hour, utc(9, 20, 0)},
+        {"within working day", testCalendar(), utc(5, 10, 0), 4 * time.Hour, utc(5, 14, 0)},
+        {"ends exactly at end of day", testCalendar(), utc(5, 10, 0), 8 * time.Hour, utc(5, 18, 0)},
+        {"carries over to next day", testCalendar(), utc(5, 16, 0), 4 * time.Hour, utc(6, 11, 0)},
+        {"starts before working hours", testCalendar(), utc(5, 7, 0), time.Hour, utc(5, 10, 0)},
+        {"starts after working hours", testCalendar(), utc(5, 20, 0), time.Hour, utc(6, 10, 0)},
+        {"skips holiday", testCalendar(), utc(6, 17, 0), 2 * time.Hour, utc(8, 10, 0)},
+        {"skips weekend", testCalendar(), utc(9, 16, 0), 4 * time.Hour, utc(12, 11, 0)},
+        {"starts on weekend", testCalendar(), utc(10, 12, 0), time.Hour, utc(12, 10, 0)},
+        {"spans holiday and weekend", testCalendar(), utc(6, 9, 0), 28 * time.Hour, utc(12, 10, 0)},
+        {"project timezone", moscow, utc(5, 5, 0), time.Hour, utc(5, 7, 0)},
+        {"no working days in calendar", &BusinessCalendar{Timezone: "UTC"}, utc(10, 12, 0), time.Hour, utc(10, 13, 0)},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := tt.calendar.AddBusinessTime(tt.start, tt.duration); !got.Equal(tt.want) {
+                t.Errorf("AddBusinessTime(%s, %s) = %s, want %s", tt.start, tt.duration, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestSLAStatus(t *testing.T) {
+    calendar := testCalendar()
+    // Пятница 16:00 + 4 рабочих часа - понедельник 11:00 после выходных
+    overWeekend := calendar.AddBusinessTime(utc(9, 16, 0), 4*time.Hour)
+    // Вторник 17:00 + 2 рабочих часа - четверг 10:00 после праздника
+    overHoliday := calendar.AddBusinessTime(utc(6, 17, 0), 2*time.Hour)
+    responseDue := utc(9, 18, 0)
+    respondedLate := utc(12, 9, 0)
+    respondedInTime := utc(9, 17, 0)
+    resolvedLate := overWeekend.Add(time.Hour)
+    resolvedInTime := overWeekend.Add(-time.Hour)
+
+    tests := []struct {
+        name   string
+        defect Defect
+        now    time.Time
+        want   string
+    }{
+        {"no SLA", Defect{Status: StatusNew}, utc(9, 16, 0), ""},
+        {"ok over weekend", Defect{Status: StatusInProgress, ResolutionDue: &overWeekend}, utc(10, 10, 0), SLAStatusOK},
+        {"at risk on sunday", Defect{Status: StatusInProgress, ResolutionDue: &overWeekend}, utc(11, 12, 0), SLAStatusAtRisk},
+        {"breached after weekend", Defect{Status: StatusInProgress, ResolutionDue: &overWeekend}, utc(12, 11, 1), SLAStatusBreached},
+        {"at risk on holiday", Defect{Status: StatusNew, ResolutionDue: &overHoliday}, utc(7, 12, 0), SLAStatusAtRisk},
+        {"breached after holiday", Defect{Status: StatusNew, ResolutionDue: &overHoliday}, utc(8, 10, 30), SLAStatusBreached},
+        {"response overdue", Defect{Status: StatusNew, ResponseDue: &responseDue, ResolutionDue: &overWeekend}, utc(10, 10, 0), SLAStatusBreached},
+        {"late response stays breached", Defect{Status: StatusInProgress, ResponseDue: &responseDue, RespondedAt: &respondedLate, ResolutionDue: &overWeekend}, utc(12, 9, 30), SLAStatusBreached},
+        {"response in time", Defect{Status: StatusInProgress, ResponseDue: &responseDue, RespondedAt: &respondedInTime, ResolutionDue: &overWeekend}, utc(10, 10, 0), SLAStatusOK},
+        {"met", Defect{Status: StatusClosed, ResolutionDue: &overWeekend, ResolvedAt: &resolvedInTime}, utc(20, 10, 0), SLAStatusMet},
+        {"resolved late", Defect{Status: StatusClosed, ResolutionDue: &overWeekend, ResolvedAt: &resolvedLate}, utc(20, 10, 0), SLAStatusBreached},
+        {"closed without resolved_at", Defect{Status: StatusClosed, ResolutionDue: &overWeekend}, utc(20, 10, 0), SLAStatusMet},
+        {"cancelled is outside SLA", Defect{Status: StatusCancelled, ResolutionDue: &overWeekend, ResolvedAt: &resolvedInTime}, utc(20, 10, 0), ""},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := tt.defect.SLAStatus(tt.now); got != tt.want {
+                t.Errorf("SLAStatus(%s) = %q, want %q", tt.now, got, tt.want)
+            }
+        })
+    }
+}
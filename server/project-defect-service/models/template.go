@@ -0,0 +1,31 @@
+package models
+
+// ProjectTemplateRequest - сохранение настроек проекта как шаблона
+type ProjectTemplateRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+// ProjectCloneRequest - новый проект из шаблона или копия существующего проекта
+type ProjectCloneRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	Key         string `json:"key"`
+	StartDate   *Date  `json:"start_date"`
+	EndDate     *Date  `json:"end_date"`
+	// Перенести и открытые дефекты (только при копировании проекта, не шаблона)
+	IncludeOpenDefects bool `json:"include_open_defects"`
+}
+
+// ProjectCloneResult - сколько записей скопировано в новый проект
+type ProjectCloneResult struct {
+	Locations         int  `json:"locations"`
+	Categories        int  `json:"categories"`
+	CategoryAssignees int  `json:"category_assignees"`
+	Labels            int  `json:"labels"`
+	CustomFields      int  `json:"custom_fields"`
+	SLAPolicies       int  `json:"sla_policies"`
+	Calendar          bool `json:"calendar"`
+	EscalationRules   int  `json:"escalation_rules"`
+	Defects           int  `json:"defects"`
+}
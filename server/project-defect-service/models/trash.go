@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+const (
+    TrashTypeDefect  = "defect"
+    TrashTypeProject = "project"
+)
+
+// TrashItem - удаленный проект или дефект в корзине. После PurgeAt
+// планировщик удаляет его окончательно вместе с комментариями и вложениями.
+type TrashItem struct {
+    Type          string    `json:"type"`
+    ID            uint      `json:"id"`
+    Key           string    `json:"key"`
+    Title         string    `json:"title"`
+    ProjectID     uint      `json:"project_id"`
+    DeletedAt     time.Time `json:"deleted_at"`
+    DeletedBy     *uint     `json:"deleted_by,omitempty"`
+    DeletedByName string    `json:"deleted_by_name,omitempty"`
+    PurgeAt       time.Time `json:"purge_at"`
+}
@@ -0,0 +1,39 @@
+package models
+
+// Причины подписки на дефект
+const (
+    WatchManual    = "manual"
+    WatchAuthor    = "author"
+    WatchAssignee  = "assignee"
+    WatchCommenter = "commenter"
+)
+
+// DefectWatcher - подписчик дефекта. Автор, исполнители и комментаторы
+// подписываются автоматически; отписка удаляет запись
+type DefectWatcher struct {
+    BaseModel
+    DefectID uint   `gorm:"not null;uniqueIndex:idx_defect_watchers_defect_user" json:"defect_id"`
+    UserID   uint   `gorm:"not null;uniqueIndex:idx_defect_watchers_defect_user;index" json:"user_id"`
+    Reason   string `gorm:"not null;default:'manual'" json:"reason"`
+}
+
+// ProjectWatcher - подписчик всех дефектов проекта; подписка на портфель
+// или программу распространяется на вложенные проекты
+type ProjectWatcher struct {
+    BaseModel
+    ProjectID uint `gorm:"not null;uniqueIndex:idx_project_watchers_project_user" json:"project_id"`
+    UserID    uint `gorm:"not null;uniqueIndex:idx_project_watchers_project_user;index" json:"user_id"`
+}
+
+// WatcherRequest - подписка пользователя другим сервисом (например, автора комментария)
+type WatcherRequest struct {
+    UserID uint   `json:"user_id" binding:"required"`
+    Reason string `json:"reason" binding:"omitempty,oneof=manual author assignee commenter"`
+}
+
+// EventRecipient - получатель уведомления о событии дефекта и почему он его получает:
+// author, assignee, watcher, project_watcher, manager
+type EventRecipient struct {
+    UserID  uint     `json:"user_id"`
+    Reasons []string `json:"reasons"`
+}
@@ -0,0 +1,38 @@
+package models
+
+// WorkLog - затраты времени на устранение дефекта. ContractorID - организация
+// сотрудника на момент записи: по ней подрядчику выставляются часы
+type WorkLog struct {
+    BaseModel
+    DefectID     uint     `gorm:"not null;index" json:"defect_id"`
+    UserID       uint     `gorm:"not null;index" json:"user_id"`
+    ContractorID *uint    `gorm:"index" json:"contractor_id,omitempty"`
+    Date         Date     `gorm:"type:date;not null;index" json:"date"`
+    Minutes      int      `gorm:"not null" json:"minutes"`
+    Note         string   `json:"note,omitempty"`
+    // Ставка за час работы; стоимость записи - Minutes / 60 * HourlyRate
+    HourlyRate   *float64 `gorm:"type:numeric(12,2)" json:"hourly_rate,omitempty"`
+}
+
+type WorkLogRequest struct {
+    Date       *Date    `json:"date" binding:"required"`
+    Minutes    int      `json:"minutes" binding:"required,min=1,max=1440"`
+    Note       string   `json:"note"`
+    HourlyRate *float64 `json:"hourly_rate" binding:"omitempty,min=0"`
+}
+
+// WorkLogTotals - итог затрат времени и стоимости
+type WorkLogTotals struct {
+    Entries int64   `json:"entries"`
+    Minutes int64   `json:"minutes"`
+    Hours   float64 `json:"hours"`
+    Cost    float64 `json:"cost"`
+}
+
+// TimeReportRow - строка отчета по времени: проект, подрядчик или инженер.
+// У записей собственных сотрудников подрядчика нет - ID пустой
+type TimeReportRow struct {
+    ID   *uint  `json:"id"`
+    Name string `json:"name,omitempty"`
+    WorkLogTotals
+}
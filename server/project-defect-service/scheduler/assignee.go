@@ -0,0 +1,103 @@
+package scheduler
+
+import (
+	"fmt"
+	"net/http"
+
+	"project-defect-service/models"
+
+	"gorm.io/gorm"
+)
+
+// authUser - пользователь auth-service, нужный для проверки исполнителя
+type authUser struct {
+    ID       uint   `json:"id"`
+    RoleName string `json:"role_name"`
+    Active   bool   `json:"is_active"`
+}
+
+// fetchUser запрашивает пользователя во внутреннем API auth-service; nil - пользователь не найден
+func (s *Scheduler) fetchUser(userID uint) (*authUser, error) {
+    var result struct {
+        Data struct {
+            User authUser `json:"user"`
+        } `json:"data"`
+    }
+
+    resp, err := s.Client.R().
+        SetHeader("X-Service-Token", s.ServiceToken).
+        SetResult(&result).
+        Get(fmt.Sprintf("%s/internal/users/%d", s.AuthServiceURL, userID))
+    if err != nil {
+        return nil, fmt.Errorf("failed to fetch user from auth-service: %w", err)
+    }
+    if resp.StatusCode() == http.StatusNotFound {
+        return nil, nil
+    }
+    if resp.StatusCode() != http.StatusOK {
+        return nil, fmt.Errorf("auth-service returned status: %d", resp.StatusCode())
+    }
+    return &result.Data.User, nil
+}
+
+// checkReassign проверяет нового исполнителя по тем же правилам, что и ручное
+// назначение: активный сотрудник подрядчика дефекта или активный инженер из команды
+// проекта. Пустая причина - назначить можно; ошибка - проверку выполнить не удалось.
+// Пользователи кэшируются в users на время прохода планировщика
+func (s *Scheduler) checkReassign(db *gorm.DB, defect *models.Defect, assigneeID uint, users map[uint]*authUser) (string, error) {
+    user, cached := users[assigneeID]
+    if !cached {
+        var err error
+        if user, err = s.fetchUser(assigneeID); err != nil {
+            return "", err
+        }
+        users[assigneeID] = user
+    }
+    if user == nil {
+        return "assignee not found", nil
+    }
+    if !user.Active {
+        return "assignee account is deactivated", nil
+    }
+
+    if defect.ContractorID != nil {
+        if user.RoleName != models.RoleContractor {
+            return "contractor defects can only be assigned to contractor members", nil
+        }
+        var members int64
+        if err := db.Model(&models.ContractorMember{}).
+            Where("contractor_id = ? AND user_id = ?", *defect.ContractorID, assigneeID).
+            Count(&members).Error; err != nil {
+            return "", err
+        }
+        if members == 0 {
+            return "assignee is not a member of the defect contractor", nil
+        }
+        return "", nil
+    }
+
+    if user.RoleName != "engineer" {
+        return "only engineers can be assigned to defects", nil
+    }
+
+    // Команда проекта - участники самого проекта и вышестоящих портфеля и программы
+    var project models.Project
+    if err := db.Select("id", "path").First(&project, defect.ProjectID).Error; err != nil {
+        return "", err
+    }
+    team := db.Model(&models.ProjectMember{}).Where("project_id = ?", project.ID)
+    if project.Path != "" {
+        team = db.Model(&models.ProjectMember{}).Where("project_id IN (?)",
+            db.Model(&models.Project{}).Select("id").Where("? LIKE path || '%'", project.Path))
+    }
+    var userIDs []uint
+    if err := team.Distinct().Pluck("user_id", &userIDs).Error; err != nil {
+        return "", err
+    }
+    for _, id := range userIDs {
+        if id == assigneeID {
+            return "", nil
+        }
+    }
+    return "assignee is not a member of the project team", nil
+}
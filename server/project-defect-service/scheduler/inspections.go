@@ -0,0 +1,74 @@
+package scheduler
+
+import (
+	"log"
+	"time"
+
+	"project-defect-service/models"
+
+	"gorm.io/gorm"
+)
+
+// createInspectionRounds создает обходы по правилам, дата которых наступила.
+// Пропущенные даты (планировщик не работал) задним числом не создаются -
+// только последний наступивший обход
+func (s *Scheduler) createInspectionRounds(db *gorm.DB, now time.Time) error {
+    today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+    
+    var schedules []models.InspectionSchedule
+    if err := db.Where("enabled = ? AND next_date <= ?", true, today).
+        // Архивные проекты только для чтения: новых обходов в них нет
+        Where("project_id IN (SELECT id FROM projects WHERE phase <> ? AND deleted_at IS NULL)", models.PhaseArchived).
+        Order("id").
+        Find(&schedules).Error; err != nil {
+        return err
+    }
+    
+    for i := range schedules {
+        if err := s.createInspectionRound(db, &schedules[i], today); err != nil {
+            log.Printf("Inspection schedule %d failed: %v", schedules[i].ID, err)
+        }
+    }
+    return nil
+}
+
+// createInspectionRound создает обход правила и сдвигает дату следующего;
+// правило, у которого следующая дата позже end_date, отключается
+func (s *Scheduler) createInspectionRound(db *gorm.DB, schedule *models.InspectionSchedule, today time.Time) error {
+    date := schedule.NextDate.Time
+    next := schedule.Advance(date)
+    for !next.After(today) {
+        date, next = next, schedule.Advance(next)
+    }
+    
+    return db.Transaction(func(tx *gorm.DB) error {
+        ended := func(d time.Time) bool { return schedule.EndDate != nil && d.After(schedule.EndDate.Time) }
+        
+        var existing int64
+        if err := tx.Model(&models.InspectionRound{}).
+            Where("schedule_id = ? AND scheduled_date = ?", schedule.ID, date).
+            Count(&existing).Error; err != nil {
+            return err
+        }
+        if existing == 0 && !ended(date) {
+            round := models.InspectionRound{
+                ProjectID:     schedule.ProjectID,
+                ScheduleID:    &schedule.ID,
+                Title:         schedule.RoundTitle(date),
+                LocationID:    schedule.LocationID,
+                ScheduledDate: models.Date{Time: date},
+                Status:        models.InspectionPlanned,
+                InspectorIDs:  schedule.InspectorIDs,
+                Items:         models.NewInspectionItems(schedule.Checklist),
+            }
+            if err := tx.Create(&round).Error; err != nil {
+                return err
+            }
+        }
+        
+        return tx.Model(&models.InspectionSchedule{}).Where("id = ?", schedule.ID).Updates(map[string]interface{}{
+            "next_date": models.Date{Time: next},
+            "enabled":   !ended(next),
+        }).Error
+    })
+}
@@ -0,0 +1,151 @@
+package scheduler
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"project-defect-service/database/dbtest"
+	"project-defect-service/models"
+
+	"gorm.io/gorm"
+)
+
+func seedSchedule(t *testing.T, db *gorm.DB, phase models.ProjectPhase, schedule models.InspectionSchedule) *models.InspectionSchedule {
+    t.Helper()
+    project := models.Project{Name: "Tower", Key: "TWR", ManagerID: 1, Phase: phase}
+    if err := db.Create(&project).Error; err != nil {
+        t.Fatal(err)
+    }
+    schedule.ProjectID = project.ID
+    schedule.Name = "Facade walk"
+    schedule.InspectorIDs = []uint{4, 6}
+    if err := db.Create(&schedule).Error; err != nil {
+        t.Fatal(err)
+    }
+    return &schedule
+}
+
+func scheduledRounds(t *testing.T, db *gorm.DB, scheduleID uint) []models.InspectionRound {
+    t.Helper()
+    var rounds []models.InspectionRound
+    if err := db.Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+        Where("schedule_id = ?", scheduleID).Order("scheduled_date").Find(&rounds).Error; err != nil {
+        t.Fatal(err)
+    }
+    return rounds
+}
+
+func TestInspectionRoundsSkipMissedDates(t *testing.T) {
+    db := dbtest.Open(t)
+    now := time.Now().UTC()
+    today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+    schedule := seedSchedule(t, db, models.PhaseConstruction, models.InspectionSchedule{
+        Checklist:  []string{"Scaffolding", "Glazing"},
+        Recurrence: models.RecurWeekly,
+        Interval:   1,
+        NextDate:   models.Date{Time: today.AddDate(0, 0, -15)},
+        Enabled:    true,
+    })
+    s := New(db, time.Minute, time.Hour, time.Hour, "", "", "")
+
+    // Повторный проход в тот же день ничего не добавляет
+    for pass := 0; pass < 2; pass++ {
+        if err := s.createInspectionRounds(db, now); err != nil {
+            t.Fatalf("pass %d: %v", pass, err)
+        }
+    }
+
+    // Из трех наступивших дат (-15, -8, -1) создается только последняя
+    rounds := scheduledRounds(t, db, schedule.ID)
+    if len(rounds) != 1 {
+        t.Fatalf("created %d rounds, want 1", len(rounds))
+    }
+    round := rounds[0]
+    lastDate := today.AddDate(0, 0, -1).Format("2006-01-02")
+    if round.ScheduledDate.Format("2006-01-02") != lastDate {
+        t.Errorf("round date = %s, want %s", round.ScheduledDate.Format("2006-01-02"), lastDate)
+    }
+    if round.Title != "Facade walk "+lastDate || round.Status != models.InspectionPlanned {
+        t.Errorf("round = %q in status %s", round.Title, round.Status)
+    }
+    if fmt.Sprint(round.InspectorIDs) != "[4 6]" {
+        t.Errorf("inspectors = %v, want [4 6]", round.InspectorIDs)
+    }
+    var items []string
+    for _, item := range round.Items {
+        items = append(items, fmt.Sprintf("%s:%s", item.Title, item.Result))
+    }
+    if fmt.Sprint(items) != "[Scaffolding:pending Glazing:pending]" {
+        t.Errorf("items = %v", items)
+    }
+
+    var stored models.InspectionSchedule
+    if err := db.First(&stored, schedule.ID).Error; err != nil {
+        t.Fatal(err)
+    }
+    if next := today.AddDate(0, 0, 6).Format("2006-01-02"); stored.NextDate.Format("2006-01-02") != next || !stored.Enabled {
+        t.Errorf("next_date = %s, enabled = %v; want %s, enabled", stored.NextDate.Format("2006-01-02"), stored.Enabled, next)
+    }
+}
+
+func TestInspectionScheduleDisabledAfterEndDate(t *testing.T) {
+    db := dbtest.Open(t)
+    now := time.Now().UTC()
+    today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+    schedule := seedSchedule(t, db, models.PhaseConstruction, models.InspectionSchedule{
+        Recurrence: models.RecurDaily,
+        Interval:   1,
+        NextDate:   models.Date{Time: today},
+        EndDate:    &models.Date{Time: today},
+        Enabled:    true,
+    })
+    s := New(db, time.Minute, time.Hour, time.Hour, "", "", "")
+
+    if err := s.createInspectionRounds(db, now); err != nil {
+        t.Fatal(err)
+    }
+
+    if rounds := scheduledRounds(t, db, schedule.ID); len(rounds) != 1 {
+        t.Fatalf("created %d rounds, want 1 on the end date", len(rounds))
+    }
+    var stored models.InspectionSchedule
+    if err := db.First(&stored, schedule.ID).Error; err != nil {
+        t.Fatal(err)
+    }
+    if stored.Enabled {
+        t.Errorf("schedule is still enabled after its end date")
+    }
+}
+
+func TestInspectionRoundsSkipDisabledAndArchived(t *testing.T) {
+    tests := []struct {
+        name    string
+        phase   models.ProjectPhase
+        enabled bool
+    }{
+        {"disabled schedule", models.PhaseConstruction, false},
+        {"archived project", models.PhaseArchived, true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            db := dbtest.Open(t)
+            now := time.Now().UTC()
+            schedule := seedSchedule(t, db, tt.phase, models.InspectionSchedule{
+                Recurrence: models.RecurDaily,
+                Interval:   1,
+                NextDate:   models.Date{Time: now.AddDate(0, 0, -1)},
+                Enabled:    tt.enabled,
+            })
+            s := New(db, time.Minute, time.Hour, time.Hour, "", "", "")
+
+            if err := s.createInspectionRounds(db, now); err != nil {
+                t.Fatal(err)
+            }
+            if rounds := scheduledRounds(t, db, schedule.ID); len(rounds) != 0 {
+                t.Errorf("created %d rounds, want none", len(rounds))
+            }
+        })
+    }
+}
@@ -0,0 +1,160 @@
+package scheduler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"project-defect-service/models"
+
+	"gorm.io/gorm"
+)
+
+// Сколько удаленных записей окончательно удаляется за один проход
+const purgeBatchSize = 100
+
+// purgeTrash окончательно удаляет дефекты и проекты, пролежавшие в корзине
+// дольше срока хранения. Сначала удаляются комментарии и вложения в content-service:
+// если он недоступен, запись остается в корзине до следующего прохода.
+func (s *Scheduler) purgeTrash(db *gorm.DB, now time.Time) error {
+    cutoff := now.Add(-s.TrashRetention)
+
+    var defects []models.Defect
+    if err := db.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at <= ?", cutoff).
+        Order("deleted_at").Limit(purgeBatchSize).Find(&defects).Error; err != nil {
+        return err
+    }
+    for i := range defects {
+        if err := s.purgeContent(fmt.Sprintf("/internal/defects/%d/content", defects[i].ID)); err != nil {
+            log.Printf("Failed to purge content of defect %d: %v", defects[i].ID, err)
+            continue
+        }
+        if err := purgeDefect(db, defects[i].ID); err != nil {
+            return fmt.Errorf("failed to purge defect %d: %w", defects[i].ID, err)
+        }
+    }
+
+    // Проект удаляется окончательно, только когда в нем не осталось дефектов
+    // и дочерних проектов, даже в корзине
+    var projects []models.Project
+    if err := db.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at <= ?", cutoff).
+        Where("NOT EXISTS (SELECT 1 FROM defects WHERE defects.project_id = projects.id)").
+        Where("NOT EXISTS (SELECT 1 FROM projects children WHERE children.parent_id = projects.id)").
+        Order("deleted_at").Limit(purgeBatchSize).Find(&projects).Error; err != nil {
+        return err
+    }
+    for i := range projects {
+        if err := s.purgeContent(fmt.Sprintf("/internal/projects/%d/content", projects[i].ID)); err != nil {
+            log.Printf("Failed to purge content of project %d: %v", projects[i].ID, err)
+            continue
+        }
+        if err := purgeProject(db, projects[i].ID); err != nil {
+            return fmt.Errorf("failed to purge project %d: %w", projects[i].ID, err)
+        }
+    }
+    return nil
+}
+
+// purgeContent удаляет данные записи в content-service
+func (s *Scheduler) purgeContent(path string) error {
+    resp, err := s.Client.R().
+        SetHeader("X-Service-Token", s.ServiceToken).
+        Delete(s.ContentServiceURL + path)
+    if err != nil {
+        return err
+    }
+    if resp.StatusCode() != http.StatusOK {
+        return fmt.Errorf("content-service returned status: %d", resp.StatusCode())
+    }
+    return nil
+}
+
+// purgeDefect удаляет дефект и все, что на него ссылается
+func purgeDefect(db *gorm.DB, defectID uint) error {
+    return db.Transaction(func(tx *gorm.DB) error {
+        tx = tx.Unscoped()
+
+        // Подчиненные дефекты остаются без родителя
+        if err := tx.Model(&models.Defect{}).Where("parent_id = ?", defectID).Update("parent_id", nil).Error; err != nil {
+            return err
+        }
+
+        for _, model := range []interface{}{
+            &models.DefectHistory{},
+            &models.DefectKeyRedirect{},
+            &models.CustomFieldValue{},
+            &models.ChecklistItem{},
+            &models.DefectEvent{},
+            &models.DefectWatcher{},
+            &models.WorkLog{},
+            &models.BackCharge{},
+            &models.DefectAcceptance{},
+        } {
+            if err := tx.Where("defect_id = ?", defectID).Delete(model).Error; err != nil {
+                return err
+            }
+        }
+        if err := tx.Where("source_id = ? OR target_id = ?", defectID, defectID).Delete(&models.DefectRelation{}).Error; err != nil {
+            return err
+        }
+
+        if err := tx.Exec("DELETE FROM defect_labels WHERE defect_id = ?", defectID).Error; err != nil {
+            return err
+        }
+        return tx.Delete(&models.Defect{}, defectID).Error
+    })
+}
+
+// purgeProject удаляет проект вместе с его справочниками и настройками
+func purgeProject(db *gorm.DB, projectID uint) error {
+    return db.Transaction(func(tx *gorm.DB) error {
+        tx = tx.Unscoped()
+
+        if err := tx.Exec(`DELETE FROM business_holidays WHERE calendar_id IN
+            (SELECT id FROM business_calendars WHERE project_id = ?)`, projectID).Error; err != nil {
+            return err
+        }
+
+        if err := tx.Exec(`DELETE FROM project_contractor_categories WHERE project_contractor_id IN
+            (SELECT id FROM project_contractors WHERE project_id = ?)`, projectID).Error; err != nil {
+            return err
+        }
+
+        if err := tx.Exec(`DELETE FROM inspection_items WHERE round_id IN
+            (SELECT id FROM inspection_rounds WHERE project_id = ?)`, projectID).Error; err != nil {
+            return err
+        }
+
+        for _, model := range []interface{}{
+            &models.DefectEvent{},
+            &models.EscalationRule{},
+            &models.InspectionRound{},
+            &models.InspectionSchedule{},
+            &models.ProjectContractor{},
+            &models.ProjectMember{},
+            &models.ProjectWatcher{},
+            &models.BusinessCalendar{},
+            &models.SLAPolicy{},
+            &models.CustomField{},
+            &models.Label{},
+            &models.CategoryAssignee{},
+        } {
+            if err := tx.Where("project_id = ?", projectID).Delete(model).Error; err != nil {
+                return err
+            }
+        }
+
+        // Иерархии мест и категорий ссылаются сами на себя
+        for _, table := range []string{"locations", "categories"} {
+            if err := tx.Exec("UPDATE "+table+" SET parent_id = NULL WHERE project_id = ?", projectID).Error; err != nil {
+                return err
+            }
+            if err := tx.Exec("DELETE FROM "+table+" WHERE project_id = ?", projectID).Error; err != nil {
+                return err
+            }
+        }
+
+        return tx.Delete(&models.Project{}, projectID).Error
+    })
+}
@@ -0,0 +1,309 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"project-defect-service/handlers"
+	"project-defect-service/models"
+
+	"github.com/go-resty/resty/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Ключ advisory-блокировки: один проход планировщика одновременно на все реплики
+const advisoryLockKey int64 = 0x64656665637473 // "defects"
+
+const dueAtSQL = models.DefectDueAtSQL
+
+// Scheduler периодически напоминает о сроках, применяет правила эскалации,
+// создает регулярные обходы и очищает корзину от записей старше срока хранения
+type Scheduler struct {
+    DB                *gorm.DB
+    Interval          time.Duration
+    ReminderLead      time.Duration
+    TrashRetention    time.Duration
+    AuthServiceURL    string
+    ContentServiceURL string
+    ServiceToken      string
+    Client            *resty.Client
+}
+
+func New(db *gorm.DB, interval, reminderLead, trashRetention time.Duration, authServiceURL, contentServiceURL, serviceToken string) *Scheduler {
+    client := resty.New()
+    client.SetTimeout(30 * time.Second)
+    return &Scheduler{
+        DB:                db,
+        Interval:          interval,
+        ReminderLead:      reminderLead,
+        TrashRetention:    trashRetention,
+        AuthServiceURL:    authServiceURL,
+        ContentServiceURL: contentServiceURL,
+        ServiceToken:      serviceToken,
+        Client:            client,
+    }
+}
+
+// Start запускает планировщик в фоне до отмены ctx
+func (s *Scheduler) Start(ctx context.Context) {
+    go func() {
+        ticker := time.NewTicker(s.Interval)
+        defer ticker.Stop()
+        
+        for {
+            if err := s.RunOnce(ctx); err != nil {
+                log.Printf("Scheduler run failed: %v", err)
+            }
+            select {
+            case <-ctx.Done():
+                return
+            case <-ticker.C:
+            }
+        }
+    }()
+}
+
+// RunOnce выполняет один проход, если блокировку не держит другая реплика
+func (s *Scheduler) RunOnce(ctx context.Context) error {
+    // Сессионная блокировка живет на соединении, поэтому весь проход идет через одно соединение
+    return s.DB.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+        var locked bool
+        if err := conn.Raw("SELECT pg_try_advisory_lock(?)", advisoryLockKey).Scan(&locked).Error; err != nil {
+            return err
+        }
+        if !locked {
+            return nil
+        }
+        defer conn.Exec("SELECT pg_advisory_unlock(?)", advisoryLockKey)
+        
+        now := time.Now().UTC()
+        if err := s.sendReminders(conn, now); err != nil {
+            return err
+        }
+        if err := s.applyEscalationRules(conn, now); err != nil {
+            return err
+        }
+        if err := s.createInspectionRounds(conn, now); err != nil {
+            return err
+        }
+        return s.purgeTrash(conn, now)
+    })
+}
+
+// dueDefect - открытый дефект со сроком
+type dueDefect struct {
+    models.Defect
+    DueAt time.Time
+}
+
+func (s *Scheduler) findDueDefects(db *gorm.DB, condition string, args ...interface{}) ([]dueDefect, error) {
+    var defects []dueDefect
+    err := db.Model(&models.Defect{}).
+        Select("defects.*, "+dueAtSQL+" AS due_at").
+        Where("defects.status IN ?", []models.DefectStatus{models.StatusNew, models.StatusInProgress, models.StatusOnReview}).
+        Where(dueAtSQL+" IS NOT NULL").
+        // Архивные проекты только для чтения: ни напоминаний, ни эскалаций
+        Where("defects.project_id IN (SELECT id FROM projects WHERE phase <> ? AND deleted_at IS NULL)", models.PhaseArchived).
+        Where(condition, args...).
+        Scan(&defects).Error
+    return defects, err
+}
+
+// sendReminders - события о приближении срока и о просрочке для исполнителей
+func (s *Scheduler) sendReminders(db *gorm.DB, now time.Time) error {
+    approaching, err := s.findDueDefects(db, dueAtSQL+" > ? AND "+dueAtSQL+" <= ?", now, now.Add(s.ReminderLead))
+    if err != nil {
+        return err
+    }
+    for _, defect := range approaching {
+        event := newEvent(&defect, nil, models.EventDeadlineApproaching,
+            fmt.Sprintf("Defect %s is due at %s", defect.Key, defect.DueAt.Format(time.RFC3339)))
+        if _, err := emitEvent(db, event); err != nil {
+            return err
+        }
+    }
+    
+    overdue, err := s.findDueDefects(db, dueAtSQL+" <= ?", now)
+    if err != nil {
+        return err
+    }
+    for _, defect := range overdue {
+        event := newEvent(&defect, nil, models.EventOverdue,
+            fmt.Sprintf("Defect %s is overdue since %s", defect.Key, defect.DueAt.Format(time.RFC3339)))
+        if _, err := emitEvent(db, event); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+func (s *Scheduler) applyEscalationRules(db *gorm.DB, now time.Time) error {
+    var rules []models.EscalationRule
+    if err := db.Where("enabled = ?", true).Order("id").Find(&rules).Error; err != nil {
+        return err
+    }
+    
+    // Исполнители правил reassign запрашиваются в auth-service один раз за проход
+    users := map[uint]*authUser{}
+    for i := range rules {
+        rule := &rules[i]
+        offset := time.Duration(rule.OffsetHours) * time.Hour
+        
+        query := db.Where("defects.project_id = ?", rule.ProjectID)
+        if rule.Priority != nil {
+            query = query.Where("defects.priority = ?", *rule.Priority)
+        }
+        
+        var defects []dueDefect
+        var err error
+        if rule.Trigger == models.TriggerBeforeDeadline {
+            defects, err = s.findDueDefects(query, dueAtSQL+" > ? AND "+dueAtSQL+" <= ?", now, now.Add(offset))
+        } else {
+            defects, err = s.findDueDefects(query, dueAtSQL+" <= ?", now.Add(-offset))
+        }
+        if err != nil {
+            return err
+        }
+        
+        for j := range defects {
+            if err := s.escalate(db, rule, &defects[j], users); err != nil {
+                log.Printf("Escalation rule %d failed for defect %d: %v", rule.ID, defects[j].ID, err)
+            }
+        }
+    }
+    return nil
+}
+
+// escalate применяет действие правила к дефекту. Событие и изменения пишутся
+// в одной транзакции, повторный проход по тому же сроку ничего не меняет.
+// Повышение приоритета пересчитывает сроки SLA, как ручное изменение приоритета.
+// Недопустимый исполнитель не назначается: вместо эскалации пишется событие
+// escalation_skipped с причиной.
+func (s *Scheduler) escalate(db *gorm.DB, rule *models.EscalationRule, defect *dueDefect, users map[uint]*authUser) error {
+    return db.Transaction(func(tx *gorm.DB) error {
+        skipReason := ""
+        if rule.Action == models.ActionReassign && rule.AssigneeID != nil {
+            reason, err := s.checkReassign(tx, &defect.Defect, *rule.AssigneeID, users)
+            if err != nil {
+                return err
+            }
+            skipReason = reason
+        }
+        
+        event := newEvent(defect, rule, models.EventEscalated, fmt.Sprintf("Escalation rule %q fired for defect %s", rule.Name, defect.Key))
+        if skipReason != "" {
+            event.Type = models.EventEscalationSkipped
+            event.Message = fmt.Sprintf("Escalation rule %q skipped for defect %s: %s", rule.Name, defect.Key, skipReason)
+        }
+        created, err := emitEvent(tx, event)
+        if err != nil || !created {
+            return err
+        }
+        if skipReason != "" {
+            log.Printf("Escalation rule %d skipped for defect %d: %s", rule.ID, defect.ID, skipReason)
+            return nil
+        }
+        
+        switch rule.Action {
+        case models.ActionRaisePriority:
+            next := defect.Priority.NextPriority()
+            if next == defect.Priority {
+                return nil
+            }
+            updated := defect.Defect
+            updated.Priority = next
+            if err := handlers.ApplySLA(tx, &updated, false); err != nil {
+                return err
+            }
+            if err := recordSystemChange(tx, defect.ID, "priority", string(defect.Priority), string(next)); err != nil {
+                return err
+            }
+            if oldDeadline, newDeadline := formatDeadline(defect.Deadline), formatDeadline(updated.Deadline); oldDeadline != newDeadline {
+                if err := recordSystemChange(tx, defect.ID, "deadline", oldDeadline, newDeadline); err != nil {
+                    return err
+                }
+            }
+            return tx.Model(&models.Defect{}).Where("id = ?", defect.ID).Updates(map[string]interface{}{
+                "priority":       next,
+                "sla_policy_id":  updated.SLAPolicyID,
+                "response_due":   updated.ResponseDue,
+                "resolution_due": updated.ResolutionDue,
+                "deadline":       updated.Deadline,
+                "version":        gorm.Expr("version + 1"),
+            }).Error
+        case models.ActionReassign:
+            if rule.AssigneeID == nil || (defect.AssigneeID != nil && *defect.AssigneeID == *rule.AssigneeID) {
+                return nil
+            }
+            oldAssignee := "none"
+            if defect.AssigneeID != nil {
+                oldAssignee = fmt.Sprintf("%d", *defect.AssigneeID)
+            }
+            if err := recordSystemChange(tx, defect.ID, "assignee", oldAssignee, fmt.Sprintf("%d", *rule.AssigneeID)); err != nil {
+                return err
+            }
+            // Новый исполнитель подписывается на дефект, как при ручном назначении
+            watcher := models.DefectWatcher{DefectID: defect.ID, UserID: *rule.AssigneeID, Reason: models.WatchAssignee}
+            if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&watcher).Error; err != nil {
+                return err
+            }
+            return tx.Model(&models.Defect{}).Where("id = ?", defect.ID).Updates(map[string]interface{}{
+                "assignee_id": *rule.AssigneeID,
+                "version":     gorm.Expr("version + 1"),
+            }).Error
+        }
+        return nil
+    })
+}
+
+func newEvent(defect *dueDefect, rule *models.EscalationRule, eventType, message string) *models.DefectEvent {
+    event := &models.DefectEvent{
+        DefectID:    defect.ID,
+        ProjectID:   defect.ProjectID,
+        Type:        eventType,
+        RecipientID: defect.AssigneeID,
+        Message:     message,
+    }
+    // Событие повторяется только при новом сроке
+    due := defect.DueAt.UTC().Format(time.RFC3339)
+    if rule != nil && rule.Action == models.ActionRaisePriority {
+        // Повышение приоритета само сдвигает срок по SLA: правило срабатывает
+        // для дефекта один раз, иначе оно поднимало бы приоритет каждый проход
+        event.RuleID = &rule.ID
+        event.DedupKey = fmt.Sprintf("rule:%d:%d", rule.ID, defect.ID)
+    } else if rule != nil {
+        event.RuleID = &rule.ID
+        event.DedupKey = fmt.Sprintf("rule:%d:%d:%s", rule.ID, defect.ID, due)
+    } else {
+        event.DedupKey = fmt.Sprintf("%s:%d:%s", eventType, defect.ID, due)
+    }
+    return event
+}
+
+// emitEvent сохраняет событие; false - такое событие уже было
+func emitEvent(db *gorm.DB, event *models.DefectEvent) (bool, error) {
+    result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(event)
+    return result.RowsAffected > 0, result.Error
+}
+
+func recordSystemChange(tx *gorm.DB, defectID uint, field, oldValue, newValue string) error {
+    history := models.DefectHistory{
+        DefectID:    defectID,
+        Field:       field,
+        OldValue:    oldValue,
+        NewValue:    newValue,
+        ChangedBy:   models.SystemActorID,
+        ChangeSetID: models.NewChangeSetID(),
+    }
+    return tx.Create(&history).Error
+}
+
+func formatDeadline(deadline *models.Date) string {
+    if deadline == nil || deadline.IsZero() {
+        return "none"
+    }
+    return deadline.Format("2006-01-02")
+}
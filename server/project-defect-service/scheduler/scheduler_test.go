@@ -0,0 +1,216 @@
+package scheduler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"project-defect-service/database/dbtest"
+	"project-defect-service/models"
+
+	"gorm.io/gorm"
+)
+
+// authUsers - заглушка внутреннего API auth-service с заданными пользователями
+func authUsers(t *testing.T, users map[string]string) *httptest.Server {
+    t.Helper()
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.Header.Get("X-Service-Token") != "service-token" {
+            w.WriteHeader(http.StatusUnauthorized)
+            return
+        }
+        user, ok := users[strings.TrimPrefix(r.URL.Path, "/internal/users/")]
+        if !ok {
+            w.WriteHeader(http.StatusNotFound)
+            return
+        }
+        w.Header().Set("Content-Type", "application/json")
+        fmt.Fprintf(w, `{"success": true, "data": {"user": %s}}`, user)
+    }))
+    t.Cleanup(server.Close)
+    return server
+}
+
+// seedOverdueDefect - дефект в работе, созданный трое суток назад, со сроком по SLA medium (48 ч)
+func seedOverdueDefect(t *testing.T, db *gorm.DB, key string) (*models.Project, *models.Defect) {
+    t.Helper()
+    project := models.Project{Name: "Project " + key, Key: key, ManagerID: 1, Phase: models.PhaseConstruction}
+    if err := db.Create(&project).Error; err != nil {
+        t.Fatal(err)
+    }
+    policies := []models.SLAPolicy{
+        {ProjectID: project.ID, Priority: models.PriorityMedium, ResolutionHours: 48},
+        {ProjectID: project.ID, Priority: models.PriorityHigh, ResolutionHours: 8},
+    }
+    if err := db.Create(&policies).Error; err != nil {
+        t.Fatal(err)
+    }
+
+    createdAt := time.Now().UTC().Add(-72 * time.Hour).Truncate(time.Second)
+    resolutionDue := createdAt.Add(48 * time.Hour)
+    defect := models.Defect{
+        Title:         "Leak",
+        Status:        models.StatusInProgress,
+        Priority:      models.PriorityMedium,
+        ProjectID:     project.ID,
+        Number:        1,
+        Key:           key + "-1",
+        AuthorID:      1,
+        SLAPolicyID:   &policies[0].ID,
+        ResolutionDue: &resolutionDue,
+        Deadline:      &models.Date{Time: time.Date(resolutionDue.Year(), resolutionDue.Month(), resolutionDue.Day(), 0, 0, 0, 0, time.UTC)},
+    }
+    defect.CreatedAt = createdAt
+    if err := db.Create(&defect).Error; err != nil {
+        t.Fatal(err)
+    }
+    return &project, &defect
+}
+
+func TestEscalationRaisesPriorityOnceAndRecomputesSLA(t *testing.T) {
+    db := dbtest.Open(t)
+    project, defect := seedOverdueDefect(t, db, "TWR")
+    rule := models.EscalationRule{
+        ProjectID: project.ID,
+        Name:      "Raise overdue",
+        Trigger:   models.TriggerOverdue,
+        Action:    models.ActionRaisePriority,
+        Enabled:   true,
+    }
+    if err := db.Create(&rule).Error; err != nil {
+        t.Fatal(err)
+    }
+    s := New(db, time.Minute, time.Hour, time.Hour, "", "", "service-token")
+
+    // Срок после повышения все еще прошел, но правило срабатывает для дефекта один раз
+    for pass := 0; pass < 2; pass++ {
+        if err := s.applyEscalationRules(db, time.Now().UTC()); err != nil {
+            t.Fatalf("pass %d: %v", pass, err)
+        }
+    }
+
+    var escalated models.Defect
+    if err := db.First(&escalated, defect.ID).Error; err != nil {
+        t.Fatal(err)
+    }
+    if escalated.Priority != models.PriorityHigh {
+        t.Fatalf("priority = %s, want %s", escalated.Priority, models.PriorityHigh)
+    }
+    if escalated.SLAPolicyID == nil || *escalated.SLAPolicyID == *defect.SLAPolicyID {
+        t.Errorf("sla_policy_id = %v, want the high priority policy", escalated.SLAPolicyID)
+    }
+    wantDue := defect.CreatedAt.Add(8 * time.Hour)
+    if escalated.ResolutionDue == nil || !escalated.ResolutionDue.Equal(wantDue) {
+        t.Errorf("resolution_due = %v, want %v", escalated.ResolutionDue, wantDue)
+    }
+    if escalated.Version != defect.Version+1 {
+        t.Errorf("version = %d, want %d", escalated.Version, defect.Version+1)
+    }
+
+    var changes []models.DefectHistory
+    if err := db.Where("defect_id = ?", defect.ID).Order("id").Find(&changes).Error; err != nil {
+        t.Fatal(err)
+    }
+    var fields []string
+    for _, change := range changes {
+        fields = append(fields, fmt.Sprintf("%s:%s->%s", change.Field, change.OldValue, change.NewValue))
+        if change.ChangedBy != models.SystemActorID {
+            t.Errorf("change %s by %d, want system actor", change.Field, change.ChangedBy)
+        }
+    }
+    wantFields := []string{
+        "priority:medium->high",
+        fmt.Sprintf("deadline:%s->%s", defect.Deadline.Format("2006-01-02"), wantDue.Format("2006-01-02")),
+    }
+    if fmt.Sprint(fields) != fmt.Sprint(wantFields) {
+        t.Errorf("history = %v, want %v", fields, wantFields)
+    }
+
+    var events int64
+    db.Model(&models.DefectEvent{}).Where("defect_id = ? AND type = ?", defect.ID, models.EventEscalated).Count(&events)
+    if events != 1 {
+        t.Errorf("escalated events = %d, want 1", events)
+    }
+}
+
+func TestEscalationReassignValidatesAssignee(t *testing.T) {
+    auth := authUsers(t, map[string]string{
+        "5": `{"id": 5, "role_name": "engineer", "is_active": true}`,
+        "7": `{"id": 7, "role_name": "engineer", "is_active": false}`,
+        "8": `{"id": 8, "role_name": "engineer", "is_active": true}`,
+        "9": `{"id": 9, "role_name": "contractor", "is_active": true}`,
+    })
+
+    tests := []struct {
+        name       string
+        assigneeID uint
+        assigned   bool
+    }{
+        {"engineer of the project team", 5, true},
+        {"deactivated engineer", 7, false},
+        {"engineer outside the team", 8, false},
+        {"contractor for an in-house defect", 9, false},
+        {"unknown user", 10, false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            db := dbtest.Open(t)
+            project, defect := seedOverdueDefect(t, db, "TWR")
+            for _, userID := range []uint{5, 7, 9} {
+                if err := db.Create(&models.ProjectMember{ProjectID: project.ID, UserID: userID}).Error; err != nil {
+                    t.Fatal(err)
+                }
+            }
+            rule := models.EscalationRule{
+                ProjectID:  project.ID,
+                Name:       "Hand over",
+                Trigger:    models.TriggerOverdue,
+                Action:     models.ActionReassign,
+                AssigneeID: &tt.assigneeID,
+                Enabled:    true,
+            }
+            if err := db.Create(&rule).Error; err != nil {
+                t.Fatal(err)
+            }
+
+            s := New(db, time.Minute, time.Hour, time.Hour, auth.URL, "", "service-token")
+            if err := s.applyEscalationRules(db, time.Now().UTC()); err != nil {
+                t.Fatal(err)
+            }
+
+            var stored models.Defect
+            if err := db.First(&stored, defect.ID).Error; err != nil {
+                t.Fatal(err)
+            }
+            var watchers int64
+            db.Model(&models.DefectWatcher{}).Where("defect_id = ? AND user_id = ?", defect.ID, tt.assigneeID).Count(&watchers)
+            var event models.DefectEvent
+            if err := db.Where("defect_id = ? AND rule_id = ?", defect.ID, rule.ID).First(&event).Error; err != nil {
+                t.Fatalf("no escalation event: %v", err)
+            }
+
+            if tt.assigned {
+                if stored.AssigneeID == nil || *stored.AssigneeID != tt.assigneeID {
+                    t.Errorf("assignee = %v, want %d", stored.AssigneeID, tt.assigneeID)
+                }
+                if watchers != 1 {
+                    t.Errorf("new assignee watches = %d, want 1", watchers)
+                }
+                if event.Type != models.EventEscalated {
+                    t.Errorf("event type = %s, want %s", event.Type, models.EventEscalated)
+                }
+                return
+            }
+            if stored.AssigneeID != nil || watchers != 0 {
+                t.Errorf("invalid assignee was set: assignee %v, watches %d", stored.AssigneeID, watchers)
+            }
+            if event.Type != models.EventEscalationSkipped {
+                t.Errorf("event type = %s, want %s", event.Type, models.EventEscalationSkipped)
+            }
+        })
+    }
+}